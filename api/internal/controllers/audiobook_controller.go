@@ -3,6 +3,9 @@ package controllers
 import (
 	"book_boy/api/internal/service"
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/repository"
+	"context"
 	"net/http"
 	"strconv"
 
@@ -12,10 +15,11 @@ import (
 type AudiobookController struct {
 	Service         service.AudiobookService
 	ProgressService service.ProgressService
+	Tx              repository.TxManager
 }
 
-func NewAudiobookController(service service.AudiobookService, pgService service.ProgressService) *AudiobookController {
-	return &AudiobookController{Service: service, ProgressService: pgService}
+func NewAudiobookController(service service.AudiobookService, pgService service.ProgressService, tx repository.TxManager) *AudiobookController {
+	return &AudiobookController{Service: service, ProgressService: pgService, Tx: tx}
 }
 
 func (ac *AudiobookController) RegisterRoutes(r gin.IRouter) {
@@ -27,13 +31,15 @@ func (ac *AudiobookController) RegisterRoutes(r gin.IRouter) {
 		audiobooks.PUT("/:id", ac.Update)
 		audiobooks.DELETE("/:id", ac.Delete)
 		audiobooks.GET("/search", ac.GetSimilarTitles)
+		audiobooks.GET("/:id/history", ac.GetHistory)
+		audiobooks.POST("/:id/revert/:version", ac.Revert)
 	}
 }
 
 func (ac *AudiobookController) GetAll(c *gin.Context) {
-	result, err := ac.Service.GetAll()
+	result, err := ac.Service.GetAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -43,65 +49,69 @@ func (ac *AudiobookController) GetAll(c *gin.Context) {
 func (ac *AudiobookController) GetByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		c.Error(errors.ErrInvalidInput("invalid ID"))
 		return
 	}
-	result, err := ac.Service.GetByID(id)
+	result, err := ac.Service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	if result == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		c.Error(errors.NotFound("not found"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": result})
 }
 
 func (ac *AudiobookController) Create(c *gin.Context) {
+	ctx := c.Request.Context()
 	var audiobook domain.Audiobook
-	var progress domain.Progress
 
-	if err := c.ShouldBindJSON(&audiobook); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &audiobook); err != nil {
+		c.Error(err)
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
-	id, err := ac.Service.Create(&audiobook)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	pgID := 0
+	if pgIDStr := c.Query("pgId"); pgIDStr != "" {
+		var err error
+		pgID, err = strconv.Atoi(pgIDStr)
+		if err != nil {
+			c.Error(errors.ErrInvalidInput("invalid audiobook id"))
+			return
+		}
 	}
 
-	pgIDStr := c.Query("pgId")
-	if pgIDStr != "" {
-		pgID, err := strconv.Atoi(pgIDStr)
+	// The audiobook and the progress tracking it land together or not at all.
+	var id int
+	err := ac.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = ac.Service.Create(ctx, &audiobook)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid audiobook id"})
-			return
+			return err
 		}
 
-		if err := ac.ProgressService.SetAudiobook(pgID, id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		if pgID != 0 {
+			_, err := ac.ProgressService.SetAudiobook(ctx, pgID, id, domain.UpdateCondition{})
+			return err
 		}
-	} else {
-		progress = domain.Progress{
+		progress := domain.Progress{
 			UserID:      userID.(int),
 			AudiobookID: &id,
 		}
-
-		_, err = ac.ProgressService.Create(&progress)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		_, err = ac.ProgressService.Create(ctx, &progress)
+		return err
+	})
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
 	audiobook.ID = id
@@ -111,19 +121,54 @@ func (ac *AudiobookController) Create(c *gin.Context) {
 func (ac *AudiobookController) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		c.Error(errors.ErrInvalidInput("invalid ID"))
 		return
 	}
 
 	var audiobook domain.Audiobook
-	if err := c.ShouldBindJSON(&audiobook); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &audiobook); err != nil {
+		c.Error(err)
 		return
 	}
 	audiobook.ID = id
 
-	if err := ac.Service.Update(&audiobook); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := ac.Service.Update(c.Request.Context(), &audiobook, c.GetInt("user_id")); err != nil {
+		respondAudiobookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": audiobook})
+}
+
+func (ac *AudiobookController) GetHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+
+	history, err := ac.Service.GetHistory(c.Request.Context(), id)
+	if err != nil {
+		respondAudiobookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+func (ac *AudiobookController) Revert(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid version"))
+		return
+	}
+
+	audiobook, err := ac.Service.Revert(c.Request.Context(), id, version, c.GetInt("user_id"))
+	if err != nil {
+		respondAudiobookError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": audiobook})
@@ -132,11 +177,11 @@ func (ac *AudiobookController) Update(c *gin.Context) {
 func (ac *AudiobookController) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		c.Error(errors.ErrInvalidInput("invalid ID"))
 		return
 	}
-	if err := ac.Service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := ac.Service.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -145,20 +190,28 @@ func (ac *AudiobookController) Delete(c *gin.Context) {
 func (ac *AudiobookController) GetSimilarTitles(c *gin.Context) {
 	title := c.Query("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing title query parameter"})
+		c.Error(errors.ErrInvalidInput("missing title query parameter"))
 		return
 	}
 
-	audiobooks, err := ac.Service.GetSimilarTitles(title)
+	audiobooks, err := ac.Service.GetSimilarTitles(c.Request.Context(), title)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	if audiobooks == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "books not found"})
+		c.Error(errors.NotFound("books not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": audiobooks})
 }
+
+func respondAudiobookError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("audiobook or version not found")
+	}
+	c.Error(err)
+}
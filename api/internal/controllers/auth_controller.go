@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/service"
 	"net/http"
 	"os"
@@ -27,15 +28,16 @@ func (ac *AuthController) RegisterRoutes(r gin.IRouter) {
 }
 
 func (ac *AuthController) Register(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req domain.RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	user, err := ac.Service.Register(&req)
+	user, err := ac.Service.Register(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -43,9 +45,9 @@ func (ac *AuthController) Register(c *gin.Context) {
 		Email:    req.Email,
 		Password: req.Password,
 	}
-	token, _, err := ac.Service.Login(loginReq)
+	token, _, err := ac.Service.Login(ctx, loginReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "registration successful but login failed"})
+		c.Error(err)
 		return
 	}
 
@@ -59,14 +61,14 @@ func (ac *AuthController) Register(c *gin.Context) {
 
 func (ac *AuthController) Login(c *gin.Context) {
 	var req domain.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	token, user, err := ac.Service.Login(&req)
+	token, user, err := ac.Service.Login(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -82,16 +84,16 @@ func (ac *AuthController) DemoLogin(c *gin.Context) {
 	demoEmail := os.Getenv("DEMO_USER_EMAIL")
 	demoPassword := os.Getenv("DEMO_USER_PASSWORD")
 	if demoEmail == "" || demoPassword == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "demo account not available"})
+		c.Error(errors.NotFound("demo account not available"))
 		return
 	}
 
-	token, user, err := ac.Service.Login(&domain.LoginRequest{
+	token, user, err := ac.Service.Login(c.Request.Context(), &domain.LoginRequest{
 		Email:    demoEmail,
 		Password: demoPassword,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "demo login failed"})
+		c.Error(err)
 		return
 	}
 
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"book_boy/api/internal/errors"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindJSON binds the request body into obj. Failed binding rules come back as
+// one field problem each, named by the field's JSON key, instead of the
+// validator's single line of Go struct names.
+func bindJSON(c *gin.Context, obj any) error {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		return bindingError(obj, err)
+	}
+	return nil
+}
+
+func bindingError(obj any, err error) error {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		fields := make(errors.ValidationErrors, 0, len(invalid))
+		for _, fe := range invalid {
+			fields = append(fields, errors.ValidationError{
+				Field:   jsonFieldPath(reflect.TypeOf(obj), fe.StructNamespace(), fe.Field()),
+				Message: ruleMessage(fe),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return errors.ErrInvalidField(typeErr.Field, "cannot be a "+typeErr.Value)
+	}
+	return errors.ErrInvalidInput(err.Error())
+}
+
+// jsonFieldPath turns a validator namespace such as "Shelf.Items[0].Name"
+// into the JSON path "items[0].name" by following obj's struct tags. Fields
+// without a JSON name keep their Go name; fallback is used if the path can't
+// be followed.
+func jsonFieldPath(t reflect.Type, namespace string, fallback string) string {
+	parts := strings.Split(namespace, ".")
+	var path []string
+	for _, part := range parts[1:] {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return fallback
+		}
+
+		name, index, _ := strings.Cut(part, "[")
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return fallback
+		}
+		t = field.Type
+
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && tag == "" {
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if index != "" {
+			tag += "[" + index
+		}
+		path = append(path, tag)
+	}
+	if len(path) == 0 {
+		return fallback
+	}
+	return strings.Join(path, ".")
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fe.Param() + sizeUnit(fe.Kind())
+	case "max":
+		return "must be at most " + fe.Param() + sizeUnit(fe.Kind())
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// sizeUnit says what min and max count for kinds where it isn't the value.
+func sizeUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindingTestItem struct {
+	Label string `json:"label" binding:"required"`
+}
+
+type bindingTestRequest struct {
+	Name   string            `json:"name" binding:"required,min=3"`
+	Kind   string            `json:"kind" binding:"omitempty,oneof=book audiobook"`
+	Target int               `json:"target" binding:"omitempty,min=1"`
+	Tags   []string          `json:"tags" binding:"max=2"`
+	Items  []bindingTestItem `json:"items" binding:"dive"`
+}
+
+func TestBindJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []middleware.FieldProblem
+		detail string
+	}{
+		{
+			name: "valid body",
+			body: `{"name": "Dune"}`,
+		},
+		{
+			name: "every failed rule becomes a field problem",
+			body: `{"name": "Du", "kind": "film", "target": -1, "tags": ["a", "b", "c"]}`,
+			fields: []middleware.FieldProblem{
+				{Field: "name", Message: "must be at least 3 characters"},
+				{Field: "kind", Message: "must be one of book, audiobook"},
+				{Field: "target", Message: "must be at least 1"},
+				{Field: "tags", Message: "must be at most 2 items"},
+			},
+		},
+		{
+			name:   "missing required field",
+			body:   `{}`,
+			fields: []middleware.FieldProblem{{Field: "name", Message: "is required"}},
+		},
+		{
+			name:   "nested fields keep their index",
+			body:   `{"name": "Dune", "items": [{"label": "x"}, {}]}`,
+			fields: []middleware.FieldProblem{{Field: "items[1].label", Message: "is required"}},
+		},
+		{
+			name:   "wrong JSON type",
+			body:   `{"name": "Dune", "target": "ten"}`,
+			fields: []middleware.FieldProblem{{Field: "target", Message: "cannot be a string"}},
+		},
+		{
+			name:   "malformed JSON",
+			body:   `{"name": `,
+			detail: "unexpected EOF",
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			var req bindingTestRequest
+			err := bindJSON(c, &req)
+			if tt.fields == nil && tt.detail == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.IsValidationError(err) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+
+			problem := middleware.ProblemFor(err)
+			if problem.Status != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", problem.Status)
+			}
+			if tt.detail != "" && problem.Detail != tt.detail {
+				t.Errorf("Detail = %q, want %q", problem.Detail, tt.detail)
+			}
+			if !reflect.DeepEqual(problem.Errors, tt.fields) {
+				t.Errorf("Errors = %+v, want %+v", problem.Errors, tt.fields)
+			}
+		})
+	}
+}
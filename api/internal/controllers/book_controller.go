@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
 	"book_boy/api/internal/service"
+	"context"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,12 +16,14 @@ import (
 type BookController struct {
 	Service         service.BookService
 	ProgressService service.ProgressService
+	Tx              repository.TxManager
 }
 
-func NewBookController(service service.BookService, pgService service.ProgressService) *BookController {
+func NewBookController(service service.BookService, pgService service.ProgressService, tx repository.TxManager) *BookController {
 	return &BookController{
 		Service:         service,
 		ProgressService: pgService,
+		Tx:              tx,
 	}
 }
 
@@ -33,13 +37,15 @@ func (bc *BookController) RegisterRoutes(r gin.IRouter) {
 		books.DELETE("/:id", bc.Delete)
 		books.GET("/search", bc.GetSimilarTitles)
 		books.GET("/filter", bc.FilterBooks)
+		books.GET("/:id/history", bc.GetHistory)
+		books.POST("/:id/revert/:version", bc.Revert)
 	}
 }
 
 func (bc *BookController) GetAll(c *gin.Context) {
-	books, err := bc.Service.GetAll()
+	books, err := bc.Service.GetAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": books})
@@ -48,17 +54,17 @@ func (bc *BookController) GetAll(c *gin.Context) {
 func (bc *BookController) GetByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
 		return
 	}
 
-	book, err := bc.Service.GetByID(id)
+	book, err := bc.Service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	if book == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "book not found"})
+		c.Error(errors.NotFound("book not found"))
 		return
 	}
 
@@ -66,98 +72,90 @@ func (bc *BookController) GetByID(c *gin.Context) {
 }
 
 func (bc *BookController) Create(c *gin.Context) {
+	ctx := c.Request.Context()
 	var book domain.Book
-	var progress domain.Progress
 
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &book); err != nil {
+		c.Error(err)
 		return
 	}
 
 	book.ISBN = strings.ReplaceAll(strings.ReplaceAll(book.ISBN, "-", ""), " ", "")
 
 	if book.ISBN == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "isbn is required"})
+		c.Error(errors.ErrInvalidInput("isbn is required"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
-	filter := repository.BookFilter{ISBN: &book.ISBN}
-	existingBooks, err := bc.Service.FilterBooks(filter)
+	uid := userID.(int)
+	skipProgress := c.Query("skipProgress") == "true"
 
-	var id int
-	if err == nil && len(existingBooks) > 0 {
-		id = existingBooks[0].ID
-	} else {
-		id, err = bc.Service.Create(&book)
+	pgID := 0
+	if pgIDStr := c.Query("pgId"); pgIDStr != "" && !skipProgress {
+		var err error
+		pgID, err = strconv.Atoi(pgIDStr)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(errors.ErrInvalidInput("invalid book id"))
 			return
 		}
 	}
 
-	uid := userID.(int)
-
-	skipProgress := c.Query("skipProgress") == "true"
-	if skipProgress {
-		savedBook, err := bc.Service.GetByID(id)
-		if err != nil || savedBook == nil {
-			book.ID = id
-			c.JSON(http.StatusCreated, gin.H{"data": book})
-			return
+	// A new book and the progress tracking it are created together, so a
+	// failed progress write doesn't leave an orphaned book behind.
+	var id int
+	err := bc.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		filter := repository.BookFilter{ISBN: &book.ISBN}
+		existingBooks, err := bc.Service.FilterBooks(ctx, filter)
+		if err == nil && len(existingBooks) > 0 {
+			id = existingBooks[0].ID
+		} else {
+			id, err = bc.Service.Create(ctx, &book)
+			if err != nil {
+				return err
+			}
 		}
-		c.JSON(http.StatusCreated, gin.H{"data": savedBook})
-		return
-	}
 
-	pgIDStr := c.Query("pgId")
-	if pgIDStr != "" {
-		pgID, err := strconv.Atoi(pgIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book id"})
-			return
+		if skipProgress {
+			return nil
 		}
-
-		if err := bc.ProgressService.SetBook(pgID, id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		if pgID != 0 {
+			_, err := bc.ProgressService.SetBook(ctx, pgID, id, domain.UpdateCondition{})
+			return err
 		}
-	} else {
+
 		progFilter := repository.ProgressFilter{
 			UserID: &uid,
 			BookID: &id,
 		}
-		existingProgress, err := bc.ProgressService.FilterProgress(progFilter)
+		existingProgress, err := bc.ProgressService.FilterProgress(ctx, progFilter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return err
 		}
-
 		if len(existingProgress) > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "progress already exists for this book"})
-			return
+			return errors.Conflict("progress already exists for this book")
 		}
 
 		page := 1
-		progress = domain.Progress{
+		progress := domain.Progress{
 			UserID:   uid,
 			BookID:   &id,
 			BookPage: &page,
 		}
-
-		_, err = bc.ProgressService.Create(&progress)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		_, err = bc.ProgressService.Create(ctx, &progress)
+		return err
+	})
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	savedBook, err := bc.Service.GetByID(id)
+	savedBook, err := bc.Service.GetByID(ctx, id)
 	if err != nil || savedBook == nil {
 		book.ID = id
 		c.JSON(http.StatusCreated, gin.H{"data": book})
@@ -169,34 +167,69 @@ func (bc *BookController) Create(c *gin.Context) {
 func (bc *BookController) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
 		return
 	}
 
 	var book domain.Book
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &book); err != nil {
+		c.Error(err)
 		return
 	}
 	book.ID = id
 
-	if err := bc.Service.Update(&book); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := bc.Service.Update(c.Request.Context(), &book, c.GetInt("user_id")); err != nil {
+		respondBookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": book})
+}
+
+func (bc *BookController) GetHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+
+	history, err := bc.Service.GetHistory(c.Request.Context(), id)
+	if err != nil {
+		respondBookError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
 
+func (bc *BookController) Revert(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid version"))
+		return
+	}
+
+	book, err := bc.Service.Revert(c.Request.Context(), id, version, c.GetInt("user_id"))
+	if err != nil {
+		respondBookError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"data": book})
 }
 
 func (bc *BookController) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
 		return
 	}
 
-	if err := bc.Service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := bc.Service.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -206,18 +239,18 @@ func (bc *BookController) Delete(c *gin.Context) {
 func (bc *BookController) GetSimilarTitles(c *gin.Context) {
 	title := c.Query("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing title query parameter"})
+		c.Error(errors.ErrInvalidInput("missing title query parameter"))
 		return
 	}
 
-	books, err := bc.Service.GetSimilarTitles(title)
+	books, err := bc.Service.GetSimilarTitles(c.Request.Context(), title)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	if books == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "books not found"})
+		c.Error(errors.NotFound("books not found"))
 		return
 	}
 
@@ -245,11 +278,25 @@ func (bc *BookController) FilterBooks(c *gin.Context) {
 		}
 	}
 
-	books, err := bc.Service.FilterBooks(filter)
+	if minRatingStr := c.Query("min_rating"); minRatingStr != "" {
+		if minRating, err := strconv.ParseFloat(minRatingStr, 64); err == nil {
+			filter.MinRating = &minRating
+		}
+	}
+
+	books, err := bc.Service.FilterBooks(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch books"})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, books)
 }
+
+func respondBookError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("book or version not found")
+	}
+	c.Error(err)
+}
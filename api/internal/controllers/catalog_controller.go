@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogController struct {
+	Service service.CatalogService
+}
+
+func NewCatalogController(service service.CatalogService) *CatalogController {
+	return &CatalogController{Service: service}
+}
+
+func (cc *CatalogController) RegisterRoutes(r gin.IRouter) {
+	r.GET("/books/:id/duplicates", cc.GetBookDuplicates)
+	r.POST("/books/:id/merge", cc.MergeBooks)
+
+	r.GET("/audiobooks/:id/duplicates", cc.GetAudiobookDuplicates)
+	r.POST("/audiobooks/:id/merge", cc.MergeAudiobooks)
+}
+
+func (cc *CatalogController) GetBookDuplicates(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+
+	duplicates, err := cc.Service.FindBookDuplicates(c.Request.Context(), id)
+	if err != nil {
+		respondCatalogError(c, err, "book not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": duplicates})
+}
+
+func (cc *CatalogController) MergeBooks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+
+	var req domain.MergeRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := cc.Service.MergeBooks(c.Request.Context(), id, &req)
+	if err != nil {
+		respondCatalogError(c, err, "book not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (cc *CatalogController) GetAudiobookDuplicates(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+
+	duplicates, err := cc.Service.FindAudiobookDuplicates(c.Request.Context(), id)
+	if err != nil {
+		respondCatalogError(c, err, "audiobook not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": duplicates})
+}
+
+func (cc *CatalogController) MergeAudiobooks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+
+	var req domain.MergeRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := cc.Service.MergeAudiobooks(c.Request.Context(), id, &req)
+	if err != nil {
+		respondCatalogError(c, err, "audiobook not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func respondCatalogError(c *gin.Context, err error, notFound string) {
+	if err == errors.ErrNotFound {
+		err = errors.NotFound(notFound)
+	}
+	c.Error(err)
+}
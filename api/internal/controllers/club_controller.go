@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClubController struct {
+	Service service.ClubService
+}
+
+func NewClubController(service service.ClubService) *ClubController {
+	return &ClubController{Service: service}
+}
+
+func (cc *ClubController) RegisterRoutes(r gin.IRouter) {
+	clubs := r.Group("/clubs")
+	{
+		clubs.GET("", cc.GetAll)
+		clubs.POST("", cc.Create)
+		clubs.GET("/invites", cc.GetInvites)
+		clubs.POST("/invites/:inviteId/accept", cc.AcceptInvite)
+		clubs.POST("/invites/:inviteId/decline", cc.DeclineInvite)
+		clubs.GET("/:id", cc.GetByID)
+		clubs.PUT("/:id", cc.Update)
+		clubs.DELETE("/:id", cc.Delete)
+		clubs.GET("/:id/members", cc.GetMembers)
+		clubs.DELETE("/:id/members/:userId", cc.RemoveMember)
+		clubs.PUT("/:id/progress", cc.LinkProgress)
+		clubs.POST("/:id/invites", cc.Invite)
+		clubs.GET("/:id/schedule", cc.GetSchedule)
+		clubs.POST("/:id/checkpoints", cc.AddCheckpoint)
+		clubs.DELETE("/:id/checkpoints/:checkpointId", cc.DeleteCheckpoint)
+		clubs.GET("/:id/checkpoints/:checkpointId/posts", cc.GetPosts)
+		clubs.POST("/:id/checkpoints/:checkpointId/posts", cc.CreatePost)
+	}
+}
+
+func (cc *ClubController) GetAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	clubs, err := cc.Service.GetAllByUser(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": clubs})
+}
+
+func (cc *ClubController) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	club, err := cc.Service.GetByID(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": club})
+}
+
+func (cc *ClubController) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	var club domain.Club
+	if err := bindJSON(c, &club); err != nil {
+		c.Error(err)
+		return
+	}
+	club.OwnerID = userID.(int)
+
+	id, err := cc.Service.Create(c.Request.Context(), &club)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+
+	club.ID = id
+	c.JSON(http.StatusCreated, gin.H{"data": club})
+}
+
+func (cc *ClubController) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	var club domain.Club
+	if err := bindJSON(c, &club); err != nil {
+		c.Error(err)
+		return
+	}
+	club.ID = id
+
+	if err := cc.Service.Update(c.Request.Context(), userID.(int), &club); err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": club})
+}
+
+func (cc *ClubController) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	if err := cc.Service.Delete(c.Request.Context(), userID.(int), id); err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (cc *ClubController) GetMembers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	members, err := cc.Service.GetMembers(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+func (cc *ClubController) RemoveMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	if err := cc.Service.RemoveMember(c.Request.Context(), userID.(int), id, memberID); err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (cc *ClubController) LinkProgress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	var req domain.LinkClubProgressRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := cc.Service.LinkProgress(c.Request.Context(), userID.(int), id, req.ProgressID); err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (cc *ClubController) Invite(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	var req domain.ClubInvite
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	invite, err := cc.Service.Invite(c.Request.Context(), userID.(int), id, req.InviteeID)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": invite})
+}
+
+func (cc *ClubController) GetInvites(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	invites, err := cc.Service.GetInvites(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invites})
+}
+
+func (cc *ClubController) AcceptInvite(c *gin.Context) {
+	cc.respondToInvite(c, true)
+}
+
+func (cc *ClubController) DeclineInvite(c *gin.Context) {
+	cc.respondToInvite(c, false)
+}
+
+func (cc *ClubController) respondToInvite(c *gin.Context, accept bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	inviteID, err := strconv.Atoi(c.Param("inviteId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid invite ID"))
+		return
+	}
+
+	if err := cc.Service.RespondToInvite(c.Request.Context(), userID.(int), inviteID, accept); err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (cc *ClubController) GetSchedule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	schedule, err := cc.Service.GetSchedule(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+func (cc *ClubController) AddCheckpoint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+
+	var checkpoint domain.Checkpoint
+	if err := bindJSON(c, &checkpoint); err != nil {
+		c.Error(err)
+		return
+	}
+
+	checkpointID, err := cc.Service.AddCheckpoint(c.Request.Context(), userID.(int), id, &checkpoint)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+
+	checkpoint.ID = checkpointID
+	c.JSON(http.StatusCreated, gin.H{"data": checkpoint})
+}
+
+func (cc *ClubController) DeleteCheckpoint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+	checkpointID, err := strconv.Atoi(c.Param("checkpointId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid checkpoint ID"))
+		return
+	}
+
+	if err := cc.Service.DeleteCheckpoint(c.Request.Context(), userID.(int), id, checkpointID); err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (cc *ClubController) GetPosts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+	checkpointID, err := strconv.Atoi(c.Param("checkpointId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid checkpoint ID"))
+		return
+	}
+
+	posts, err := cc.Service.GetPosts(c.Request.Context(), userID.(int), id, checkpointID)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": posts})
+}
+
+func (cc *ClubController) CreatePost(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid club ID"))
+		return
+	}
+	checkpointID, err := strconv.Atoi(c.Param("checkpointId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid checkpoint ID"))
+		return
+	}
+
+	var post domain.ClubPost
+	if err := bindJSON(c, &post); err != nil {
+		c.Error(err)
+		return
+	}
+
+	postID, err := cc.Service.CreatePost(c.Request.Context(), userID.(int), id, checkpointID, &post)
+	if err != nil {
+		respondClubError(c, err)
+		return
+	}
+
+	post.ID = postID
+	c.JSON(http.StatusCreated, gin.H{"data": post})
+}
+
+func respondClubError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("not found")
+	case errors.ErrForbidden:
+		err = errors.Forbidden("you are not allowed to do that in this club")
+	case errors.ErrConflict:
+		err = errors.Conflict("user is already a member or the invite was already answered")
+	}
+	c.Error(err)
+}
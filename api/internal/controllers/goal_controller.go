@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GoalController struct {
+	Service service.GoalService
+}
+
+func NewGoalController(service service.GoalService) *GoalController {
+	return &GoalController{Service: service}
+}
+
+func (gc *GoalController) RegisterRoutes(r gin.IRouter) {
+	goals := r.Group("/goals")
+	{
+		goals.GET("", gc.GetAll)
+		goals.GET("/:id", gc.GetByID)
+		goals.POST("", gc.Create)
+		goals.PUT("/:id", gc.Update)
+		goals.DELETE("/:id", gc.Delete)
+	}
+}
+
+func (gc *GoalController) GetAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	goals, err := gc.Service.GetAllByUser(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": goals})
+}
+
+func (gc *GoalController) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid goal ID"))
+		return
+	}
+
+	goal, err := gc.Service.GetByID(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondGoalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": goal})
+}
+
+func (gc *GoalController) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	var goal domain.Goal
+	if err := bindJSON(c, &goal); err != nil {
+		c.Error(err)
+		return
+	}
+	goal.UserID = userID.(int)
+
+	id, err := gc.Service.Create(c.Request.Context(), &goal)
+	if err != nil {
+		respondGoalError(c, err)
+		return
+	}
+
+	goal.ID = id
+	c.JSON(http.StatusCreated, gin.H{"data": goal})
+}
+
+func (gc *GoalController) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid goal ID"))
+		return
+	}
+
+	var goal domain.Goal
+	if err := bindJSON(c, &goal); err != nil {
+		c.Error(err)
+		return
+	}
+	goal.ID = id
+
+	if err := gc.Service.Update(c.Request.Context(), userID.(int), &goal); err != nil {
+		respondGoalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": goal})
+}
+
+func (gc *GoalController) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid goal ID"))
+		return
+	}
+
+	if err := gc.Service.Delete(c.Request.Context(), userID.(int), id); err != nil {
+		respondGoalError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondGoalError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("goal not found")
+	case errors.ErrForbidden:
+		err = errors.Forbidden("you can only access your own goals")
+	case errors.ErrConflict:
+		err = errors.Conflict("a goal for this metric and period already exists")
+	}
+	c.Error(err)
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NoteController struct {
+	Service service.NoteService
+}
+
+func NewNoteController(service service.NoteService) *NoteController {
+	return &NoteController{Service: service}
+}
+
+func (nc *NoteController) RegisterRoutes(r gin.IRouter) {
+	notes := r.Group("/progress/:id/notes")
+	{
+		notes.GET("", nc.GetAll)
+		notes.GET("/:noteId", nc.GetByID)
+		notes.POST("", nc.Create)
+		notes.PUT("/:noteId", nc.Update)
+		notes.DELETE("/:noteId", nc.Delete)
+	}
+
+	r.GET("/progress/:id/tags", nc.GetTags)
+	r.PUT("/progress/:id/tags", nc.SetTags)
+	r.GET("/notes/search", nc.Search)
+}
+
+func (nc *NoteController) GetAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+
+	notes, err := nc.Service.GetByProgress(c.Request.Context(), userID.(int), progressID)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": notes})
+}
+
+func (nc *NoteController) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+	noteID, err := strconv.Atoi(c.Param("noteId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid note id"))
+		return
+	}
+
+	note, err := nc.Service.GetByID(c.Request.Context(), userID.(int), progressID, noteID)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": note})
+}
+
+func (nc *NoteController) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+
+	var note domain.Note
+	if err := bindJSON(c, &note); err != nil {
+		c.Error(err)
+		return
+	}
+
+	id, err := nc.Service.Create(c.Request.Context(), userID.(int), progressID, &note)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+
+	note.ID = id
+	c.JSON(http.StatusCreated, gin.H{"data": note})
+}
+
+func (nc *NoteController) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+	noteID, err := strconv.Atoi(c.Param("noteId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid note id"))
+		return
+	}
+
+	var note domain.Note
+	if err := bindJSON(c, &note); err != nil {
+		c.Error(err)
+		return
+	}
+	note.ID = noteID
+
+	if err := nc.Service.Update(c.Request.Context(), userID.(int), progressID, &note); err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": note})
+}
+
+func (nc *NoteController) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+	noteID, err := strconv.Atoi(c.Param("noteId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid note id"))
+		return
+	}
+
+	if err := nc.Service.Delete(c.Request.Context(), userID.(int), progressID, noteID); err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (nc *NoteController) GetTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+
+	tags, err := nc.Service.GetTags(c.Request.Context(), userID.(int), progressID)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+func (nc *NoteController) SetTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	progressID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid progress id"))
+		return
+	}
+
+	var req domain.SetTagsRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tags, err := nc.Service.SetTags(c.Request.Context(), userID.(int), progressID, req.Tags)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+func (nc *NoteController) Search(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	query := c.Query("q")
+	if query == "" {
+		c.Error(errors.ErrInvalidInput("missing q query parameter"))
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	results, err := nc.Service.Search(c.Request.Context(), userID.(int), query, limit)
+	if err != nil {
+		respondNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+func respondNoteError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("not found")
+	case errors.ErrForbidden:
+		err = errors.Forbidden("you can only access notes on your own progress")
+	}
+	c.Error(err)
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/openapi"
+)
+
+// feedResponse is the shape GetFeed writes: the page's events under data
+// alongside the cursor for the next page.
+type feedResponse struct {
+	Data       []domain.ActivityEvent `json:"data"`
+	NextCursor string                 `json:"next_cursor"`
+}
+
+var (
+	limitParam = openapi.Param{Name: "limit", Description: "Maximum number of results"}
+	shelfParam = openapi.Param{Name: "shelf", Description: "Only include items on this shelf, which must be yours (404 otherwise)"}
+	mergeParam = openapi.Param{Name: "merge", Type: "string", Description: `"furthest" keeps whichever position is further along instead of failing on a stale If-Match`}
+)
+
+// Operations documents every route the controllers register. The drift test
+// fails when a route is added or removed without updating this table.
+var Operations = []openapi.Operation{
+	// Auth
+	{Method: "POST", Path: "/auth/register", Tag: "Auth", Summary: "Create an account and sign in", Public: true, Request: domain.RegisterRequest{}, Response: domain.AuthResponse{}, Raw: true, Status: 201},
+	{Method: "POST", Path: "/auth/login", Tag: "Auth", Summary: "Sign in with email and password", Public: true, Request: domain.LoginRequest{}, Response: domain.AuthResponse{}, Raw: true},
+	{Method: "POST", Path: "/auth/demo", Tag: "Auth", Summary: "Sign in as the demo user", Public: true, Response: domain.AuthResponse{}, Raw: true},
+
+	// Books
+	{Method: "GET", Path: "/books", Tag: "Books", Summary: "List books", Response: []domain.Book{}},
+	{Method: "GET", Path: "/books/search", Tag: "Books", Summary: "Find books with similar titles", Query: []openapi.Param{{Name: "title", Type: "string", Required: true}}, Response: []domain.Book{}},
+	{Method: "GET", Path: "/books/filter", Tag: "Books", Summary: "Filter books", Query: []openapi.Param{
+		{Name: "id"},
+		{Name: "isbn", Type: "string"},
+		{Name: "title", Type: "string"},
+		{Name: "total_pages"},
+		{Name: "min_rating", Type: "number"},
+	}, Response: []domain.Book{}, Raw: true},
+	{Method: "GET", Path: "/books/:id", Tag: "Books", Summary: "Get a book", Response: domain.Book{}},
+	{Method: "POST", Path: "/books", Tag: "Books", Summary: "Add a book", Query: []openapi.Param{
+		{Name: "pgId", Description: "Progress entry to attach the new book to"},
+		{Name: "skipProgress", Type: "boolean", Description: "Don't touch any progress entry"},
+	}, Request: domain.Book{}, Response: domain.Book{}, Status: 201},
+	{Method: "PUT", Path: "/books/:id", Tag: "Books", Summary: "Update a book", Request: domain.Book{}, Response: domain.Book{}},
+	{Method: "DELETE", Path: "/books/:id", Tag: "Books", Summary: "Delete a book"},
+	{Method: "GET", Path: "/books/:id/history", Tag: "Books", Summary: "List a book's revisions", Response: []domain.CatalogRevision{}},
+	{Method: "POST", Path: "/books/:id/revert/:version", Tag: "Books", Summary: "Restore a book to an earlier revision", Response: domain.Book{}},
+
+	// Audiobooks
+	{Method: "GET", Path: "/audiobooks", Tag: "Audiobooks", Summary: "List audiobooks", Response: []domain.Audiobook{}},
+	{Method: "GET", Path: "/audiobooks/search", Tag: "Audiobooks", Summary: "Find audiobooks with similar titles", Query: []openapi.Param{{Name: "title", Type: "string", Required: true}}, Response: []domain.Audiobook{}},
+	{Method: "GET", Path: "/audiobooks/:id", Tag: "Audiobooks", Summary: "Get an audiobook", Response: domain.Audiobook{}},
+	{Method: "POST", Path: "/audiobooks", Tag: "Audiobooks", Summary: "Add an audiobook", Query: []openapi.Param{
+		{Name: "pgId", Description: "Progress entry to attach the new audiobook to"},
+	}, Request: domain.Audiobook{}, Response: domain.Audiobook{}, Status: 201},
+	{Method: "PUT", Path: "/audiobooks/:id", Tag: "Audiobooks", Summary: "Update an audiobook", Request: domain.Audiobook{}, Response: domain.Audiobook{}},
+	{Method: "DELETE", Path: "/audiobooks/:id", Tag: "Audiobooks", Summary: "Delete an audiobook"},
+	{Method: "GET", Path: "/audiobooks/:id/history", Tag: "Audiobooks", Summary: "List an audiobook's revisions", Response: []domain.CatalogRevision{}},
+	{Method: "POST", Path: "/audiobooks/:id/revert/:version", Tag: "Audiobooks", Summary: "Restore an audiobook to an earlier revision", Response: domain.Audiobook{}},
+
+	// Catalog
+	{Method: "GET", Path: "/books/:id/duplicates", Tag: "Catalog", Summary: "Find likely duplicates of a book", Response: []domain.DuplicateCandidate{}},
+	{Method: "POST", Path: "/books/:id/merge", Tag: "Catalog", Summary: "Merge duplicates into this book", Request: domain.MergeRequest{}, Response: domain.MergeResult{}},
+	{Method: "GET", Path: "/audiobooks/:id/duplicates", Tag: "Catalog", Summary: "Find likely duplicates of an audiobook", Response: []domain.DuplicateCandidate{}},
+	{Method: "POST", Path: "/audiobooks/:id/merge", Tag: "Catalog", Summary: "Merge duplicates into this audiobook", Request: domain.MergeRequest{}, Response: domain.MergeResult{}},
+
+	// Reviews
+	{Method: "GET", Path: "/books/:id/reviews", Tag: "Reviews", Summary: "List reviews of a book", Response: []domain.Review{}},
+	{Method: "PUT", Path: "/books/:id/review", Tag: "Reviews", Summary: "Rate or review a book", Request: domain.ReviewRequest{}, Response: domain.Review{}},
+	{Method: "DELETE", Path: "/books/:id/review", Tag: "Reviews", Summary: "Delete your review of a book"},
+	{Method: "GET", Path: "/audiobooks/:id/reviews", Tag: "Reviews", Summary: "List reviews of an audiobook", Response: []domain.Review{}},
+	{Method: "PUT", Path: "/audiobooks/:id/review", Tag: "Reviews", Summary: "Rate or review an audiobook", Request: domain.ReviewRequest{}, Response: domain.Review{}},
+	{Method: "DELETE", Path: "/audiobooks/:id/review", Tag: "Reviews", Summary: "Delete your review of an audiobook"},
+
+	// Progress
+	{Method: "GET", Path: "/progress", Tag: "Progress", Summary: "List progress entries", Response: []domain.Progress{}},
+	{Method: "GET", Path: "/progress/filter", Tag: "Progress", Summary: "Filter progress entries", Query: []openapi.Param{
+		{Name: "id"},
+		{Name: "user_id"},
+		{Name: "book_id"},
+		{Name: "audiobook_id"},
+		{Name: "status", Type: "string"},
+	}, Response: []domain.Progress{}, Raw: true},
+	{Method: "GET", Path: "/progress/enriched", Tag: "Progress", Summary: "List your progress with book and audiobook details", Query: []openapi.Param{shelfParam}, Response: []domain.EnrichedProgress{}, Raw: true},
+	{Method: "GET", Path: "/progress/:id", Tag: "Progress", Summary: "Get a progress entry; the ETag header carries its version", Response: domain.Progress{}},
+	{Method: "POST", Path: "/progress", Tag: "Progress", Summary: "Create a progress entry", Request: domain.Progress{}, Response: domain.Progress{}, Raw: true, Status: 201},
+	{Method: "PUT", Path: "/progress/:id", Tag: "Progress", Summary: "Update a progress entry; honours If-Match", Query: []openapi.Param{mergeParam}, Request: updateProgressReq{}, Response: domain.Progress{}, Raw: true},
+	{Method: "DELETE", Path: "/progress/:id", Tag: "Progress", Summary: "Delete a progress entry"},
+	{Method: "PATCH", Path: "/progress/:id/page", Tag: "Progress", Summary: "Set the current page; honours If-Match", Query: []openapi.Param{mergeParam}, Request: updatePageReq{}},
+	{Method: "PATCH", Path: "/progress/:id/time", Tag: "Progress", Summary: "Set the current audiobook position; honours If-Match", Query: []openapi.Param{mergeParam}, Request: updateTimeReq{}},
+	{Method: "POST", Path: "/progress/batch", Tag: "Progress", Summary: "Apply several position updates at once", Request: domain.ProgressBatchRequest{}, Response: domain.ProgressBatchResponse{}},
+	{Method: "POST", Path: "/sync", Tag: "Progress", Summary: "Replay offline mutations and fetch changes since a sync token", Request: domain.SyncRequest{}, Response: domain.SyncResponse{}},
+
+	// Tracking
+	{Method: "POST", Path: "/tracking/start", Tag: "Tracking", Summary: "Start tracking a book or audiobook", Request: domain.StartTrackingRequest{}, Response: domain.Progress{}, Raw: true, Status: 201},
+	{Method: "GET", Path: "/tracking/current", Tag: "Tracking", Summary: "List what you're currently reading or listening to", Query: []openapi.Param{shelfParam}, Response: []domain.CurrentTrackingResponse{}, Raw: true},
+
+	// Notes
+	{Method: "GET", Path: "/progress/:id/notes", Tag: "Notes", Summary: "List notes on a progress entry", Response: []domain.Note{}},
+	{Method: "GET", Path: "/progress/:id/notes/:noteId", Tag: "Notes", Summary: "Get a note", Response: domain.Note{}},
+	{Method: "POST", Path: "/progress/:id/notes", Tag: "Notes", Summary: "Add a note", Request: domain.Note{}, Response: domain.Note{}, Status: 201},
+	{Method: "PUT", Path: "/progress/:id/notes/:noteId", Tag: "Notes", Summary: "Update a note", Request: domain.Note{}, Response: domain.Note{}},
+	{Method: "DELETE", Path: "/progress/:id/notes/:noteId", Tag: "Notes", Summary: "Delete a note"},
+	{Method: "GET", Path: "/progress/:id/tags", Tag: "Notes", Summary: "List a progress entry's tags", Response: []string{}},
+	{Method: "PUT", Path: "/progress/:id/tags", Tag: "Notes", Summary: "Replace a progress entry's tags", Request: domain.SetTagsRequest{}, Response: []string{}},
+	{Method: "GET", Path: "/notes/search", Tag: "Notes", Summary: "Search your notes", Query: []openapi.Param{{Name: "q", Type: "string", Required: true}, limitParam}, Response: []domain.NoteSearchResult{}},
+
+	// Shelves
+	{Method: "GET", Path: "/shelves", Tag: "Shelves", Summary: "List your shelves", Response: []domain.Shelf{}},
+	{Method: "GET", Path: "/shelves/:id", Tag: "Shelves", Summary: "Get a shelf with its items", Response: domain.Shelf{}},
+	{Method: "POST", Path: "/shelves", Tag: "Shelves", Summary: "Create a shelf", Request: domain.Shelf{}, Response: domain.Shelf{}, Status: 201},
+	{Method: "PUT", Path: "/shelves/:id", Tag: "Shelves", Summary: "Update a shelf", Request: domain.Shelf{}, Response: domain.Shelf{}},
+	{Method: "DELETE", Path: "/shelves/:id", Tag: "Shelves", Summary: "Delete a shelf"},
+	{Method: "POST", Path: "/shelves/:id/items", Tag: "Shelves", Summary: "Put a progress entry on a shelf", Request: domain.AddShelfItemRequest{}, Response: domain.ShelfItem{}, Status: 201},
+	{Method: "DELETE", Path: "/shelves/:id/items/:itemId", Tag: "Shelves", Summary: "Take an item off a shelf"},
+	{Method: "PUT", Path: "/shelves/:id/items/order", Tag: "Shelves", Summary: "Reorder a shelf's items", Request: domain.ReorderShelfItemsRequest{}, Response: domain.Shelf{}},
+
+	// Goals
+	{Method: "GET", Path: "/goals", Tag: "Goals", Summary: "List your reading goals", Response: []domain.Goal{}},
+	{Method: "GET", Path: "/goals/:id", Tag: "Goals", Summary: "Get a goal", Response: domain.Goal{}},
+	{Method: "POST", Path: "/goals", Tag: "Goals", Summary: "Create a goal", Request: domain.Goal{}, Response: domain.Goal{}, Status: 201},
+	{Method: "PUT", Path: "/goals/:id", Tag: "Goals", Summary: "Update a goal", Request: domain.Goal{}, Response: domain.Goal{}},
+	{Method: "DELETE", Path: "/goals/:id", Tag: "Goals", Summary: "Delete a goal"},
+
+	// Social
+	{Method: "GET", Path: "/feed", Tag: "Social", Summary: "Activity from people you follow, newest first", Query: []openapi.Param{
+		{Name: "cursor", Type: "string", Description: "next_cursor from the previous page"},
+		limitParam,
+	}, Response: feedResponse{}, Raw: true},
+	{Method: "POST", Path: "/users/:id/follow", Tag: "Social", Summary: "Follow a user, or ask to if their profile isn't public", Response: domain.FollowResult{}},
+	{Method: "DELETE", Path: "/users/:id/follow", Tag: "Social", Summary: "Unfollow a user"},
+	{Method: "GET", Path: "/users/:id/followers", Tag: "Social", Summary: "List a user's followers", Response: []domain.UserSummary{}},
+	{Method: "GET", Path: "/users/:id/following", Tag: "Social", Summary: "List who a user follows", Response: []domain.UserSummary{}},
+	{Method: "GET", Path: "/users/:id/profile", Tag: "Social", Summary: "Get a user's public profile", Response: domain.Profile{}},
+	{Method: "GET", Path: "/follow-requests", Tag: "Social", Summary: "List users asking to follow you", Response: []domain.UserSummary{}},
+	{Method: "POST", Path: "/follow-requests/:userId/approve", Tag: "Social", Summary: "Approve a follow request"},
+	{Method: "POST", Path: "/follow-requests/:userId/decline", Tag: "Social", Summary: "Decline a follow request"},
+
+	// Clubs
+	{Method: "GET", Path: "/clubs", Tag: "Clubs", Summary: "List clubs you belong to", Response: []domain.Club{}},
+	{Method: "GET", Path: "/clubs/:id", Tag: "Clubs", Summary: "Get a club", Response: domain.Club{}},
+	{Method: "POST", Path: "/clubs", Tag: "Clubs", Summary: "Start a club", Request: domain.Club{}, Response: domain.Club{}, Status: 201},
+	{Method: "PUT", Path: "/clubs/:id", Tag: "Clubs", Summary: "Update a club", Request: domain.Club{}, Response: domain.Club{}},
+	{Method: "DELETE", Path: "/clubs/:id", Tag: "Clubs", Summary: "Delete a club"},
+	{Method: "GET", Path: "/clubs/:id/members", Tag: "Clubs", Summary: "List a club's members", Response: []domain.ClubMember{}},
+	{Method: "DELETE", Path: "/clubs/:id/members/:userId", Tag: "Clubs", Summary: "Remove a member, or leave the club"},
+	{Method: "PUT", Path: "/clubs/:id/progress", Tag: "Clubs", Summary: "Pick the progress entry your club position comes from", Request: domain.LinkClubProgressRequest{}},
+	{Method: "POST", Path: "/clubs/:id/invites", Tag: "Clubs", Summary: "Invite a user", Request: domain.ClubInvite{}, Response: domain.ClubInvite{}, Status: 201},
+	{Method: "GET", Path: "/clubs/invites", Tag: "Clubs", Summary: "List your pending invites", Response: []domain.ClubInvite{}},
+	{Method: "POST", Path: "/clubs/invites/:inviteId/accept", Tag: "Clubs", Summary: "Accept an invite"},
+	{Method: "POST", Path: "/clubs/invites/:inviteId/decline", Tag: "Clubs", Summary: "Decline an invite"},
+	{Method: "GET", Path: "/clubs/:id/schedule", Tag: "Clubs", Summary: "Get the reading schedule and checkpoints", Response: domain.ClubSchedule{}},
+	{Method: "POST", Path: "/clubs/:id/checkpoints", Tag: "Clubs", Summary: "Add a checkpoint", Request: domain.Checkpoint{}, Response: domain.Checkpoint{}, Status: 201},
+	{Method: "DELETE", Path: "/clubs/:id/checkpoints/:checkpointId", Tag: "Clubs", Summary: "Delete a checkpoint"},
+	{Method: "GET", Path: "/clubs/:id/checkpoints/:checkpointId/posts", Tag: "Clubs", Summary: "List discussion posts at an open checkpoint", Response: []domain.ClubPost{}},
+	{Method: "POST", Path: "/clubs/:id/checkpoints/:checkpointId/posts", Tag: "Clubs", Summary: "Post to an open checkpoint's discussion", Request: domain.ClubPost{}, Response: domain.ClubPost{}, Status: 201},
+
+	// Discovery
+	{Method: "GET", Path: "/recommendations", Tag: "Discovery", Summary: "Books and audiobooks picked for you", Query: []openapi.Param{limitParam}, Response: []domain.Recommendation{}},
+	{Method: "GET", Path: "/search", Tag: "Discovery", Summary: "Full-text search across books and audiobooks", Query: []openapi.Param{
+		{Name: "q", Type: "string", Required: true},
+		{Name: "format", Type: "string", Description: "all, book or audiobook"},
+		{Name: "prefix", Type: "boolean", Description: "Match the last word as a prefix, for search-as-you-type"},
+		limitParam,
+	}, Response: []domain.SearchResult{}},
+
+	// Users
+	{Method: "GET", Path: "/users", Tag: "Users", Summary: "List users", Response: []domain.User{}},
+	{Method: "GET", Path: "/users/:id", Tag: "Users", Summary: "Get a user", Response: domain.User{}},
+	{Method: "POST", Path: "/users", Tag: "Users", Summary: "Create a user", Request: domain.User{}, Response: domain.User{}, Status: 201},
+	{Method: "PUT", Path: "/users/:id", Tag: "Users", Summary: "Update a user", Request: domain.User{}, Response: domain.User{}},
+	{Method: "DELETE", Path: "/users/:id", Tag: "Users", Summary: "Delete a user"},
+	{Method: "PUT", Path: "/users/me/privacy", Tag: "Users", Summary: "Change your privacy settings", Request: domain.UpdatePrivacyRequest{}, Response: domain.PrivacySettings{}},
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"book_boy/api/internal/middleware"
+	"book_boy/api/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routes registers every controller the way main does. Handlers never run,
+// so the services can be nil.
+func routes() gin.RoutesInfo {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewAuthController(nil).RegisterRoutes(r)
+	NewBookController(nil, nil, nil).RegisterRoutes(r)
+	NewAudiobookController(nil, nil, nil).RegisterRoutes(r)
+	NewUserController(nil).RegisterRoutes(r)
+	NewProgressController(nil, nil, nil, nil, nil, nil).RegisterRoutes(r)
+	NewSyncController(nil).RegisterRoutes(r)
+	NewTrackingController(nil, nil).RegisterRoutes(r)
+	NewShelfController(nil).RegisterRoutes(r)
+	NewNoteController(nil).RegisterRoutes(r)
+	NewReviewController(nil).RegisterRoutes(r)
+	NewGoalController(nil).RegisterRoutes(r)
+	NewSocialController(nil).RegisterRoutes(r)
+	NewClubController(nil).RegisterRoutes(r)
+	NewRecommendationController(nil).RegisterRoutes(r)
+	NewSearchController(nil).RegisterRoutes(r)
+	NewCatalogController(nil).RegisterRoutes(r)
+	return r.Routes()
+}
+
+func TestOperations_MatchRoutes(t *testing.T) {
+	undocumented, stale := openapi.Drift(routes(), Operations)
+	for _, route := range undocumented {
+		t.Errorf("route %s is not in Operations", route)
+	}
+	for _, route := range stale {
+		t.Errorf("Operations documents %s, which no controller registers", route)
+	}
+}
+
+func TestOperations_Unique(t *testing.T) {
+	seen := make(map[string]bool, len(Operations))
+	for _, op := range Operations {
+		key := op.Method + " " + op.Path
+		if seen[key] {
+			t.Errorf("%s is documented twice", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestOperations_BuildDocument(t *testing.T) {
+	doc := openapi.Build(openapi.Spec{
+		Title:      "Book Boy API",
+		Version:    "test",
+		Operations: Operations,
+		Error:      middleware.Problem{},
+	})
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal document: %v", err)
+	}
+	var parsed struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("unmarshal document: %v", err)
+	}
+
+	if _, ok := parsed.Paths["/books/{id}"]["get"]; !ok {
+		t.Fatal("expected GET /books/{id} in paths")
+	}
+	book, ok := parsed.Components.Schemas["Book"]
+	if !ok {
+		t.Fatal("expected a Book schema")
+	}
+	if _, ok := book.Properties["title"]; !ok {
+		t.Fatalf("expected Book.title, got %v", book.Properties)
+	}
+	register := parsed.Components.Schemas["RegisterRequest"]
+	if !contains(register.Required, "email") {
+		t.Fatalf("expected RegisterRequest.email to be required, got %v", register.Required)
+	}
+	if _, ok := parsed.Components.Schemas["User"].Properties["password_hash"]; ok {
+		t.Fatal("User schema must not expose the password hash")
+	}
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
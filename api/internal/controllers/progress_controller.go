@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"book_boy/api/internal/service"
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
 )
 
@@ -15,6 +19,9 @@ type ProgressController struct {
 	Service          service.ProgressService
 	BookService      service.BookService
 	AudiobookService service.AudiobookService
+	ShelfService     service.ShelfService
+	SocialService    service.SocialService
+	Tx               repository.TxManager
 }
 
 type updatePageReq struct {
@@ -25,11 +32,14 @@ type updateTimeReq struct {
 	AudiobookTime domain.CustomDuration `json:"audiobook_time" binding:"required"`
 }
 
-func NewProgressController(Service service.ProgressService, BookService service.BookService, AudiobookService service.AudiobookService) *ProgressController {
+func NewProgressController(Service service.ProgressService, BookService service.BookService, AudiobookService service.AudiobookService, ShelfService service.ShelfService, SocialService service.SocialService, tx repository.TxManager) *ProgressController {
 	return &ProgressController{
 		Service:          Service,
 		BookService:      BookService,
 		AudiobookService: AudiobookService,
+		ShelfService:     ShelfService,
+		SocialService:    SocialService,
+		Tx:               tx,
 	}
 }
 
@@ -38,6 +48,7 @@ func (pc *ProgressController) RegisterRoutes(r gin.IRouter) {
 	progress.GET("", pc.GetAll)
 	progress.GET("/:id", pc.GetByID)
 	progress.POST("", pc.Create)
+	progress.POST("/batch", pc.UpdateBatch)
 	progress.PUT("/:id", pc.Update)
 	progress.DELETE("/:id", pc.Delete)
 	progress.PATCH("/:id/page", pc.UpdateByPage)
@@ -46,43 +57,68 @@ func (pc *ProgressController) RegisterRoutes(r gin.IRouter) {
 	progress.GET("/enriched", pc.GetEnrichedByUser)
 }
 
+// GetAll lists the caller's own progress; other users' is read through
+// /progress/filter?user_id=, which honours their privacy.
 func (pc *ProgressController) GetAll(c *gin.Context) {
-	progress, err := pc.Service.GetAll()
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+	uid := userID.(int)
+	progress, err := pc.Service.FilterProgress(c.Request.Context(), repository.ProgressFilter{UserID: &uid})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": progress})
 }
 
 func (pc *ProgressController) GetByID(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(errors.ErrInvalidInput("invalid id"))
 		return
 	}
-	progress, err := pc.Service.GetByIDWithCompletion(id)
+	progress, err := pc.Service.GetByIDWithCompletion(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
+	// Entries the owner's privacy hides look the same as missing ones.
+	if progress != nil {
+		if err := pc.SocialService.CheckProgressVisible(ctx, userID.(int), progress.UserID); err == errors.ErrForbidden {
+			progress = nil
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+	}
 	if progress == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "progress not found"})
+		c.Error(errors.NotFound("progress not found"))
 		return
 	}
+	setProgressETag(c, progress)
 	c.JSON(http.StatusOK, gin.H{"data": progress})
 }
 
 func (pc *ProgressController) Create(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
 	var progress domain.Progress
-	if err := c.ShouldBindJSON(&progress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &progress); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -94,13 +130,13 @@ func (pc *ProgressController) Create(c *gin.Context) {
 
 	if progress.BookID != nil {
 		filter.BookID = progress.BookID
-		existingProgress, err := pc.Service.FilterProgress(filter)
+		existingProgress, err := pc.Service.FilterProgress(ctx, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 		if len(existingProgress) > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "progress already exists for this book"})
+			c.Error(errors.Conflict("progress already exists for this book"))
 			return
 		}
 	}
@@ -108,20 +144,20 @@ func (pc *ProgressController) Create(c *gin.Context) {
 	if progress.AudiobookID != nil {
 		filter.AudiobookID = progress.AudiobookID
 		filter.BookID = nil
-		existingProgress, err := pc.Service.FilterProgress(filter)
+		existingProgress, err := pc.Service.FilterProgress(ctx, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 		if len(existingProgress) > 0 {
-			c.JSON(http.StatusConflict, gin.H{"error": "progress already exists for this audiobook"})
+			c.Error(errors.Conflict("progress already exists for this audiobook"))
 			return
 		}
 	}
 
-	id, err := pc.Service.Create(&progress)
+	id, err := pc.Service.Create(ctx, &progress)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	progress.ID = id
@@ -136,124 +172,153 @@ type updateProgressReq struct {
 }
 
 func (pc *ProgressController) Update(c *gin.Context) {
+	ctx := c.Request.Context()
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(errors.ErrInvalidInput("invalid id"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
-	existing, err := pc.Service.GetByID(id)
+	existing, err := pc.Service.GetByID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	if existing == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "progress not found"})
+		c.Error(errors.NotFound("progress not found"))
 		return
 	}
 
 	if existing.UserID != userID.(int) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only update your own progress"})
+		c.Error(errors.Forbidden("you can only update your own progress"))
 		return
 	}
 
-	var req updateProgressReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	cond, err := progressCondition(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if req.BookID != nil {
-		if err := pc.Service.SetBook(id, *req.BookID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	pinned := cond.IfMatch > 0 && cond.Mode != domain.MergeModeFurthest
+	if pinned && existing.Version != cond.IfMatch {
+		respondProgressWriteError(c, errors.ErrPreconditionFailed)
+		return
 	}
 
-	if req.AudiobookID != nil {
-		if err := pc.Service.SetAudiobook(id, *req.AudiobookID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	var req updateProgressReq
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
 	}
 
-	if req.BookPage != nil {
-		if err := pc.Service.UpdateProgressPage(id, *req.BookPage); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	// The links and the position are written in one transaction, each write
+	// guarded by the version the previous one produced, so a write that lands
+	// in between fails the whole PUT with 412 instead of being overwritten.
+	err = pc.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		chain := func(progress *domain.Progress) {
+			if pinned && progress != nil {
+				cond.IfMatch = progress.Version
+			}
 		}
-	}
-
-	if req.AudiobookTime != nil {
-		if err := pc.Service.UpdateProgressTime(id, req.AudiobookTime); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		if req.BookID != nil {
+			progress, err := pc.Service.SetBook(ctx, id, *req.BookID, cond)
+			if err != nil {
+				return err
+			}
+			chain(progress)
+		}
+		if req.AudiobookID != nil {
+			progress, err := pc.Service.SetAudiobook(ctx, id, *req.AudiobookID, cond)
+			if err != nil {
+				return err
+			}
+			chain(progress)
+		}
+		if req.BookPage != nil {
+			progress, err := pc.Service.UpdateProgressPage(ctx, id, *req.BookPage, cond)
+			if err != nil {
+				return err
+			}
+			chain(progress)
+		}
+		if req.AudiobookTime != nil {
+			if _, err := pc.Service.UpdateProgressTime(ctx, id, req.AudiobookTime, cond); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		respondProgressWriteError(c, err)
+		return
 	}
 
-	updated, err := pc.Service.GetByIDWithCompletion(id)
+	updated, err := pc.Service.GetByIDWithCompletion(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	setProgressETag(c, updated)
 	c.JSON(http.StatusOK, updated)
 }
 
 func (pc *ProgressController) Delete(c *gin.Context) {
+	ctx := c.Request.Context()
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(errors.ErrInvalidInput("invalid id"))
 		return
 	}
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
-	existing, err := pc.Service.GetByID(id)
+	existing, err := pc.Service.GetByID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	if existing == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "progress not found"})
+		c.Error(errors.NotFound("progress not found"))
 		return
 	}
 
 	if existing.UserID != userID.(int) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only delete your own progress"})
+		c.Error(errors.Forbidden("you can only delete your own progress"))
 		return
 	}
 
 	bookID := existing.BookID
 	audiobookID := existing.AudiobookID
 
-	if err := pc.Service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := pc.Service.Delete(ctx, id); err != nil {
+		c.Error(err)
 		return
 	}
 
 	if bookID != nil {
 		var filter repository.ProgressFilter
 		filter.BookID = bookID
-		remainingProgress, err := pc.Service.FilterProgress(filter)
+		remainingProgress, err := pc.Service.FilterProgress(ctx, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 
 		if len(remainingProgress) == 0 {
-			if err := pc.BookService.Delete(*bookID); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			if err := pc.BookService.Delete(ctx, *bookID); err != nil {
+				c.Error(err)
 				return
 			}
 		}
@@ -262,15 +327,15 @@ func (pc *ProgressController) Delete(c *gin.Context) {
 	if audiobookID != nil {
 		var filter repository.ProgressFilter
 		filter.AudiobookID = audiobookID
-		remainingProgress, err := pc.Service.FilterProgress(filter)
+		remainingProgress, err := pc.Service.FilterProgress(ctx, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 
 		if len(remainingProgress) == 0 {
-			if err := pc.AudiobookService.Delete(*audiobookID); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			if err := pc.AudiobookService.Delete(ctx, *audiobookID); err != nil {
+				c.Error(err)
 				return
 			}
 		}
@@ -282,45 +347,92 @@ func (pc *ProgressController) Delete(c *gin.Context) {
 func (pc *ProgressController) UpdateByPage(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(errors.ErrInvalidInput("invalid id"))
+		return
+	}
+
+	cond, err := progressCondition(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	var req updatePageReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := pc.Service.UpdateProgressPage(id, req.Page); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	progress, err := pc.Service.UpdateProgressPage(c.Request.Context(), id, req.Page, cond)
+	if err != nil {
+		respondProgressWriteError(c, err)
 		return
 	}
+	setProgressETag(c, progress)
 	c.Status(http.StatusNoContent)
 }
 
 func (pc *ProgressController) UpdateByTime(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		c.Error(errors.ErrInvalidInput("invalid id"))
+		return
+	}
+
+	cond, err := progressCondition(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	var req updateTimeReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := pc.Service.UpdateProgressTime(id, &req.AudiobookTime); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	progress, err := pc.Service.UpdateProgressTime(c.Request.Context(), id, &req.AudiobookTime, cond)
+	if err != nil {
+		respondProgressWriteError(c, err)
 		return
 	}
+	setProgressETag(c, progress)
 	c.Status(http.StatusNoContent)
 }
 
+func (pc *ProgressController) UpdateBatch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	var req domain.ProgressBatchRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	resp, err := pc.Service.UpdateBatch(c.Request.Context(), userID.(int), &req)
+	if err != nil {
+		respondProgressWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
+
+// FilterProgress defaults to the caller's own entries. Filtering by another
+// user_id needs their profile and progress privacy to allow it.
 func (pc *ProgressController) FilterProgress(c *gin.Context) {
-	var filter repository.ProgressFilter
+	ctx := c.Request.Context()
+	viewerID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	uid := viewerID.(int)
+	filter := repository.ProgressFilter{UserID: &uid}
 	if idStr := c.Query("id"); idStr != "" {
 		if id, err := strconv.Atoi(idStr); err == nil {
 			filter.ID = &id
@@ -346,9 +458,14 @@ func (pc *ProgressController) FilterProgress(c *gin.Context) {
 		filter.Status = &progressStatus
 	}
 
-	progresses, err := pc.Service.FilterProgress(filter)
+	if err := pc.SocialService.CheckProgressVisible(ctx, viewerID.(int), *filter.UserID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	progresses, err := pc.Service.FilterProgress(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch progresss"})
+		c.Error(err)
 		return
 	}
 
@@ -356,17 +473,74 @@ func (pc *ProgressController) FilterProgress(c *gin.Context) {
 }
 
 func (pc *ProgressController) GetEnrichedByUser(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
-	enriched, err := pc.Service.GetAllEnrichedByUser(userID.(int))
+	var enriched []domain.EnrichedProgress
+	var err error
+	if shelfStr := c.Query("shelf"); shelfStr != "" {
+		shelfID, convErr := strconv.Atoi(shelfStr)
+		if convErr != nil {
+			c.Error(errors.ErrInvalidInput("invalid shelf id"))
+			return
+		}
+		if err := pc.ShelfService.CheckOwned(ctx, userID.(int), shelfID); err != nil {
+			c.Error(err)
+			return
+		}
+		enriched, err = pc.Service.GetAllEnrichedByUserShelf(ctx, userID.(int), shelfID)
+	} else {
+		enriched, err = pc.Service.GetAllEnrichedByUser(ctx, userID.(int))
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch enriched progress"})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, enriched)
 }
+
+// progressCondition reads the version the client last saw from If-Match
+// ("3", W/"3" or *) and the merge mode from ?merge=furthest.
+func progressCondition(c *gin.Context) (domain.UpdateCondition, error) {
+	var cond domain.UpdateCondition
+	switch merge := c.Query("merge"); merge {
+	case "":
+	case string(domain.MergeModeFurthest):
+		cond.Mode = domain.MergeModeFurthest
+	default:
+		return cond, errors.ErrInvalidField("merge", fmt.Sprintf("must be %q", domain.MergeModeFurthest))
+	}
+
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return cond, nil
+	}
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return cond, errors.ErrInvalidInput("invalid If-Match header")
+	}
+	cond.IfMatch = version
+	return cond, nil
+}
+
+func setProgressETag(c *gin.Context, progress *domain.Progress) {
+	if progress != nil && progress.Version > 0 {
+		c.Header("ETag", fmt.Sprintf(`"%d"`, progress.Version))
+	}
+}
+
+func respondProgressWriteError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrPreconditionFailed:
+		err = errors.PreconditionFailed("progress was changed by another request; fetch it and try again")
+	case errors.ErrNotFound:
+		err = errors.NotFound("progress not found")
+	}
+	c.Error(err)
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecommendationController struct {
+	Service service.RecommendationService
+}
+
+func NewRecommendationController(service service.RecommendationService) *RecommendationController {
+	return &RecommendationController{Service: service}
+}
+
+func (rc *RecommendationController) RegisterRoutes(r gin.IRouter) {
+	r.GET("/recommendations", rc.GetRecommendations)
+}
+
+func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	recommendations, err := rc.Service.GetForUser(c.Request.Context(), userID.(int), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": recommendations})
+}
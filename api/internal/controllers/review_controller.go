@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewController struct {
+	Service service.ReviewService
+}
+
+func NewReviewController(service service.ReviewService) *ReviewController {
+	return &ReviewController{Service: service}
+}
+
+func (rc *ReviewController) RegisterRoutes(r gin.IRouter) {
+	r.GET("/books/:id/reviews", rc.GetBookReviews)
+	r.PUT("/books/:id/review", rc.ReviewBook)
+	r.DELETE("/books/:id/review", rc.DeleteBookReview)
+
+	r.GET("/audiobooks/:id/reviews", rc.GetAudiobookReviews)
+	r.PUT("/audiobooks/:id/review", rc.ReviewAudiobook)
+	r.DELETE("/audiobooks/:id/review", rc.DeleteAudiobookReview)
+}
+
+func (rc *ReviewController) GetBookReviews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+
+	reviews, err := rc.Service.GetBookReviews(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reviews})
+}
+
+func (rc *ReviewController) ReviewBook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+
+	var req domain.ReviewRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	review, err := rc.Service.ReviewBook(c.Request.Context(), userID.(int), id, &req)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": review})
+}
+
+func (rc *ReviewController) DeleteBookReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid book ID"))
+		return
+	}
+
+	if err := rc.Service.DeleteBookReview(c.Request.Context(), userID.(int), id); err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (rc *ReviewController) GetAudiobookReviews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+
+	reviews, err := rc.Service.GetAudiobookReviews(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reviews})
+}
+
+func (rc *ReviewController) ReviewAudiobook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+
+	var req domain.ReviewRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	review, err := rc.Service.ReviewAudiobook(c.Request.Context(), userID.(int), id, &req)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": review})
+}
+
+func (rc *ReviewController) DeleteAudiobookReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid ID"))
+		return
+	}
+
+	if err := rc.Service.DeleteAudiobookReview(c.Request.Context(), userID.(int), id); err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondReviewError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("not found")
+	}
+	c.Error(err)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	Service service.SearchService
+}
+
+func NewSearchController(service service.SearchService) *SearchController {
+	return &SearchController{Service: service}
+}
+
+func (sc *SearchController) RegisterRoutes(r gin.IRouter) {
+	r.GET("/search", sc.Search)
+}
+
+func (sc *SearchController) Search(c *gin.Context) {
+	query := domain.SearchQuery{
+		Query:  c.Query("q"),
+		Format: domain.SearchFormat(c.Query("format")),
+	}
+	if prefixStr := c.Query("prefix"); prefixStr != "" {
+		prefix, err := strconv.ParseBool(prefixStr)
+		if err != nil {
+			c.Error(errors.ErrInvalidInput("prefix must be true or false"))
+			return
+		}
+		query.Prefix = prefix
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			query.Limit = l
+		}
+	}
+
+	results, err := sc.Service.Search(c.Request.Context(), query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if results == nil {
+		results = []domain.SearchResult{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShelfController struct {
+	Service service.ShelfService
+}
+
+func NewShelfController(service service.ShelfService) *ShelfController {
+	return &ShelfController{Service: service}
+}
+
+func (sc *ShelfController) RegisterRoutes(r gin.IRouter) {
+	shelves := r.Group("/shelves")
+	{
+		shelves.GET("", sc.GetAll)
+		shelves.GET("/:id", sc.GetByID)
+		shelves.POST("", sc.Create)
+		shelves.PUT("/:id", sc.Update)
+		shelves.DELETE("/:id", sc.Delete)
+		shelves.POST("/:id/items", sc.AddItem)
+		shelves.DELETE("/:id/items/:itemId", sc.RemoveItem)
+		shelves.PUT("/:id/items/order", sc.ReorderItems)
+	}
+}
+
+func (sc *ShelfController) GetAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	shelves, err := sc.Service.GetAllByUser(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shelves})
+}
+
+func (sc *ShelfController) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid shelf ID"))
+		return
+	}
+
+	shelf, err := sc.Service.GetByID(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shelf})
+}
+
+func (sc *ShelfController) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	var shelf domain.Shelf
+	if err := bindJSON(c, &shelf); err != nil {
+		c.Error(err)
+		return
+	}
+	shelf.UserID = userID.(int)
+
+	id, err := sc.Service.Create(c.Request.Context(), &shelf)
+	if err != nil {
+		respondShelfError(c, err)
+		return
+	}
+
+	shelf.ID = id
+	c.JSON(http.StatusCreated, gin.H{"data": shelf})
+}
+
+func (sc *ShelfController) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid shelf ID"))
+		return
+	}
+
+	var shelf domain.Shelf
+	if err := bindJSON(c, &shelf); err != nil {
+		c.Error(err)
+		return
+	}
+	shelf.ID = id
+
+	if err := sc.Service.Update(c.Request.Context(), userID.(int), &shelf); err != nil {
+		respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shelf})
+}
+
+func (sc *ShelfController) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid shelf ID"))
+		return
+	}
+
+	if err := sc.Service.Delete(c.Request.Context(), userID.(int), id); err != nil {
+		respondShelfError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (sc *ShelfController) AddItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid shelf ID"))
+		return
+	}
+
+	var req domain.AddShelfItemRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	item, err := sc.Service.AddItem(c.Request.Context(), userID.(int), id, &req)
+	if err != nil {
+		respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": item})
+}
+
+func (sc *ShelfController) RemoveItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid shelf ID"))
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("itemId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid item ID"))
+		return
+	}
+
+	if err := sc.Service.RemoveItem(c.Request.Context(), userID.(int), id, itemID); err != nil {
+		respondShelfError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (sc *ShelfController) ReorderItems(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid shelf ID"))
+		return
+	}
+
+	var req domain.ReorderShelfItemsRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := sc.Service.ReorderItems(ctx, userID.(int), id, req.ItemIDs); err != nil {
+		respondShelfError(c, err)
+		return
+	}
+
+	shelf, err := sc.Service.GetByID(ctx, userID.(int), id)
+	if err != nil {
+		respondShelfError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shelf})
+}
+
+func respondShelfError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("shelf not found")
+	case errors.ErrForbidden:
+		err = errors.Forbidden("you can only modify your own shelves")
+	}
+	c.Error(err)
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SocialController struct {
+	Service service.SocialService
+}
+
+func NewSocialController(service service.SocialService) *SocialController {
+	return &SocialController{Service: service}
+}
+
+func (sc *SocialController) RegisterRoutes(r gin.IRouter) {
+	r.POST("/users/:id/follow", sc.Follow)
+	r.DELETE("/users/:id/follow", sc.Unfollow)
+	r.GET("/users/:id/followers", sc.GetFollowers)
+	r.GET("/users/:id/following", sc.GetFollowing)
+	r.GET("/users/:id/profile", sc.GetProfile)
+	r.GET("/follow-requests", sc.GetFollowRequests)
+	r.POST("/follow-requests/:userId/approve", sc.ApproveFollowRequest)
+	r.POST("/follow-requests/:userId/decline", sc.DeclineFollowRequest)
+	r.GET("/feed", sc.GetFeed)
+}
+
+func (sc *SocialController) Follow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	result, err := sc.Service.Follow(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondSocialError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (sc *SocialController) Unfollow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	if err := sc.Service.Unfollow(c.Request.Context(), userID.(int), id); err != nil {
+		respondSocialError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (sc *SocialController) GetFollowers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	users, err := sc.Service.GetFollowers(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondSocialError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func (sc *SocialController) GetFollowing(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	users, err := sc.Service.GetFollowing(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondSocialError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func (sc *SocialController) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	profile, err := sc.Service.GetProfile(c.Request.Context(), userID.(int), id)
+	if err != nil {
+		respondSocialError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+func (sc *SocialController) GetFeed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	page, err := sc.Service.GetFeed(c.Request.Context(), userID.(int), c.Query("cursor"), limit)
+	if err != nil {
+		respondSocialError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": page.Events, "next_cursor": page.NextCursor})
+}
+
+func (sc *SocialController) GetFollowRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	users, err := sc.Service.GetFollowRequests(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func (sc *SocialController) ApproveFollowRequest(c *gin.Context) {
+	sc.respondToFollowRequest(c, true)
+}
+
+func (sc *SocialController) DeclineFollowRequest(c *gin.Context) {
+	sc.respondToFollowRequest(c, false)
+}
+
+func (sc *SocialController) respondToFollowRequest(c *gin.Context, approve bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	requesterID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.Error(errors.ErrInvalidInput("invalid user ID"))
+		return
+	}
+
+	if approve {
+		err = sc.Service.ApproveFollowRequest(c.Request.Context(), userID.(int), requesterID)
+	} else {
+		err = sc.Service.DeclineFollowRequest(c.Request.Context(), userID.(int), requesterID)
+	}
+	if err == errors.ErrNotFound {
+		err = errors.NotFound("follow request not found")
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondSocialError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrNotFound:
+		err = errors.NotFound("user not found")
+	case errors.ErrForbidden:
+		err = errors.Forbidden("this profile is not visible to you")
+	}
+	c.Error(err)
+}
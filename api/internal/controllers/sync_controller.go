@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncController struct {
+	Service service.SyncService
+}
+
+func NewSyncController(service service.SyncService) *SyncController {
+	return &SyncController{Service: service}
+}
+
+func (sc *SyncController) RegisterRoutes(r gin.IRouter) {
+	r.POST("/sync", sc.Sync)
+}
+
+func (sc *SyncController) Sync(c *gin.Context) {
+	var req domain.SyncRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	resp, err := sc.Service.Sync(c.Request.Context(), c.GetInt("user_id"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": resp})
+}
@@ -5,16 +5,18 @@ import (
 	"book_boy/api/internal/domain"
 	"book_boy/api/internal/errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TrackingController struct {
-	Service service.TrackingService
+	Service      service.TrackingService
+	ShelfService service.ShelfService
 }
 
-func NewTrackingController(service service.TrackingService) *TrackingController {
-	return &TrackingController{Service: service}
+func NewTrackingController(service service.TrackingService, shelfService service.ShelfService) *TrackingController {
+	return &TrackingController{Service: service, ShelfService: shelfService}
 }
 
 func (tc *TrackingController) RegisterRoutes(r gin.IRouter) {
@@ -26,23 +28,19 @@ func (tc *TrackingController) RegisterRoutes(r gin.IRouter) {
 func (tc *TrackingController) StartTracking(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
 	var req domain.StartTrackingRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	progress, err := tc.Service.StartTracking(userID.(int), &req)
+	progress, err := tc.Service.StartTracking(c.Request.Context(), userID.(int), &req)
 	if err != nil {
-		if errors.IsValidationError(err) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -50,15 +48,31 @@ func (tc *TrackingController) StartTracking(c *gin.Context) {
 }
 
 func (tc *TrackingController) GetCurrentTracking(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		c.Error(errors.Unauthorized("user not authenticated"))
 		return
 	}
 
-	currentTracking, err := tc.Service.GetCurrentTracking(userID.(int))
+	var currentTracking []domain.CurrentTrackingResponse
+	var err error
+	if shelfStr := c.Query("shelf"); shelfStr != "" {
+		shelfID, convErr := strconv.Atoi(shelfStr)
+		if convErr != nil {
+			c.Error(errors.ErrInvalidInput("invalid shelf id"))
+			return
+		}
+		if err := tc.ShelfService.CheckOwned(ctx, userID.(int), shelfID); err != nil {
+			c.Error(err)
+			return
+		}
+		currentTracking, err = tc.Service.GetCurrentTrackingByShelf(ctx, userID.(int), shelfID)
+	} else {
+		currentTracking, err = tc.Service.GetCurrentTracking(ctx, userID.(int))
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -3,6 +3,7 @@ package controllers
 import (
 	"book_boy/api/internal/service"
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"net/http"
 	"strconv"
 
@@ -23,30 +24,41 @@ func (uc *UserController) RegisterRoutes(r gin.IRouter) {
 	r.POST("/users", uc.Create)
 	r.PUT("/users/:id", uc.Update)
 	r.DELETE("/users/:id", uc.Delete)
+	r.PUT("/users/me/privacy", uc.UpdatePrivacy)
 }
 
 func (uc *UserController) GetAll(c *gin.Context) {
-	users, err := uc.Service.GetAll()
+	viewerID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+	users, err := uc.Service.GetAll(c.Request.Context(), viewerID.(int))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": users})
 }
 
 func (uc *UserController) GetByID(c *gin.Context) {
+	viewerID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		c.Error(errors.ErrInvalidInput("invalid ID"))
 		return
 	}
-	user, err := uc.Service.GetByID(id)
+	user, err := uc.Service.GetByID(c.Request.Context(), viewerID.(int), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(errors.NotFound("user not found"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": user})
@@ -54,13 +66,13 @@ func (uc *UserController) GetByID(c *gin.Context) {
 
 func (uc *UserController) Create(c *gin.Context) {
 	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &user); err != nil {
+		c.Error(err)
 		return
 	}
-	id, err := uc.Service.Create(&user)
+	id, err := uc.Service.Create(c.Request.Context(), &user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -71,17 +83,17 @@ func (uc *UserController) Create(c *gin.Context) {
 func (uc *UserController) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		c.Error(errors.ErrInvalidInput("invalid ID"))
 		return
 	}
 	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &user); err != nil {
+		c.Error(err)
 		return
 	}
 	user.ID = id
-	if err := uc.Service.Update(&user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := uc.Service.Update(c.Request.Context(), &user); err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": user})
@@ -90,12 +102,36 @@ func (uc *UserController) Update(c *gin.Context) {
 func (uc *UserController) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		c.Error(errors.ErrInvalidInput("invalid ID"))
 		return
 	}
-	if err := uc.Service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := uc.Service.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
+
+func (uc *UserController) UpdatePrivacy(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.Unauthorized("user not authenticated"))
+		return
+	}
+
+	var req domain.UpdatePrivacyRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	settings, err := uc.Service.UpdatePrivacy(c.Request.Context(), userID.(int), &req)
+	if err == errors.ErrNotFound {
+		err = errors.NotFound("user not found")
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
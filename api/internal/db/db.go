@@ -1,21 +1,38 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"database/sql/driver"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func InitDB(connStr string) *sql.DB {
-	db, err := sql.Open("postgres", connStr)
+// InitDB opens the Postgres pool and checks that it can connect. Queries are
+// traced as children of the caller's span; those made outside any trace, such
+// as by the background workers, are not traced.
+func InitDB(connStr string) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", connStr,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	if err := db.Ping(); err != nil {
-		log.Fatal(err)
+		db.Close()
+		return nil, err
 	}
 
-	return db
+	return db, nil
 }
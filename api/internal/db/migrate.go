@@ -4,14 +4,14 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 )
 
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
-func RunMigrations(db *sql.DB) error {
+func RunMigrations(db *sql.DB, logger *slog.Logger) error {
 	if err := createMigrationsTable(db); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
@@ -30,12 +30,12 @@ func RunMigrations(db *sql.DB) error {
 	sort.Strings(migrationFiles)
 
 	for _, filename := range migrationFiles {
-		if err := runMigration(db, filename); err != nil {
+		if err := runMigration(db, filename, logger); err != nil {
 			return fmt.Errorf("failed to run migration %s: %w", filename, err)
 		}
 	}
 
-	log.Println("All migrations completed successfully")
+	logger.Info("migrations complete", "files", len(migrationFiles))
 	return nil
 }
 
@@ -51,7 +51,7 @@ func createMigrationsTable(db *sql.DB) error {
 	return err
 }
 
-func runMigration(db *sql.DB, filename string) error {
+func runMigration(db *sql.DB, filename string, logger *slog.Logger) error {
 	var exists bool
 	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE filename = $1)", filename).Scan(&exists)
 	if err != nil {
@@ -59,7 +59,7 @@ func runMigration(db *sql.DB, filename string) error {
 	}
 
 	if exists {
-		log.Printf("Migration %s already applied, skipping", filename)
+		logger.Debug("migration already applied", "file", filename)
 		return nil
 	}
 
@@ -86,6 +86,6 @@ func runMigration(db *sql.DB, filename string) error {
 		return err
 	}
 
-	log.Printf("Applied migration: %s", filename)
+	logger.Info("applied migration", "file", filename)
 	return nil
 }
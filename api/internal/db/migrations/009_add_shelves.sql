@@ -0,0 +1,43 @@
+-- Migration: Add user-owned shelves and shelf items
+-- Date: 2026-10-19
+-- Description: Shelves are named, ordered collections of books/audiobooks owned by a user.
+-- A book or audiobook can sit on any number of shelves.
+
+CREATE TABLE IF NOT EXISTS shelves (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS shelf_items (
+    id SERIAL PRIMARY KEY,
+    shelf_id INTEGER NOT NULL REFERENCES shelves(id) ON DELETE CASCADE,
+    book_id INTEGER REFERENCES books(id) ON DELETE CASCADE,
+    audiobook_id INTEGER REFERENCES audiobooks(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (
+        (book_id IS NOT NULL AND audiobook_id IS NULL) OR
+        (book_id IS NULL AND audiobook_id IS NOT NULL)
+    )
+);
+
+CREATE TRIGGER set_shelves_timestamp
+BEFORE UPDATE ON shelves
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_shelves_user ON shelves(user_id);
+CREATE INDEX IF NOT EXISTS idx_shelf_items_shelf ON shelf_items(shelf_id, position);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shelf_items_shelf_book
+ON shelf_items(shelf_id, book_id)
+WHERE book_id IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shelf_items_shelf_audiobook
+ON shelf_items(shelf_id, audiobook_id)
+WHERE audiobook_id IS NOT NULL;
@@ -0,0 +1,35 @@
+-- Migration: Add notes, highlights and tags for progress entries
+-- Date: 2026-10-19
+-- Description: Notes and page/time-anchored highlights hang off a progress row.
+-- search_vector backs full-text search across a user's notes.
+
+CREATE TABLE IF NOT EXISTS progress_notes (
+    id SERIAL PRIMARY KEY,
+    progress_id INTEGER NOT NULL REFERENCES progress(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL DEFAULT 'note',
+    body TEXT NOT NULL,
+    page INTEGER,
+    audiobook_time INTERVAL,
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', body)) STORED,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (kind IN ('note', 'highlight')),
+    CHECK (kind = 'note' OR page IS NOT NULL OR audiobook_time IS NOT NULL)
+);
+
+CREATE TABLE IF NOT EXISTS progress_tags (
+    progress_id INTEGER NOT NULL REFERENCES progress(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (progress_id, tag)
+);
+
+CREATE TRIGGER set_progress_notes_timestamp
+BEFORE UPDATE ON progress_notes
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_progress_notes_progress ON progress_notes(progress_id);
+CREATE INDEX IF NOT EXISTS idx_progress_notes_user ON progress_notes(user_id);
+CREATE INDEX IF NOT EXISTS idx_progress_notes_search ON progress_notes USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_progress_tags_tag ON progress_tags(tag);
@@ -0,0 +1,52 @@
+-- Migration: Add ratings and reviews for books and audiobooks
+-- Date: 2026-10-19
+-- Description: One review per user per book/audiobook. Ratings use half-star steps (0.5 - 5.0).
+-- review_privacy on users controls who can read a user's reviews.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_privacy TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE users ADD CONSTRAINT users_review_privacy_check CHECK (review_privacy IN ('public', 'private'));
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id INTEGER REFERENCES books(id) ON DELETE CASCADE,
+    audiobook_id INTEGER REFERENCES audiobooks(id) ON DELETE CASCADE,
+    rating NUMERIC(2,1) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    spoiler BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (rating >= 0.5 AND rating <= 5.0 AND rating * 2 = TRUNC(rating * 2)),
+    CHECK (
+        (book_id IS NOT NULL AND audiobook_id IS NULL) OR
+        (book_id IS NULL AND audiobook_id IS NOT NULL)
+    )
+);
+
+CREATE TRIGGER set_reviews_timestamp
+BEFORE UPDATE ON reviews
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_book
+ON reviews(user_id, book_id)
+WHERE book_id IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_audiobook
+ON reviews(user_id, audiobook_id)
+WHERE audiobook_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reviews_book ON reviews(book_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_audiobook ON reviews(audiobook_id);
+
+CREATE OR REPLACE VIEW book_rating_stats AS
+SELECT book_id, ROUND(AVG(rating), 2)::FLOAT8 AS average_rating, COUNT(*) AS rating_count
+FROM reviews
+WHERE book_id IS NOT NULL
+GROUP BY book_id;
+
+CREATE OR REPLACE VIEW audiobook_rating_stats AS
+SELECT audiobook_id, ROUND(AVG(rating), 2)::FLOAT8 AS average_rating, COUNT(*) AS rating_count
+FROM reviews
+WHERE audiobook_id IS NOT NULL
+GROUP BY audiobook_id;
@@ -0,0 +1,41 @@
+-- Migration: Add reading goals and dated progress activity
+-- Date: 2026-10-19
+-- Description: progress_activity records the position delta of every progress update
+-- so goals can be measured over a calendar period.
+
+CREATE TABLE IF NOT EXISTS reading_goals (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period TEXT NOT NULL,
+    metric TEXT NOT NULL,
+    target INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    month INTEGER,
+    last_milestone INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (period IN ('yearly', 'monthly')),
+    CHECK (metric IN ('books', 'pages', 'listening_hours')),
+    CHECK (target > 0),
+    CHECK ((period = 'monthly' AND month BETWEEN 1 AND 12) OR (period = 'yearly' AND month IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reading_goals_unique
+ON reading_goals(user_id, period, metric, year, COALESCE(month, 0));
+
+CREATE TRIGGER set_reading_goals_timestamp
+BEFORE UPDATE ON reading_goals
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS progress_activity (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    progress_id INTEGER REFERENCES progress(id) ON DELETE SET NULL,
+    pages_delta INTEGER NOT NULL DEFAULT 0,
+    seconds_delta INTEGER NOT NULL DEFAULT 0,
+    finished BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_progress_activity_user_time ON progress_activity(user_id, recorded_at);
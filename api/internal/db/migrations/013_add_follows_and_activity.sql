@@ -0,0 +1,35 @@
+-- Migration: Add follow graph, profile/progress privacy and activity feed events
+-- Date: 2026-10-19
+-- Description: Users can follow each other. Privacy levels (public, followers, private)
+-- gate who sees a user's profile, progress and reviews. activity_events backs GET /feed.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_privacy TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS progress_privacy TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE users ADD CONSTRAINT users_profile_privacy_check CHECK (profile_privacy IN ('public', 'followers', 'private'));
+ALTER TABLE users ADD CONSTRAINT users_progress_privacy_check CHECK (progress_privacy IN ('public', 'followers', 'private'));
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_review_privacy_check;
+ALTER TABLE users ADD CONSTRAINT users_review_privacy_check CHECK (review_privacy IN ('public', 'followers', 'private'));
+
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows(followee_id);
+
+CREATE TABLE IF NOT EXISTS activity_events (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('started', 'finished', 'rated')),
+    progress_id INTEGER REFERENCES progress(id) ON DELETE SET NULL,
+    book_id INTEGER REFERENCES books(id) ON DELETE CASCADE,
+    audiobook_id INTEGER REFERENCES audiobooks(id) ON DELETE CASCADE,
+    rating NUMERIC(2,1),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_events_user ON activity_events(user_id, id DESC);
@@ -0,0 +1,63 @@
+-- Migration: Add book clubs with shared reading schedules
+-- Date: 2026-10-19
+-- Description: Clubs read one book together against page checkpoints. Members join by
+-- invite. Each checkpoint has a discussion thread that unlocks once a member's progress
+-- reaches the checkpoint page, so nobody is spoiled ahead of where they are.
+
+CREATE TABLE IF NOT EXISTS clubs (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER set_clubs_timestamp
+BEFORE UPDATE ON clubs
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS club_members (
+    club_id INTEGER NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member')),
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (club_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_members_user ON club_members(user_id);
+
+CREATE TABLE IF NOT EXISTS club_invites (
+    id SERIAL PRIMARY KEY,
+    club_id INTEGER NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    inviter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invitee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (club_id, invitee_id)
+);
+
+CREATE TABLE IF NOT EXISTS club_checkpoints (
+    id SERIAL PRIMARY KEY,
+    club_id INTEGER NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    page INTEGER NOT NULL CHECK (page > 0),
+    opens_at TIMESTAMP NOT NULL,
+    notified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (club_id, page)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_checkpoints_due ON club_checkpoints(opens_at) WHERE NOT notified;
+
+CREATE TABLE IF NOT EXISTS club_posts (
+    id SERIAL PRIMARY KEY,
+    checkpoint_id INTEGER NOT NULL REFERENCES club_checkpoints(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_posts_checkpoint ON club_posts(checkpoint_id, created_at);
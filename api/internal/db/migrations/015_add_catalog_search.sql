@@ -0,0 +1,18 @@
+-- Migration: Add authors and search indexes to the catalog
+-- Date: 2026-10-19
+-- Description: Unified search covers titles, authors and ISBNs across books and audiobooks.
+-- Authors are filled in by the metadata service or entered by hand. Full-text search uses
+-- the 'simple' configuration so author names and non-English titles aren't stemmed away.
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS author VARCHAR(255);
+ALTER TABLE audiobooks ADD COLUMN IF NOT EXISTS author VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_books_author_trgm ON books USING gin (author gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_audiobooks_author_trgm ON audiobooks USING gin (author gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_books_search_fts ON books
+    USING gin (to_tsvector('simple', title || ' ' || COALESCE(author, '')));
+CREATE INDEX IF NOT EXISTS idx_audiobooks_search_fts ON audiobooks
+    USING gin (to_tsvector('simple', title || ' ' || COALESCE(author, '')));
+
+CREATE INDEX IF NOT EXISTS idx_books_isbn_prefix ON books (isbn text_pattern_ops);
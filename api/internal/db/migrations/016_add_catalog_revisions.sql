@@ -0,0 +1,37 @@
+-- Migration: Add edit history for books and audiobooks
+-- Date: 2026-10-19
+-- Description: Every change to a catalog record is stored as a numbered revision with the
+-- full snapshot after the change and a per-field diff, tagged with who or what made it.
+-- locked_fields lists fields a person entered, which metadata enrichment must leave alone.
+-- Existing records get a version 1 baseline so they can always be reverted to it.
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS locked_fields TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE audiobooks ADD COLUMN IF NOT EXISTS locked_fields TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS catalog_revisions (
+    id SERIAL PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    editor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reverted_to INTEGER,
+    changes JSONB NOT NULL DEFAULT '{}',
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (entity_type IN ('book', 'audiobook')),
+    CHECK (source IN ('created', 'user', 'metadata', 'revert')),
+    UNIQUE (entity_type, entity_id, version)
+);
+
+INSERT INTO catalog_revisions (entity_type, entity_id, version, source, snapshot)
+SELECT 'book', id, 1, 'created',
+    jsonb_build_object('isbn', isbn, 'title', title, 'total_pages', total_pages, 'author', COALESCE(author, ''))
+FROM books
+ON CONFLICT DO NOTHING;
+
+INSERT INTO catalog_revisions (entity_type, entity_id, version, source, snapshot)
+SELECT 'audiobook', id, 1, 'created',
+    jsonb_build_object('title', title, 'total_length', to_char(total_length, 'HH24:MI:SS'), 'author', COALESCE(author, ''))
+FROM audiobooks
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration: Add optimistic concurrency to progress
+-- Date: 2026-10-19
+-- Description: version increases on every write. It is exposed as the ETag on progress
+-- reads and checked against If-Match so two devices can't silently overwrite each other.
+
+ALTER TABLE progress ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,55 @@
+-- Migration: Add offline sync bookkeeping to progress
+-- Date: 2026-10-19
+-- Description: every insert or update stamps progress with the next sync_seq and the writing
+-- transaction's id, and deletes leave a tombstone, so POST /sync can return what changed since a
+-- client's token. sync_seq is drawn when a row is written, not when it commits, so tokens are the
+-- oldest transaction still running when they were issued: everything older has committed or
+-- rolled back, and a slower transaction that drew a lower sync_seq is still picked up.
+
+CREATE SEQUENCE IF NOT EXISTS progress_sync_seq;
+
+ALTER TABLE progress ADD COLUMN IF NOT EXISTS sync_seq BIGINT NOT NULL DEFAULT nextval('progress_sync_seq');
+ALTER TABLE progress ADD COLUMN IF NOT EXISTS sync_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS idx_progress_user_sync_xid ON progress(user_id, sync_xid);
+
+CREATE OR REPLACE FUNCTION bump_progress_sync_seq()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.sync_seq := nextval('progress_sync_seq');
+    NEW.sync_xid := pg_current_xact_id();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER set_progress_sync_seq
+BEFORE UPDATE ON progress
+FOR EACH ROW
+EXECUTE FUNCTION bump_progress_sync_seq();
+
+-- No foreign key on user_id: rows are written while a user's progress is
+-- cascade-deleted along with the user.
+CREATE TABLE IF NOT EXISTS progress_deletions (
+    progress_id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    sync_seq BIGINT NOT NULL DEFAULT nextval('progress_sync_seq'),
+    sync_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    deleted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_progress_deletions_user_sync_xid ON progress_deletions(user_id, sync_xid);
+
+CREATE OR REPLACE FUNCTION record_progress_deletion()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO progress_deletions (progress_id, user_id)
+    VALUES (OLD.id, OLD.user_id)
+    ON CONFLICT (progress_id) DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER log_progress_deletion
+AFTER DELETE ON progress
+FOR EACH ROW
+EXECUTE FUNCTION record_progress_deletion();
@@ -0,0 +1,12 @@
+-- Migration: Add chapter checkpoints to book clubs
+-- Date: 2026-10-19
+-- Description: A checkpoint is either a page or a chapter. Progress is tracked in pages, so a
+-- chapter checkpoint also records the page the chapter ends on, which the spoiler gate checks.
+
+ALTER TABLE club_checkpoints ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'page' CHECK (kind IN ('page', 'chapter'));
+ALTER TABLE club_checkpoints ADD COLUMN IF NOT EXISTS chapter INTEGER CHECK (chapter > 0);
+
+ALTER TABLE club_checkpoints DROP CONSTRAINT IF EXISTS club_checkpoints_chapter_matches_kind;
+ALTER TABLE club_checkpoints ADD CONSTRAINT club_checkpoints_chapter_matches_kind CHECK ((kind = 'chapter') = (chapter IS NOT NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_club_checkpoints_chapter ON club_checkpoints(club_id, chapter) WHERE chapter IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Migration: Add follow requests
+-- Date: 2026-10-19
+-- Description: Following a user whose profile isn't public files a request the followee
+-- approves or declines; only approved requests become rows in follows.
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+    follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_requests_followee ON follow_requests(followee_id);
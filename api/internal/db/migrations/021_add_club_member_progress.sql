@@ -0,0 +1,7 @@
+-- Migration: Link club members to the progress entry they read the club's book with
+-- Date: 2026-10-19
+-- Description: Members usually track the club's title on their own books row or through
+-- an audiobook, so the spoiler gate can't rely on progress pointing at the club's book.
+-- A member can name the entry explicitly; without one the same title or ISBN is matched.
+
+ALTER TABLE club_members ADD COLUMN IF NOT EXISTS progress_id INTEGER REFERENCES progress(id) ON DELETE SET NULL;
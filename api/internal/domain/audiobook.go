@@ -1,22 +1,71 @@
 package domain
 
-import "book_boy/api/internal/errors"
+import (
+	"book_boy/api/internal/errors"
+	"fmt"
+	"time"
+)
 
 type Audiobook struct {
-	ID          int             `json:"id"`
-	Title       string          `json:"title" binding:"required,min=1,max=500"`
-	TotalLength *CustomDuration `json:"total_length" binding:"required"`
+	ID            int             `json:"id"`
+	Title         string          `json:"title" binding:"required,min=1,max=500"`
+	TotalLength   *CustomDuration `json:"total_length" binding:"required"`
+	Author        string          `json:"author,omitempty" binding:"omitempty,max=255"`
+	LockedFields  []string        `json:"locked_fields,omitempty"`
+	AverageRating *float64        `json:"average_rating"`
+	RatingCount   int             `json:"rating_count"`
 }
 
 func (a *Audiobook) Validate() error {
 	if a.Title == "" {
-		return errors.ErrInvalidInput("title cannot be empty")
+		return errors.ErrInvalidField("title", "cannot be empty")
 	}
 	if len(a.Title) > 500 {
-		return errors.ErrInvalidInput("title cannot exceed 500 characters")
+		return errors.ErrInvalidField("title", "cannot exceed 500 characters")
+	}
+	if len(a.Author) > 255 {
+		return errors.ErrInvalidField("author", "cannot exceed 255 characters")
 	}
 	if a.TotalLength == nil || a.TotalLength.Duration <= 0 {
-		return errors.ErrInvalidInput("total_length must be greater than 0")
+		return errors.ErrInvalidField("total_length", "must be greater than 0")
 	}
 	return nil
 }
+
+// FillOmitted keeps the current author when an edit leaves it empty, so a
+// PUT that omits it doesn't blank it or lock it.
+func (a *Audiobook) FillOmitted(current *Audiobook) {
+	if a.Author == "" {
+		a.Author = current.Author
+	}
+}
+
+// Fields is the revision snapshot of the editable fields; total_length uses
+// the same HH:MM:SS form as the API.
+func (a *Audiobook) Fields() map[string]interface{} {
+	totalLength := ""
+	if a.TotalLength != nil {
+		value, _ := a.TotalLength.Value()
+		totalLength = value.(string)
+	}
+	return map[string]interface{}{
+		"title":        a.Title,
+		"total_length": totalLength,
+		"author":       a.Author,
+	}
+}
+
+// ApplyFields restores fields from a revision snapshot; missing fields are left as is.
+func (a *Audiobook) ApplyFields(fields map[string]interface{}) {
+	if v, ok := fields["title"]; ok {
+		a.Title = fmt.Sprint(v)
+	}
+	if v, ok := fields["total_length"]; ok {
+		if parsed, err := time.ParseDuration(parseHMS(fmt.Sprint(v))); err == nil && parsed > 0 {
+			a.TotalLength = &CustomDuration{Duration: parsed}
+		}
+	}
+	if v, ok := fields["author"]; ok {
+		a.Author = fmt.Sprint(v)
+	}
+}
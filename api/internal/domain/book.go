@@ -1,20 +1,89 @@
 package domain
 
-import "book_boy/api/internal/errors"
+import (
+	"book_boy/api/internal/errors"
+	"fmt"
+	"strconv"
+)
 
 type Book struct {
-	ID         int    `json:"id"`
-	ISBN       string `json:"isbn" binding:"required"`
-	Title      string `json:"title" binding:"omitempty,min=1,max=500"`
-	TotalPages int    `json:"total_pages" binding:"omitempty,min=1"`
+	ID            int      `json:"id"`
+	ISBN          string   `json:"isbn" binding:"required"`
+	Title         string   `json:"title" binding:"omitempty,min=1,max=500"`
+	TotalPages    int      `json:"total_pages" binding:"omitempty,min=1"`
+	Author        string   `json:"author,omitempty" binding:"omitempty,max=255"`
+	LockedFields  []string `json:"locked_fields,omitempty"`
+	AverageRating *float64 `json:"average_rating"`
+	RatingCount   int      `json:"rating_count"`
 }
 
 func (b *Book) Validate() error {
 	if b.Title != "" && len(b.Title) > 500 {
-		return errors.ErrInvalidInput("title cannot exceed 500 characters")
+		return errors.ErrInvalidField("title", "cannot exceed 500 characters")
+	}
+	if len(b.Author) > 255 {
+		return errors.ErrInvalidField("author", "cannot exceed 255 characters")
 	}
 	if b.TotalPages < 0 {
-		return errors.ErrInvalidInput("total_pages cannot be negative")
+		return errors.ErrInvalidField("total_pages", "cannot be negative")
 	}
 	return nil
 }
+
+// EnteredFields lists the enrichable fields a person filled in, which
+// metadata enrichment must not overwrite.
+func (b *Book) EnteredFields() []string {
+	var fields []string
+	if b.Author != "" {
+		fields = append(fields, "author")
+	}
+	if b.Title != "" {
+		fields = append(fields, "title")
+	}
+	if b.TotalPages > 0 {
+		fields = append(fields, "total_pages")
+	}
+	return fields
+}
+
+// FillOmitted copies current values into the optional fields an edit left
+// empty, so a PUT that omits them doesn't blank them or lock them.
+func (b *Book) FillOmitted(current *Book) {
+	if b.Author == "" {
+		b.Author = current.Author
+	}
+	if b.Title == "" {
+		b.Title = current.Title
+	}
+	if b.TotalPages == 0 {
+		b.TotalPages = current.TotalPages
+	}
+}
+
+// Fields is the revision snapshot of the fields users and enrichment can edit.
+func (b *Book) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"isbn":        b.ISBN,
+		"title":       b.Title,
+		"total_pages": b.TotalPages,
+		"author":      b.Author,
+	}
+}
+
+// ApplyFields restores fields from a revision snapshot; missing fields are left as is.
+func (b *Book) ApplyFields(fields map[string]interface{}) {
+	if v, ok := fields["isbn"]; ok {
+		b.ISBN = fmt.Sprint(v)
+	}
+	if v, ok := fields["title"]; ok {
+		b.Title = fmt.Sprint(v)
+	}
+	if v, ok := fields["total_pages"]; ok {
+		if pages, err := strconv.ParseFloat(fmt.Sprint(v), 64); err == nil {
+			b.TotalPages = int(pages)
+		}
+	}
+	if v, ok := fields["author"]; ok {
+		b.Author = fmt.Sprint(v)
+	}
+}
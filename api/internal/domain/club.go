@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ClubRole string
+
+const (
+	ClubRoleOwner  ClubRole = "owner"
+	ClubRoleMember ClubRole = "member"
+)
+
+type InviteStatus string
+
+const (
+	InvitePending  InviteStatus = "pending"
+	InviteAccepted InviteStatus = "accepted"
+	InviteDeclined InviteStatus = "declined"
+)
+
+type Club struct {
+	ID          int       `json:"id"`
+	OwnerID     int       `json:"owner_id"`
+	BookID      int       `json:"book_id" binding:"required"`
+	Name        string    `json:"name" binding:"required,min=1,max=100"`
+	Description string    `json:"description" binding:"max=1000"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (c *Club) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.ErrInvalidField("name", "cannot be empty")
+	}
+	if len(c.Name) > 100 {
+		return errors.ErrInvalidField("name", "cannot exceed 100 characters")
+	}
+	if len(c.Description) > 1000 {
+		return errors.ErrInvalidField("description", "cannot exceed 1000 characters")
+	}
+	if c.BookID <= 0 {
+		return errors.ErrInvalidField("book_id", "is required")
+	}
+	return nil
+}
+
+type ClubMember struct {
+	ClubID     int       `json:"club_id"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       ClubRole  `json:"role"`
+	ProgressID *int      `json:"progress_id,omitempty"`
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// LinkClubProgressRequest names the progress entry a member reads the club's
+// book with. A null progress_id goes back to matching by title and ISBN.
+type LinkClubProgressRequest struct {
+	ProgressID *int `json:"progress_id"`
+}
+
+type ClubInvite struct {
+	ID        int          `json:"id"`
+	ClubID    int          `json:"club_id"`
+	ClubName  string       `json:"club_name,omitempty"`
+	InviterID int          `json:"inviter_id"`
+	InviteeID int          `json:"invitee_id" binding:"required"`
+	Status    InviteStatus `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type CheckpointKind string
+
+const (
+	CheckpointPage    CheckpointKind = "page"
+	CheckpointChapter CheckpointKind = "chapter"
+)
+
+// Checkpoint is a point in the club's schedule that members are expected to
+// reach by OpensAt, when its discussion opens. A page checkpoint is reached at
+// Page. A chapter checkpoint names its Chapter, and since progress is tracked
+// in pages, Page is where that chapter ends.
+type Checkpoint struct {
+	ID        int            `json:"id"`
+	ClubID    int            `json:"club_id"`
+	Kind      CheckpointKind `json:"kind"`
+	Chapter   *int           `json:"chapter,omitempty" binding:"omitempty,min=1"`
+	Label     string         `json:"label" binding:"max=100"`
+	Page      int            `json:"page" binding:"required,min=1"`
+	OpensAt   time.Time      `json:"opens_at" binding:"required"`
+	IsOpen    bool           `json:"is_open"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ApplyDefaults makes a checkpoint without a kind a page checkpoint and labels
+// an unlabelled one after its chapter or page.
+func (c *Checkpoint) ApplyDefaults() {
+	if c.Kind == "" {
+		c.Kind = CheckpointPage
+	}
+	if strings.TrimSpace(c.Label) != "" {
+		return
+	}
+	if c.Kind == CheckpointChapter && c.Chapter != nil {
+		c.Label = fmt.Sprintf("Chapter %d", *c.Chapter)
+	} else {
+		c.Label = fmt.Sprintf("Page %d", c.Page)
+	}
+}
+
+func (c *Checkpoint) Validate(totalPages int) error {
+	switch c.Kind {
+	case CheckpointPage:
+		if c.Chapter != nil {
+			return errors.ErrInvalidField("chapter", "only applies to chapter checkpoints")
+		}
+	case CheckpointChapter:
+		if c.Chapter == nil || *c.Chapter < 1 {
+			return errors.ErrInvalidField("chapter", "must be at least 1 for a chapter checkpoint")
+		}
+	default:
+		return errors.ErrInvalidField("kind", "must be page or chapter")
+	}
+	if strings.TrimSpace(c.Label) == "" {
+		return errors.ErrInvalidField("label", "cannot be empty")
+	}
+	if len(c.Label) > 100 {
+		return errors.ErrInvalidField("label", "cannot exceed 100 characters")
+	}
+	if c.Page < 1 {
+		return errors.ErrInvalidField("page", "must be at least 1")
+	}
+	if totalPages > 0 && c.Page > totalPages {
+		return errors.ErrInvalidField("page", "cannot exceed the book's total pages")
+	}
+	if c.OpensAt.IsZero() {
+		return errors.ErrInvalidField("opens_at", "is required")
+	}
+	return nil
+}
+
+type ClubPost struct {
+	ID           int       `json:"id"`
+	CheckpointID int       `json:"checkpoint_id"`
+	UserID       int       `json:"user_id"`
+	Username     string    `json:"username"`
+	Body         string    `json:"body" binding:"required,min=1,max=5000"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (p *ClubPost) Validate() error {
+	if strings.TrimSpace(p.Body) == "" {
+		return errors.ErrInvalidField("body", "cannot be empty")
+	}
+	if len(p.Body) > 5000 {
+		return errors.ErrInvalidField("body", "cannot exceed 5000 characters")
+	}
+	return nil
+}
+
+// MemberSchedule shows where one member is against the club's schedule.
+type MemberSchedule struct {
+	UserID              int      `json:"user_id"`
+	Username            string   `json:"username"`
+	Role                ClubRole `json:"role"`
+	CurrentPage         *int     `json:"current_page"`
+	ReachedCheckpointID *int     `json:"reached_checkpoint_id"`
+	OnSchedule          bool     `json:"on_schedule"`
+}
+
+type ClubSchedule struct {
+	Club        Club             `json:"club"`
+	TotalPages  int              `json:"total_pages"`
+	Checkpoints []Checkpoint     `json:"checkpoints"`
+	Members     []MemberSchedule `json:"members"`
+}
+
+type CheckpointOpenedEvent struct {
+	ClubID     int        `json:"club_id"`
+	ClubName   string     `json:"club_name"`
+	Checkpoint Checkpoint `json:"checkpoint"`
+}
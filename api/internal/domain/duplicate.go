@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"fmt"
+)
+
+const MaxMergeDuplicates = 20
+
+// DuplicateCandidate is a catalog entry that looks like the same work as the
+// record being checked. Exactly one of Book and Audiobook is set.
+type DuplicateCandidate struct {
+	Book            *Book      `json:"book,omitempty"`
+	Audiobook       *Audiobook `json:"audiobook,omitempty"`
+	TitleSimilarity float64    `json:"title_similarity"`
+	Confidence      float64    `json:"confidence"`
+	Reasons         []string   `json:"reasons"`
+}
+
+type MergeRequest struct {
+	DuplicateIDs []int `json:"duplicate_ids" binding:"required,min=1"`
+}
+
+func (r *MergeRequest) Validate(canonicalID int) error {
+	if len(r.DuplicateIDs) == 0 {
+		return errors.ErrInvalidField("duplicate_ids", "cannot be empty")
+	}
+	if len(r.DuplicateIDs) > MaxMergeDuplicates {
+		return errors.ErrInvalidInput(fmt.Sprintf("cannot merge more than %d records at once", MaxMergeDuplicates))
+	}
+	seen := make(map[int]bool, len(r.DuplicateIDs))
+	for _, id := range r.DuplicateIDs {
+		if id <= 0 {
+			return errors.ErrInvalidField("duplicate_ids", "must be positive")
+		}
+		if id == canonicalID {
+			return errors.ErrInvalidInput("cannot merge a record into itself")
+		}
+		if seen[id] {
+			return errors.ErrInvalidField("duplicate_ids", "cannot repeat")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// MergeResult reports what a merge moved. ProgressCombined counts users who
+// were tracking both records and kept only their furthest-along entry.
+type MergeResult struct {
+	CanonicalID      int   `json:"canonical_id"`
+	MergedIDs        []int `json:"merged_ids"`
+	ProgressMoved    int   `json:"progress_moved"`
+	ProgressCombined int   `json:"progress_combined"`
+}
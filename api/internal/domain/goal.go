@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"time"
+)
+
+type GoalPeriod string
+
+const (
+	GoalPeriodYearly  GoalPeriod = "yearly"
+	GoalPeriodMonthly GoalPeriod = "monthly"
+)
+
+type GoalMetric string
+
+const (
+	GoalMetricBooks          GoalMetric = "books"
+	GoalMetricPages          GoalMetric = "pages"
+	GoalMetricListeningHours GoalMetric = "listening_hours"
+)
+
+type GoalPace string
+
+const (
+	GoalPaceAhead     GoalPace = "ahead"
+	GoalPaceOnTrack   GoalPace = "on_track"
+	GoalPaceBehind    GoalPace = "behind"
+	GoalPaceCompleted GoalPace = "completed"
+)
+
+type Goal struct {
+	ID            int           `json:"id"`
+	UserID        int           `json:"user_id"`
+	Period        GoalPeriod    `json:"period" binding:"required,oneof=yearly monthly"`
+	Metric        GoalMetric    `json:"metric" binding:"required,oneof=books pages listening_hours"`
+	Target        int           `json:"target" binding:"required,min=1"`
+	Year          int           `json:"year" binding:"required,min=1900"`
+	Month         *int          `json:"month,omitempty" binding:"omitempty,min=1,max=12"`
+	LastMilestone int           `json:"last_milestone"`
+	Progress      *GoalProgress `json:"progress,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+func (g *Goal) Validate() error {
+	if g.Period != GoalPeriodYearly && g.Period != GoalPeriodMonthly {
+		return errors.ErrInvalidField("period", "must be yearly or monthly")
+	}
+	if g.Metric != GoalMetricBooks && g.Metric != GoalMetricPages && g.Metric != GoalMetricListeningHours {
+		return errors.ErrInvalidField("metric", "must be books, pages or listening_hours")
+	}
+	if g.Target <= 0 {
+		return errors.ErrInvalidField("target", "must be greater than 0")
+	}
+	if g.Year < 1900 {
+		return errors.ErrInvalidField("year", "is invalid")
+	}
+	if g.Period == GoalPeriodMonthly && (g.Month == nil || *g.Month < 1 || *g.Month > 12) {
+		return errors.ErrInvalidField("month", "between 1 and 12 is required for monthly goals")
+	}
+	if g.Period == GoalPeriodYearly && g.Month != nil {
+		return errors.ErrInvalidField("month", "must be omitted for yearly goals")
+	}
+	return nil
+}
+
+// Bounds returns the [start, end) window the goal is measured over.
+func (g *Goal) Bounds(loc *time.Location) (time.Time, time.Time) {
+	if g.Period == GoalPeriodMonthly && g.Month != nil {
+		start := time.Date(g.Year, time.Month(*g.Month), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := time.Date(g.Year, time.January, 1, 0, 0, 0, 0, loc)
+	return start, start.AddDate(1, 0, 0)
+}
+
+type GoalProgress struct {
+	Current       float64  `json:"current"`
+	Target        int      `json:"target"`
+	Percent       int      `json:"percent"`
+	ExpectedByNow float64  `json:"expected_by_now"`
+	Difference    float64  `json:"difference"`
+	Pace          GoalPace `json:"pace"`
+}
+
+// ProgressActivity is one dated position change recorded from a progress update.
+type ProgressActivity struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	ProgressID   int       `json:"progress_id"`
+	PagesDelta   int       `json:"pages_delta"`
+	SecondsDelta int       `json:"seconds_delta"`
+	Finished     bool      `json:"finished"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+type ActivityTotals struct {
+	Pages         int
+	Seconds       int
+	BooksFinished int
+}
+
+type GoalMilestoneEvent struct {
+	GoalID    int          `json:"goal_id"`
+	Milestone int          `json:"milestone"`
+	Goal      Goal         `json:"goal"`
+	Progress  GoalProgress `json:"progress"`
+}
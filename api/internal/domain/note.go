@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"strings"
+	"time"
+)
+
+type NoteKind string
+
+const (
+	NoteKindNote      NoteKind = "note"
+	NoteKindHighlight NoteKind = "highlight"
+)
+
+type Note struct {
+	ID            int             `json:"id"`
+	ProgressID    int             `json:"progress_id"`
+	UserID        int             `json:"user_id"`
+	Kind          NoteKind        `json:"kind" binding:"omitempty,oneof=note highlight"`
+	Body          string          `json:"body" binding:"required,min=1,max=10000"`
+	Page          *int            `json:"page,omitempty" binding:"omitempty,min=1"`
+	AudiobookTime *CustomDuration `json:"audiobook_time,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func (n *Note) Validate() error {
+	if n.Kind == "" {
+		n.Kind = NoteKindNote
+	}
+	if n.Kind != NoteKindNote && n.Kind != NoteKindHighlight {
+		return errors.ErrInvalidField("kind", "must be note or highlight")
+	}
+	if strings.TrimSpace(n.Body) == "" {
+		return errors.ErrInvalidField("body", "cannot be empty")
+	}
+	if len(n.Body) > 10000 {
+		return errors.ErrInvalidField("body", "cannot exceed 10000 characters")
+	}
+	if n.Page != nil && *n.Page <= 0 {
+		return errors.ErrInvalidField("page", "must be greater than 0")
+	}
+	if n.AudiobookTime != nil && n.AudiobookTime.Duration < 0 {
+		return errors.ErrInvalidField("audiobook_time", "cannot be negative")
+	}
+	if n.Kind == NoteKindHighlight && n.Page == nil && n.AudiobookTime == nil {
+		return errors.ErrInvalidInput("highlight must be anchored to a page or audiobook_time")
+	}
+	return nil
+}
+
+type NoteSearchResult struct {
+	Note     Note    `json:"note"`
+	Headline string  `json:"headline"`
+	Rank     float64 `json:"rank"`
+}
+
+type SetTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// NormalizeTags lowercases and trims tags, dropping blanks and duplicates
+// while keeping the caller's order.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > 50 {
+			return nil, errors.ErrInvalidField("tags", "cannot exceed 50 characters")
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
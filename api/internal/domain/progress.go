@@ -2,6 +2,7 @@ package domain
 
 import (
 	"book_boy/api/internal/errors"
+	"fmt"
 	"time"
 )
 
@@ -20,6 +21,7 @@ type Progress struct {
 	BookPage          *int            `json:"book_page,omitempty" binding:"omitempty,min=1"`
 	AudiobookTime     *CustomDuration `json:"audiobook_time,omitempty"`
 	CompletionPercent int             `json:"completion_percent,omitempty"`
+	Version           int             `json:"version"`
 	CreatedAt         time.Time       `json:"created_at"`
 	UpdatedAt         time.Time       `json:"updated_at"`
 }
@@ -29,14 +31,100 @@ func (p *Progress) Validate() error {
 		return errors.ErrInvalidInput("progress must have at least a book_id or audiobook_id")
 	}
 	if p.BookPage != nil && *p.BookPage <= 0 {
-		return errors.ErrInvalidInput("book_page must be greater than 0")
+		return errors.ErrInvalidField("book_page", "must be greater than 0")
 	}
 	if p.AudiobookTime != nil && p.AudiobookTime.Duration < 0 {
-		return errors.ErrInvalidInput("audiobook_time cannot be negative")
+		return errors.ErrInvalidField("audiobook_time", "cannot be negative")
 	}
 	return nil
 }
 
+type MergeMode string
+
+const (
+	// MergeModeStrict applies the write as sent, subject to IfMatch.
+	MergeModeStrict MergeMode = ""
+	// MergeModeFurthest ignores version conflicts and keeps whichever position
+	// is further along, so offline clients can replay writes safely.
+	MergeModeFurthest MergeMode = "furthest"
+)
+
+// UpdateCondition guards a position update. IfMatch is the version the client
+// last saw; zero means unconditional.
+type UpdateCondition struct {
+	IfMatch int
+	Mode    MergeMode
+}
+
+const MaxProgressBatch = 200
+
+// ProgressBatchItem moves one entry. Exactly one of Page, AudiobookTime or
+// Status is set; Status "completed" moves the entry to its last page or the
+// end of the audiobook.
+type ProgressBatchItem struct {
+	ProgressID    int             `json:"progress_id"`
+	Page          *int            `json:"page,omitempty"`
+	AudiobookTime *CustomDuration `json:"audiobook_time,omitempty"`
+	Status        ProgressStatus  `json:"status,omitempty"`
+}
+
+// ProgressBatchRequest applies every update or none when Atomic is set, and
+// otherwise applies what it can and reports each item.
+type ProgressBatchRequest struct {
+	Atomic  bool                `json:"atomic"`
+	Updates []ProgressBatchItem `json:"updates"`
+}
+
+func (r *ProgressBatchRequest) Validate() error {
+	if len(r.Updates) == 0 {
+		return errors.ErrInvalidField("updates", "cannot be empty")
+	}
+	if len(r.Updates) > MaxProgressBatch {
+		return errors.ErrInvalidInput(fmt.Sprintf("cannot update more than %d entries at once", MaxProgressBatch))
+	}
+	for i, item := range r.Updates {
+		if item.ProgressID <= 0 {
+			return errors.ErrInvalidField(fmt.Sprintf("updates[%d].progress_id", i), "is required")
+		}
+		set := 0
+		if item.Page != nil {
+			set++
+			if *item.Page < 1 {
+				return errors.ErrInvalidField(fmt.Sprintf("updates[%d].page", i), "must be at least 1")
+			}
+		}
+		if item.AudiobookTime != nil {
+			set++
+			if item.AudiobookTime.Duration < 0 {
+				return errors.ErrInvalidField(fmt.Sprintf("updates[%d].audiobook_time", i), "cannot be negative")
+			}
+		}
+		if item.Status != "" {
+			set++
+			if item.Status != ProgressStatusCompleted {
+				return errors.ErrInvalidField(fmt.Sprintf("updates[%d].status", i), fmt.Sprintf("can only be %q", ProgressStatusCompleted))
+			}
+		}
+		if set != 1 {
+			return errors.ErrInvalidInput(fmt.Sprintf("updates[%d]: set exactly one of page, audiobook_time or status", i))
+		}
+	}
+	return nil
+}
+
+type ProgressBatchResult struct {
+	Index      int       `json:"index"`
+	ProgressID int       `json:"progress_id"`
+	Progress   *Progress `json:"progress,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type ProgressBatchResponse struct {
+	Applied int                   `json:"applied"`
+	Failed  int                   `json:"failed"`
+	Results []ProgressBatchResult `json:"results"`
+}
+
 type EnrichedProgress struct {
 	Progress          Progress
 	Book              *Book
@@ -0,0 +1,19 @@
+package domain
+
+// RecommendationCandidate is one scored signal for a book from a single source
+// (co-reading or title similarity) before the sources are blended.
+type RecommendationCandidate struct {
+	BookID     int
+	CoReaders  int
+	CoScore    float64
+	Similarity float64
+	SimilarTo  string
+}
+
+type Recommendation struct {
+	Book            Book    `json:"book"`
+	Score           float64 `json:"score"`
+	CoReaders       int     `json:"co_readers"`
+	TitleSimilarity float64 `json:"title_similarity"`
+	SimilarTo       string  `json:"similar_to,omitempty"`
+}
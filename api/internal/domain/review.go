@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"math"
+	"time"
+)
+
+type Review struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	Username    string    `json:"username"`
+	BookID      *int      `json:"book_id,omitempty"`
+	AudiobookID *int      `json:"audiobook_id,omitempty"`
+	Rating      float64   `json:"rating"`
+	Body        string    `json:"body"`
+	Spoiler     bool      `json:"spoiler"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ReviewRequest struct {
+	Rating  float64 `json:"rating" binding:"required"`
+	Body    string  `json:"body" binding:"max=10000"`
+	Spoiler bool    `json:"spoiler"`
+}
+
+func (r *ReviewRequest) Validate() error {
+	if r.Rating < 0.5 || r.Rating > 5 {
+		return errors.ErrInvalidField("rating", "must be between 0.5 and 5")
+	}
+	if math.Mod(r.Rating*2, 1) != 0 {
+		return errors.ErrInvalidField("rating", "must be in half-star steps")
+	}
+	if len(r.Body) > 10000 {
+		return errors.ErrInvalidField("body", "cannot exceed 10000 characters")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type RevisionSource string
+
+const (
+	RevisionCreated  RevisionSource = "created"
+	RevisionUser     RevisionSource = "user"
+	RevisionMetadata RevisionSource = "metadata"
+	RevisionRevert   RevisionSource = "revert"
+)
+
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// CatalogRevision is one numbered edit to a book or audiobook. Snapshot holds
+// every tracked field after the edit, so reverting to a version restores it.
+type CatalogRevision struct {
+	ID         int                    `json:"id"`
+	Version    int                    `json:"version"`
+	Source     RevisionSource         `json:"source"`
+	EditorID   *int                   `json:"editor_id,omitempty"`
+	RevertedTo *int                   `json:"reverted_to,omitempty"`
+	Changes    map[string]FieldChange `json:"changes"`
+	Snapshot   map[string]interface{} `json:"snapshot"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// DiffFields compares two snapshots field by field. Values are compared by
+// their printed form because snapshots read back from JSON hold float64s.
+func DiffFields(before, after map[string]interface{}) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for field, to := range after {
+		from := before[field]
+		if fmt.Sprint(from) != fmt.Sprint(to) {
+			changes[field] = FieldChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+// MergeLockedFields adds the changed fields to the locked set, sorted so the
+// stored array is stable.
+func MergeLockedFields(locked []string, changes map[string]FieldChange) []string {
+	set := make(map[string]bool, len(locked)+len(changes))
+	for _, field := range locked {
+		set[field] = true
+	}
+	for field := range changes {
+		set[field] = true
+	}
+	merged := make([]string, 0, len(set))
+	for field := range set {
+		merged = append(merged, field)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+func IsLocked(locked []string, field string) bool {
+	for _, f := range locked {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "book_boy/api/internal/errors"
+
+type SearchFormat string
+
+const (
+	SearchFormatAll       SearchFormat = "all"
+	SearchFormatBook      SearchFormat = "book"
+	SearchFormatAudiobook SearchFormat = "audiobook"
+)
+
+type SearchQuery struct {
+	Query  string
+	Format SearchFormat
+	Prefix bool
+	Limit  int
+	// TSQuery is the to_tsquery input the service builds for prefix mode;
+	// plain searches leave it empty and the repo uses plainto_tsquery.
+	TSQuery string
+}
+
+func (q *SearchQuery) Validate() error {
+	if q.Query == "" {
+		return errors.ErrInvalidField("q", "is required")
+	}
+	if len(q.Query) > 200 {
+		return errors.ErrInvalidField("q", "cannot exceed 200 characters")
+	}
+	if q.Format != SearchFormatAll && q.Format != SearchFormatBook && q.Format != SearchFormatAudiobook {
+		return errors.ErrInvalidField("format", "must be all, book or audiobook")
+	}
+	return nil
+}
+
+type SearchResult struct {
+	Type      SearchFormat    `json:"type"`
+	ID        int             `json:"id"`
+	Title     string          `json:"title"`
+	Author    string          `json:"author,omitempty"`
+	ISBN      string          `json:"isbn,omitempty"`
+	Score     float64         `json:"score"`
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+// SearchHighlight holds the matched fields with query terms wrapped in <mark>.
+type SearchHighlight struct {
+	Title  string `json:"title"`
+	Author string `json:"author,omitempty"`
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"time"
+)
+
+type Shelf struct {
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id"`
+	Name        string      `json:"name" binding:"required,min=1,max=100"`
+	Description string      `json:"description" binding:"max=1000"`
+	Items       []ShelfItem `json:"items,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+func (s *Shelf) Validate() error {
+	if s.Name == "" {
+		return errors.ErrInvalidField("name", "cannot be empty")
+	}
+	if len(s.Name) > 100 {
+		return errors.ErrInvalidField("name", "cannot exceed 100 characters")
+	}
+	if len(s.Description) > 1000 {
+		return errors.ErrInvalidField("description", "cannot exceed 1000 characters")
+	}
+	return nil
+}
+
+type ShelfItem struct {
+	ID          int        `json:"id"`
+	ShelfID     int        `json:"shelf_id"`
+	BookID      *int       `json:"book_id,omitempty"`
+	AudiobookID *int       `json:"audiobook_id,omitempty"`
+	Book        *Book      `json:"book,omitempty"`
+	Audiobook   *Audiobook `json:"audiobook,omitempty"`
+	Position    int        `json:"position"`
+	AddedAt     time.Time  `json:"added_at"`
+}
+
+func (i *ShelfItem) Validate() error {
+	if i.BookID == nil && i.AudiobookID == nil {
+		return errors.ErrInvalidInput("shelf item must have a book_id or audiobook_id")
+	}
+	if i.BookID != nil && i.AudiobookID != nil {
+		return errors.ErrInvalidInput("shelf item cannot have both book_id and audiobook_id")
+	}
+	return nil
+}
+
+type AddShelfItemRequest struct {
+	BookID      *int `json:"book_id"`
+	AudiobookID *int `json:"audiobook_id"`
+}
+
+type ReorderShelfItemsRequest struct {
+	ItemIDs []int `json:"item_ids" binding:"required"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+type ActivityType string
+
+const (
+	ActivityStarted  ActivityType = "started"
+	ActivityFinished ActivityType = "finished"
+	ActivityRated    ActivityType = "rated"
+)
+
+// ActivityEvent is one entry in the activity feed, produced when a user starts
+// or finishes something or rates it.
+type ActivityEvent struct {
+	ID          int          `json:"id"`
+	UserID      int          `json:"user_id"`
+	Username    string       `json:"username"`
+	Type        ActivityType `json:"type"`
+	ProgressID  *int         `json:"progress_id,omitempty"`
+	BookID      *int         `json:"book_id,omitempty"`
+	AudiobookID *int         `json:"audiobook_id,omitempty"`
+	Title       string       `json:"title"`
+	Rating      *float64     `json:"rating,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type FeedPage struct {
+	Events     []ActivityEvent `json:"events"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type FollowStatus string
+
+const (
+	FollowStatusFollowing FollowStatus = "following"
+	FollowStatusRequested FollowStatus = "requested"
+)
+
+// FollowResult reports whether a follow took effect or is waiting on the
+// followee's approval because their profile isn't public.
+type FollowResult struct {
+	Status FollowStatus `json:"status"`
+}
+
+type UserSummary struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type Profile struct {
+	ID               int                `json:"id"`
+	Username         string             `json:"username"`
+	FollowerCount    int                `json:"follower_count"`
+	FollowingCount   int                `json:"following_count"`
+	IsFollowing      bool               `json:"is_following"`
+	CurrentlyReading []EnrichedProgress `json:"currently_reading,omitempty"`
+}
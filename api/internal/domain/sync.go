@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"book_boy/api/internal/errors"
+	"fmt"
+	"time"
+)
+
+const MaxSyncMutations = 500
+
+// SyncMutation is one position change a client recorded while offline.
+// RecordedAt is the client's clock at the time; it orders mutations on the
+// same entry and dates the reading activity they produce.
+type SyncMutation struct {
+	ProgressID    int             `json:"progress_id"`
+	RecordedAt    time.Time       `json:"recorded_at"`
+	BookPage      *int            `json:"book_page,omitempty"`
+	AudiobookTime *CustomDuration `json:"audiobook_time,omitempty"`
+}
+
+type SyncRequest struct {
+	SyncToken string         `json:"sync_token"`
+	Mutations []SyncMutation `json:"mutations"`
+}
+
+func (r *SyncRequest) Validate() error {
+	if len(r.Mutations) > MaxSyncMutations {
+		return errors.ErrInvalidInput(fmt.Sprintf("cannot sync more than %d mutations at once", MaxSyncMutations))
+	}
+	for i, m := range r.Mutations {
+		if m.ProgressID <= 0 {
+			return errors.ErrInvalidField(fmt.Sprintf("mutations[%d].progress_id", i), "is required")
+		}
+		if m.RecordedAt.IsZero() {
+			return errors.ErrInvalidField(fmt.Sprintf("mutations[%d].recorded_at", i), "is required")
+		}
+		if m.BookPage == nil && m.AudiobookTime == nil {
+			return errors.ErrInvalidInput(fmt.Sprintf("mutations[%d]: book_page or audiobook_time is required", i))
+		}
+		if m.BookPage != nil && *m.BookPage < 1 {
+			return errors.ErrInvalidField(fmt.Sprintf("mutations[%d].book_page", i), "must be at least 1")
+		}
+		if m.AudiobookTime != nil && m.AudiobookTime.Duration < 0 {
+			return errors.ErrInvalidField(fmt.Sprintf("mutations[%d].audiobook_time", i), "cannot be negative")
+		}
+	}
+	return nil
+}
+
+type SyncStatus string
+
+const (
+	// SyncApplied means the mutation moved the entry forward.
+	SyncApplied SyncStatus = "applied"
+	// SyncSuperseded means the server, or an earlier mutation in the batch,
+	// was already at or past the mutation's position.
+	SyncSuperseded SyncStatus = "superseded"
+	// SyncRejected means the mutation can't apply to the entry at all.
+	SyncRejected SyncStatus = "rejected"
+)
+
+// SyncResult reports what happened to the mutation at Index in the request.
+type SyncResult struct {
+	Index      int        `json:"index"`
+	ProgressID int        `json:"progress_id"`
+	Status     SyncStatus `json:"status"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// SyncTarget is a progress entry locked for a sync, with the totals needed to
+// convert between page and time.
+type SyncTarget struct {
+	Progress    Progress
+	TotalPages  int
+	TotalLength *CustomDuration
+}
+
+// SyncChanges is everything that changed for a user since a sync horizon.
+type SyncChanges struct {
+	Progress   []Progress
+	DeletedIDs []int
+	// Horizon is the oldest transaction that was still running when the
+	// changes were read; the next sync resumes from it.
+	Horizon int64
+}
+
+type SyncResponse struct {
+	SyncToken string       `json:"sync_token"`
+	Results   []SyncResult `json:"results"`
+	Changes   []Progress   `json:"changes"`
+	Deleted   []int        `json:"deleted"`
+}
@@ -18,10 +18,10 @@ type StartTrackingRequest struct {
 
 func (r *StartTrackingRequest) Validate() error {
 	if r.Format == "book" && r.TotalPages <= 0 {
-		return errors.ErrInvalidInput("total_pages is required and must be > 0 for books")
+		return errors.ErrInvalidField("total_pages", "is required and must be > 0 for books")
 	}
 	if r.Format == "audiobook" && r.TotalLength == "" {
-		return errors.ErrInvalidInput("total_length is required for audiobooks")
+		return errors.ErrInvalidField("total_length", "is required for audiobooks")
 	}
 	return nil
 }
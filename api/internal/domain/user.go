@@ -2,12 +2,53 @@ package domain
 
 import "time"
 
+type PrivacyLevel string
+
+const (
+	PrivacyPublic    PrivacyLevel = "public"
+	PrivacyFollowers PrivacyLevel = "followers"
+	PrivacyPrivate   PrivacyLevel = "private"
+)
+
+func (p PrivacyLevel) IsValid() bool {
+	return p == PrivacyPublic || p == PrivacyFollowers || p == PrivacyPrivate
+}
+
+// Allows reports whether a viewer may see something guarded by this level.
+// Owners always can; followers-only content needs an existing follow.
+func (p PrivacyLevel) Allows(isOwner bool, isFollower bool) bool {
+	switch {
+	case isOwner, p == PrivacyPublic:
+		return true
+	case p == PrivacyFollowers:
+		return isFollower
+	default:
+		return false
+	}
+}
+
 type User struct {
-	ID           int       `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID              int          `json:"id"`
+	Username        string       `json:"username"`
+	Email           string       `json:"email,omitempty"`
+	PasswordHash    string       `json:"-"`
+	ReviewPrivacy   PrivacyLevel `json:"review_privacy"`
+	ProfilePrivacy  PrivacyLevel `json:"profile_privacy"`
+	ProgressPrivacy PrivacyLevel `json:"progress_privacy"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+type PrivacySettings struct {
+	ProfilePrivacy  PrivacyLevel `json:"profile_privacy"`
+	ProgressPrivacy PrivacyLevel `json:"progress_privacy"`
+	ReviewPrivacy   PrivacyLevel `json:"review_privacy"`
+}
+
+// UpdatePrivacyRequest changes only the levels that are present.
+type UpdatePrivacyRequest struct {
+	ProfilePrivacy  *PrivacyLevel `json:"profile_privacy"`
+	ProgressPrivacy *PrivacyLevel `json:"progress_privacy"`
+	ReviewPrivacy   *PrivacyLevel `json:"review_privacy"`
 }
 
 type RegisterRequest struct {
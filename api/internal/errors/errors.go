@@ -1,26 +1,107 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+)
 
+// ValidationError is bad client input. Field names the offending JSON field
+// when there is a single one.
 type ValidationError struct {
+	Field   string
 	Message string
 }
 
 func (e ValidationError) Error() string {
-	return e.Message
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + " " + e.Message
 }
 
 func ErrInvalidInput(msg string) error {
 	return ValidationError{Message: msg}
 }
 
+// ErrInvalidField reports a problem with one field, e.g.
+// ErrInvalidField("title", "cannot be empty").
+func ErrInvalidField(field, msg string) error {
+	return ValidationError{Field: field, Message: msg}
+}
+
+// ValidationErrors reports several bad fields at once, as request binding
+// does when more than one rule fails.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
 func IsValidationError(err error) bool {
-	_, ok := err.(ValidationError)
-	return ok
+	var v ValidationError
+	var vs ValidationErrors
+	return stderrors.As(err, &v) || stderrors.As(err, &vs)
 }
 
 var (
 	ErrNotFound     = fmt.Errorf("resource not found")
 	ErrUnauthorized = fmt.Errorf("unauthorized")
+	ErrForbidden    = fmt.Errorf("forbidden")
 	ErrConflict     = fmt.Errorf("resource conflict")
+	// ErrPreconditionFailed means the caller's expected version is stale.
+	ErrPreconditionFailed = fmt.Errorf("precondition failed")
 )
+
+// Error is one of the sentinel kinds above with a message meant for the
+// client. errors.Is matches it against its kind, so callers can keep
+// comparing against the sentinels.
+type Error struct {
+	Kind error
+	// Code overrides the kind's stable error code when a client needs to
+	// tell this case apart from others of the same kind.
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NotFound(msg string) error {
+	return &Error{Kind: ErrNotFound, Message: msg}
+}
+
+func Unauthorized(msg string) error {
+	return &Error{Kind: ErrUnauthorized, Message: msg}
+}
+
+func Forbidden(msg string) error {
+	return &Error{Kind: ErrForbidden, Message: msg}
+}
+
+func Conflict(msg string) error {
+	return &Error{Kind: ErrConflict, Message: msg}
+}
+
+func PreconditionFailed(msg string) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: msg}
+}
+
+// Is reports whether err is, or wraps, target.
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target.
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
@@ -0,0 +1,151 @@
+// Package health serves the liveness and readiness probes. Readiness runs a
+// check per dependency, concurrently and each under its own timeout, and
+// reports every component so an operator can see what's wrong.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+// Overall readiness statuses.
+const (
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// Component statuses.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check probes one dependency. A failing critical check makes the instance
+// not ready; a failing non-critical one only marks it degraded, for
+// dependencies the API can limp along without.
+type Check struct {
+	Name     string
+	Critical bool
+	// Timeout bounds Probe; zero means two seconds.
+	Timeout time.Duration
+	Probe   func(ctx context.Context) error
+}
+
+// ComponentStatus is one check's result.
+type ComponentStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness response body.
+type Report struct {
+	Status     string            `json:"status"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// Checker runs the readiness checks.
+type Checker struct {
+	checks []Check
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// Add registers another check.
+func (h *Checker) Add(check Check) {
+	h.checks = append(h.checks, check)
+}
+
+// Run probes every dependency at once, so the slowest check bounds the
+// report rather than their sum.
+func (h *Checker) Run(ctx context.Context) Report {
+	components := make([]ComponentStatus, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			components[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Components: components}
+	for _, c := range components {
+		if c.Status == StatusUp {
+			continue
+		}
+		if c.Critical {
+			report.Status = StatusNotReady
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func run(ctx context.Context, check Check) ComponentStatus {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe(ctx, check.Probe)
+	status := ComponentStatus{
+		Name:      check.Name,
+		Status:    StatusUp,
+		Critical:  check.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// probe returns when p does or when ctx is done, so a probe that ignores its
+// context can't hold the report past the timeout.
+func probe(ctx context.Context, p func(ctx context.Context) error) error {
+	result := make(chan error, 1)
+	go func() { result <- p(ctx) }()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Readyz reports 200 while the instance is ready or degraded and 503 when a
+// critical dependency is down.
+func (h *Checker) Readyz(c *gin.Context) {
+	report := h.Run(c.Request.Context())
+	code := http.StatusOK
+	if report.Status == StatusNotReady {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
+
+// Livez reports that the process is up and serving. It checks no
+// dependencies, so an outage elsewhere doesn't get healthy pods restarted.
+func Livez(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
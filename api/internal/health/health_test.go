@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func up(context.Context) error { return nil }
+
+func down(context.Context) error { return errors.New("connection refused") }
+
+func readyz(t *testing.T, checker *Checker) (int, Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/readyz", checker.Readyz)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return w.Code, report
+}
+
+func TestReadyz_AllUp(t *testing.T) {
+	code, report := readyz(t, NewChecker(
+		Check{Name: "postgres", Critical: true, Probe: up},
+		Check{Name: "cache", Probe: up},
+	))
+
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if report.Status != StatusReady {
+		t.Errorf("expected %s, got %s", StatusReady, report.Status)
+	}
+	if len(report.Components) != 2 || report.Components[0].Name != "postgres" || report.Components[1].Name != "cache" {
+		t.Errorf("expected the components in check order, got %+v", report.Components)
+	}
+}
+
+func TestReadyz_CriticalDownIsNotReady(t *testing.T) {
+	code, report := readyz(t, NewChecker(
+		Check{Name: "postgres", Critical: true, Probe: down},
+		Check{Name: "cache", Probe: up},
+	))
+
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", code)
+	}
+	if report.Status != StatusNotReady {
+		t.Errorf("expected %s, got %s", StatusNotReady, report.Status)
+	}
+	if c := report.Components[0]; c.Status != StatusDown || c.Error != "connection refused" {
+		t.Errorf("expected postgres down with its error, got %+v", c)
+	}
+}
+
+func TestReadyz_NonCriticalDownIsDegraded(t *testing.T) {
+	code, report := readyz(t, NewChecker(
+		Check{Name: "postgres", Critical: true, Probe: up},
+		Check{Name: "rabbitmq", Probe: down},
+	))
+
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if report.Status != StatusDegraded {
+		t.Errorf("expected %s, got %s", StatusDegraded, report.Status)
+	}
+}
+
+func TestRun_TimesOutSlowProbe(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	checker := NewChecker(Check{
+		Name:     "postgres",
+		Critical: true,
+		Timeout:  20 * time.Millisecond,
+		// Ignores its context, like a driver call that hangs.
+		Probe: func(context.Context) error {
+			<-block
+			return nil
+		},
+	})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the check to give up at its timeout, took %s", elapsed)
+	}
+	c := report.Components[0]
+	if c.Status != StatusDown || c.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected a timed out check, got %+v", c)
+	}
+	if c.LatencyMS < 20 {
+		t.Errorf("expected the latency to cover the timeout, got %vms", c.LatencyMS)
+	}
+}
+
+func TestLivez(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/livez", Livez)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,156 @@
+package infra
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"book_boy/api/internal/logging"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Reconnect attempts back off from the first delay, doubling up to the max.
+const (
+	amqpReconnectDelay    = 500 * time.Millisecond
+	amqpMaxReconnectDelay = 30 * time.Second
+)
+
+// AMQPConnection keeps a RabbitMQ connection open. When the broker drops it,
+// the connection is redialled with backoff and every registered setup is run
+// again, so publishers and consumers reopen their channels and redeclare
+// their topology.
+type AMQPConnection struct {
+	url    string
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	conn   *amqp.Connection
+	setups []func(conn *amqp.Connection) error
+	closed bool
+	done   chan struct{}
+}
+
+// DialAMQP connects to url. Only this first dial fails outright; later
+// losses are recovered in the background.
+func DialAMQP(url string, logger *slog.Logger) (*AMQPConnection, error) {
+	c := &AMQPConnection{
+		url:    url,
+		logger: logging.OrDiscard(logger),
+		done:   make(chan struct{}),
+	}
+	conn, notify, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	go c.watch(conn, notify)
+	return c, nil
+}
+
+// Register runs setup on the current connection and again after every
+// reconnect. setup should open its own channels and declare whatever it
+// publishes to or consumes from.
+func (c *AMQPConnection) Register(setup func(conn *amqp.Connection) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return amqp.ErrClosed
+	}
+	if err := setup(c.conn); err != nil {
+		return err
+	}
+	c.setups = append(c.setups, setup)
+	return nil
+}
+
+// IsConnected reports whether the connection is currently open.
+func (c *AMQPConnection) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.closed && c.conn != nil && !c.conn.IsClosed()
+}
+
+// Close closes the connection and stops reconnecting.
+func (c *AMQPConnection) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn.IsClosed() {
+		return nil
+	}
+	return conn.Close()
+}
+
+// connect dials and runs the registered setups, installing the connection
+// only if they all succeed.
+func (c *AMQPConnection) connect() (*amqp.Connection, chan *amqp.Error, error) {
+	conn, err := ConnectRabbitMQ(c.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Buffered, since the library blocks on sending the close error.
+	notify := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		conn.Close()
+		return nil, nil, amqp.ErrClosed
+	}
+	for _, setup := range c.setups {
+		if err := setup(conn); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("failed to restore topology: %w", err)
+		}
+	}
+	c.conn = conn
+	return conn, notify, nil
+}
+
+func (c *AMQPConnection) watch(conn *amqp.Connection, notify chan *amqp.Error) {
+	for {
+		// A graceful close sends nothing and closes notify.
+		err := <-notify
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		c.logger.Warn("lost RabbitMQ connection", "error", err)
+
+		conn, notify = c.reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+// reconnect redials until it succeeds or the connection is closed, in which
+// case it returns nil.
+func (c *AMQPConnection) reconnect() (*amqp.Connection, chan *amqp.Error) {
+	delay := amqpReconnectDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return nil, nil
+		case <-time.After(delay):
+		}
+
+		conn, notify, err := c.connect()
+		if err == nil {
+			amqpReconnects.Inc()
+			c.logger.Info("reconnected to RabbitMQ", "attempt", attempt)
+			return conn, notify
+		}
+		delay = min(2*delay, amqpMaxReconnectDelay)
+		c.logger.Warn("failed to reconnect to RabbitMQ", "attempt", attempt, "retry_in", delay, "error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package infra
+
+import (
+	"context"
+
+	"book_boy/api/internal/logging"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("book_boy/api/internal/infra")
+
+// headerCarrier lets the OpenTelemetry propagator read and write trace
+// context in AMQP message headers.
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// messageHeaders carries the request ID and trace context of ctx to whoever
+// consumes the message.
+func messageHeaders(ctx context.Context) amqp.Table {
+	headers := amqp.Table{}
+	if id := logging.RequestID(ctx); id != "" {
+		headers[logging.RequestIDHeader] = id
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// MessageContext returns a context carrying the request ID and trace context
+// from a consumed message's headers, where it has them.
+func MessageContext(ctx context.Context, headers amqp.Table) context.Context {
+	id, _ := headers[logging.RequestIDHeader].(string)
+	ctx = logging.WithRequestID(ctx, id)
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier(headers))
+}
+
+func startPublishSpan(ctx context.Context, exchange, routingKey string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, routingKey+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(exchange),
+			semconv.MessagingRabbitMQDestinationRoutingKey(routingKey),
+		),
+	)
+}
+
+// StartProcessSpan starts the span for handling a message consumed from
+// queue, as a child of the trace the publisher sent with it. Pass the context
+// from MessageContext.
+func StartProcessSpan(ctx context.Context, queue string, msg amqp.Delivery) (context.Context, trace.Span) {
+	return tracer.Start(ctx, msg.RoutingKey+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(msg.Exchange),
+			semconv.MessagingDestinationSubscriptionName(queue),
+			semconv.MessagingRabbitMQDestinationRoutingKey(msg.RoutingKey),
+			semconv.MessagingMessageBodySize(len(msg.Body)),
+		),
+	)
+}
+
+// EndSpan records err, if any, on span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,47 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"book_boy/api/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMessageHeaders_RoundTripRequestIDAndTrace(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = logging.WithRequestID(ctx, "req-123")
+
+	headers := messageHeaders(ctx)
+	if headers["traceparent"] == nil {
+		t.Fatalf("expected a traceparent header, got %v", headers)
+	}
+
+	consumed := MessageContext(context.Background(), headers)
+	if id := logging.RequestID(consumed); id != "req-123" {
+		t.Errorf("expected request ID req-123, got %q", id)
+	}
+	span := trace.SpanContextFromContext(consumed)
+	if span.TraceID() != traceID || span.SpanID() != spanID {
+		t.Errorf("expected the publisher's span as parent, got %s/%s", span.TraceID(), span.SpanID())
+	}
+}
+
+func TestMessageHeaders_EmptyOutsideARequest(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if headers := messageHeaders(context.Background()); headers != nil {
+		t.Errorf("expected no headers, got %v", headers)
+	}
+}
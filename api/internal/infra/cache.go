@@ -0,0 +1,121 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrCacheMiss is returned by Get when the key isn't cached or has expired.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache stores JSON-encoded values by key. Get decodes into dest, so callers
+// always receive their own copy.
+type Cache interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Ping checks that a remote backend is reachable.
+	Ping(ctx context.Context) error
+	// Close releases the backend's connections. The cache isn't used after.
+	Close() error
+}
+
+// Cache backends selectable with CACHE_BACKEND.
+const (
+	CacheBackendRedis  = "redis"
+	CacheBackendMemory = "memory"
+	CacheBackendTiered = "tiered"
+	CacheBackendNone   = "none"
+)
+
+// CacheConfig picks and sizes the cache backend.
+type CacheConfig struct {
+	Backend   string
+	RedisAddr string
+	// MemorySize caps the in-process LRU's entries.
+	MemorySize int
+	// L1TTL caps how long the tiered backend keeps a value in memory, which
+	// bounds how stale one instance can be after another invalidates a key.
+	L1TTL time.Duration
+}
+
+// NewCacheFromConfig builds the configured backend. The none backend returns
+// a nil Cache, which services treat as caching disabled.
+func NewCacheFromConfig(cfg CacheConfig) (Cache, error) {
+	switch cfg.Backend {
+	case "", CacheBackendRedis:
+		return NewRedisCache(cfg.RedisAddr), nil
+	case CacheBackendMemory:
+		return NewMemoryCache(cfg.MemorySize), nil
+	case CacheBackendTiered:
+		return NewTieredCache(NewMemoryCache(cfg.MemorySize), NewRedisCache(cfg.RedisAddr), cfg.L1TTL), nil
+	case CacheBackendNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+}
+
+// loads collapses concurrent misses on the same key into a single load.
+var loads singleflight.Group
+
+// GetOrLoad returns the cached value for key, or calls load and caches what
+// it returns for ttl. When many requests miss the same key at once only one
+// of them runs load and the rest share its result, so an expired hot key
+// doesn't stampede the database. Errors and nil results aren't cached. A nil
+// cache just calls load.
+func GetOrLoad[T any](ctx context.Context, cache Cache, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	if cache == nil {
+		return load(ctx)
+	}
+
+	var cached T
+	if err := cache.Get(ctx, key, &cached); err == nil {
+		return cached, nil
+	}
+
+	results := loads.DoChan(key, func() (interface{}, error) {
+		// The shared load outlives the first caller's cancellation but keeps
+		// its deadline, so one client hanging up doesn't fail everyone
+		// waiting on the same key.
+		loadCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			loadCtx, cancel = context.WithDeadline(loadCtx, deadline)
+			defer cancel()
+		}
+
+		value, err := load(loadCtx)
+		if err != nil {
+			return value, err
+		}
+		if !isNil(value) {
+			cache.Set(loadCtx, key, value, ttl)
+		}
+		return value, nil
+	})
+
+	select {
+	case res := <-results:
+		value, _ := res.Val.(T)
+		return value, res.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -6,30 +6,46 @@ import (
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-type Cache struct {
+// RedisCache is the shared cache every API instance sees.
+type RedisCache struct {
 	client *redis.Client
 }
 
-func NewCache(addr string) *Cache {
-	return &Cache{
-		client: redis.NewClient(&redis.Options{
-			Addr: addr,
-		}),
-	}
+func NewRedisCache(addr string) *RedisCache {
+	client := redis.NewClient(&redis.Options{
+		Addr: addr,
+	})
+	// Commands are traced under the caller's span, without their arguments,
+	// which hold the cached values. This only fails for client types other
+	// than *redis.Client.
+	_ = redisotel.InstrumentTracing(client, redisotel.WithDBStatement(false))
+	return &RedisCache{client: client}
+}
+
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
 }
 
-func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
-	val, err := c.client.Get(ctx, key).Result()
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string, dest interface{}) error {
+	val, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return ErrCacheMiss
+	}
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal([]byte(val), dest)
+	return json.Unmarshal(val, dest)
 }
 
-func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	bytes, err := json.Marshal(value)
 	if err != nil {
 		return err
@@ -37,7 +53,7 @@ func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time
 	return c.client.Set(ctx, key, bytes, ttl).Err()
 }
 
-func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
 	return c.client.Del(ctx, keys...).Err()
 }
 
@@ -0,0 +1,74 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CacheCounts are the lookups made against one kind of key.
+type CacheCounts struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"`
+}
+
+type cacheCounters struct {
+	hits, misses, errors atomic.Int64
+}
+
+// cacheStats counts lookups per key kind, the part of the key before the
+// first colon ("book", "progress_views", ...).
+var cacheStats sync.Map
+
+// CacheStats snapshots the hit, miss and error counts by key kind.
+func CacheStats() map[string]CacheCounts {
+	stats := make(map[string]CacheCounts)
+	cacheStats.Range(func(kind, value any) bool {
+		c := value.(*cacheCounters)
+		stats[kind.(string)] = CacheCounts{Hits: c.hits.Load(), Misses: c.misses.Load(), Errors: c.errors.Load()}
+		return true
+	})
+	return stats
+}
+
+func countersFor(key string) *cacheCounters {
+	kind, _, _ := strings.Cut(key, ":")
+	if c, ok := cacheStats.Load(kind); ok {
+		return c.(*cacheCounters)
+	}
+	c, _ := cacheStats.LoadOrStore(kind, &cacheCounters{})
+	return c.(*cacheCounters)
+}
+
+// instrumentedCache counts Get outcomes for the backend it wraps.
+type instrumentedCache struct {
+	Cache
+}
+
+// InstrumentCache wraps c so its lookups show up in CacheStats. A nil cache
+// stays nil.
+func InstrumentCache(c Cache) Cache {
+	if c == nil {
+		return nil
+	}
+	return instrumentedCache{c}
+}
+
+func (c instrumentedCache) Get(ctx context.Context, key string, dest interface{}) error {
+	err := c.Cache.Get(ctx, key, dest)
+	counters := countersFor(key)
+	switch {
+	case err == nil:
+		counters.hits.Add(1)
+	case errors.Is(err, ErrCacheMiss):
+		counters.misses.Add(1)
+	default:
+		// An unreachable backend falls through to the database like a miss,
+		// but is worth telling apart.
+		counters.errors.Add(1)
+	}
+	return err
+}
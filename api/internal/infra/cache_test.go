@@ -0,0 +1,205 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type cachedBook struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	c.Set(ctx, "a", cachedBook{ID: 1}, 0)
+	c.Set(ctx, "b", cachedBook{ID: 2}, 0)
+	var got cachedBook
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("expected a to be cached: %v", err)
+	}
+	c.Set(ctx, "c", cachedBook{ID: 3}, 0)
+
+	if err := c.Get(ctx, "b", &got); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected b to be evicted, got %v", err)
+	}
+	if err := c.Get(ctx, "a", &got); err != nil || got.ID != 1 {
+		t.Fatalf("expected a to survive, got %+v, %v", got, err)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestMemoryCache_Expires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewMemoryCache(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", cachedBook{ID: 1}, time.Minute)
+	var got cachedBook
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("expected a hit before expiry: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := c.Get(ctx, "a", &got); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected a miss after expiry, got %v", err)
+	}
+}
+
+func TestMemoryCache_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	book := &cachedBook{ID: 1, Title: "Dune"}
+	c.Set(ctx, "a", book, 0)
+	book.Title = "changed"
+
+	var got cachedBook
+	c.Get(ctx, "a", &got)
+	if got.Title != "Dune" {
+		t.Fatalf("expected the cached value to be unaffected, got %q", got.Title)
+	}
+}
+
+func TestTieredCache_PromotesFromL2(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := NewMemoryCache(10), NewMemoryCache(10)
+	c := NewTieredCache(l1, l2, time.Second)
+
+	l2.Set(ctx, "a", cachedBook{ID: 1}, time.Hour)
+	var got cachedBook
+	if err := c.Get(ctx, "a", &got); err != nil || got.ID != 1 {
+		t.Fatalf("expected an L2 hit, got %+v, %v", got, err)
+	}
+	if err := l1.Get(ctx, "a", &got); err != nil {
+		t.Fatalf("expected the value promoted to L1: %v", err)
+	}
+
+	c.Delete(ctx, "a")
+	if err := c.Get(ctx, "a", &got); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected delete to reach both tiers, got %v", err)
+	}
+}
+
+func TestGetOrLoad_CollapsesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	load := func(ctx context.Context) (*cachedBook, error) {
+		calls.Add(1)
+		<-release
+		return &cachedBook{ID: 7}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*cachedBook, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			book, err := GetOrLoad(ctx, c, "stampede", time.Minute, load)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = book
+		}(i)
+	}
+	// Give the goroutines time to pile up on the in-flight load.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected one load, got %d", calls.Load())
+	}
+	for _, book := range results {
+		if book == nil || book.ID != 7 {
+			t.Fatalf("expected every caller to get the loaded book, got %+v", book)
+		}
+	}
+
+	if _, err := GetOrLoad(ctx, c, "stampede", time.Minute, load); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected the next read to hit the cache, got %d loads", calls.Load())
+	}
+}
+
+func TestGetOrLoad_DoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+	boom := errors.New("boom")
+
+	if _, err := GetOrLoad(ctx, c, "k", time.Minute, func(context.Context) (*cachedBook, error) {
+		return nil, boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("expected the load error, got %v", err)
+	}
+	var got cachedBook
+	if err := c.Get(ctx, "k", &got); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected nothing cached, got %v", err)
+	}
+}
+
+func TestInstrumentCache_CountsByKeyKind(t *testing.T) {
+	cache := InstrumentCache(NewMemoryCache(10))
+	ctx := context.Background()
+	before := CacheStats()["stats_test"]
+
+	var value string
+	cache.Get(ctx, "stats_test:1", &value)
+	cache.Set(ctx, "stats_test:1", "cached", time.Minute)
+	cache.Get(ctx, "stats_test:1", &value)
+	cache.Get(ctx, "stats_test:2", &value)
+
+	after := CacheStats()["stats_test"]
+	if hits := after.Hits - before.Hits; hits != 1 {
+		t.Errorf("expected 1 hit, got %d", hits)
+	}
+	if misses := after.Misses - before.Misses; misses != 2 {
+		t.Errorf("expected 2 misses, got %d", misses)
+	}
+	if InstrumentCache(nil) != nil {
+		t.Error("expected a nil cache to stay nil")
+	}
+}
+
+func TestCacheCollector_ReportsCacheStats(t *testing.T) {
+	cache := InstrumentCache(NewMemoryCache(10))
+	var value string
+	cache.Get(context.Background(), "collector_test:1", &value)
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(cacheCollector{})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	misses := -1.0
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			labels := map[string]string{}
+			for _, label := range metric.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["kind"] == "collector_test" && labels["result"] == "miss" {
+				misses = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	if misses != 1 {
+		t.Fatalf("expected 1 miss reported, got %v", misses)
+	}
+}
@@ -1,25 +1,80 @@
 package infra
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+
+	"book_boy/api/internal/logging"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// publishBufferSize caps the events held while RabbitMQ is unreachable.
+const publishBufferSize = 1000
+
+var (
+	ErrPublisherClosed   = errors.New("event publisher closed")
+	ErrPublishBufferFull = errors.New("event publish buffer full")
+)
+
 type EventPublisher struct {
-	channel  *amqp.Channel
 	exchange string
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	// pending holds events, oldest first, that couldn't be sent yet.
+	pending []pendingEvent
+	closed  bool
+}
+
+type pendingEvent struct {
+	routingKey string
+	msg        amqp.Publishing
 }
 
-func NewEventPublisher(conn *amqp.Connection, exchange string) (*EventPublisher, error) {
+func NewEventPublisher(conn *AMQPConnection, exchange string, logger *slog.Logger) (*EventPublisher, error) {
+	p := &EventPublisher{
+		exchange: exchange,
+		logger:   logging.OrDiscard(logger),
+	}
+	if err := conn.Register(p.setup); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// setup opens a channel on a new connection and sends what was buffered
+// while there was none.
+func (p *EventPublisher) setup(conn *amqp.Connection) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	if err := p.open(conn); err != nil {
+		return err
+	}
+	if err := p.flush(); err != nil {
+		p.logger.Warn("failed to send buffered events", "buffered", len(p.pending), "error", err)
+	}
+	amqpPublishBuffered.Set(float64(len(p.pending)))
+	return nil
+}
+
+func (p *EventPublisher) open(conn *amqp.Connection) error {
 	ch, err := conn.Channel()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	err = ch.ExchangeDeclare(
-		exchange,
+		p.exchange,
 		"topic",
 		true,
 		false,
@@ -28,42 +83,112 @@ func NewEventPublisher(conn *amqp.Connection, exchange string) (*EventPublisher,
 		nil,
 	)
 	if err != nil {
-		return nil, err
+		ch.Close()
+		return err
 	}
 
-	return &EventPublisher{
-		channel:  ch,
-		exchange: exchange,
-	}, nil
+	p.conn, p.channel = conn, ch
+	return nil
 }
 
-func (p *EventPublisher) Publish(routingKey string, event interface{}) error {
+// Publish sends event with the request ID and trace context of ctx in its
+// headers, so consumers log and trace under the same request. While RabbitMQ
+// is unreachable the event is buffered and sent, in order, once the
+// connection is back; Publish only fails when the buffer is full or the
+// publisher is closed.
+func (p *EventPublisher) Publish(ctx context.Context, routingKey string, event interface{}) (err error) {
+	ctx, span := startPublishSpan(ctx, p.exchange, routingKey)
+	defer func() { EndSpan(span, err) }()
+
 	body, err := json.Marshal(event)
 	if err != nil {
+		amqpPublishFailures.WithLabelValues(routingKey).Inc()
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	err = p.channel.Publish(
-		p.exchange,
-		routingKey,
-		false,
-		false,
-		amqp.Publishing{
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		err = ErrPublisherClosed
+	case len(p.pending) >= publishBufferSize:
+		err = ErrPublishBufferFull
+	}
+	if err != nil {
+		amqpPublishFailures.WithLabelValues(routingKey).Inc()
+		return err
+	}
+
+	p.pending = append(p.pending, pendingEvent{
+		routingKey: routingKey,
+		msg: amqp.Publishing{
 			ContentType:  "application/json",
 			Body:         body,
 			DeliveryMode: amqp.Persistent,
+			Headers:      messageHeaders(ctx),
 		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to publish event: %w", err)
+	})
+	if flushErr := p.flush(); flushErr != nil {
+		amqpPublishBuffered.Set(float64(len(p.pending)))
+		p.logger.WarnContext(ctx, "RabbitMQ unavailable, buffered event", "routing_key", routingKey, "buffered", len(p.pending), "error", flushErr)
+		return nil
 	}
+	amqpPublishBuffered.Set(0)
 
-	fmt.Printf("Published event: %s with payload: %s\n", routingKey, string(body))
+	p.logger.DebugContext(ctx, "published event", "routing_key", routingKey, "payload", string(body))
 	return nil
 }
 
+// flush sends the pending events in order. It stops at the first failure,
+// leaving that event and the ones after it pending.
+func (p *EventPublisher) flush() error {
+	for len(p.pending) > 0 {
+		event := p.pending[0]
+		if err := p.send(event); err != nil {
+			return err
+		}
+		p.pending[0] = pendingEvent{}
+		p.pending = p.pending[1:]
+		amqpPublished.WithLabelValues(event.routingKey).Inc()
+	}
+	return nil
+}
+
+func (p *EventPublisher) send(event pendingEvent) error {
+	if p.channel == nil || p.channel.IsClosed() {
+		if p.conn == nil || p.conn.IsClosed() {
+			return amqp.ErrClosed
+		}
+		// Only the channel died, so reopen it rather than wait for a
+		// reconnect that won't come.
+		if err := p.open(p.conn); err != nil {
+			return err
+		}
+	}
+	return p.channel.Publish(p.exchange, event.routingKey, false, false, event.msg)
+}
+
+// Close makes a last attempt to send buffered events, then closes the
+// channel. Events that still couldn't be sent are dropped and logged.
 func (p *EventPublisher) Close() error {
-	if p.channel != nil {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	if err := p.flush(); err != nil {
+		p.logger.Error("dropping buffered events", "count", len(p.pending), "error", err)
+		for _, event := range p.pending {
+			amqpPublishFailures.WithLabelValues(event.routingKey).Inc()
+		}
+	}
+	p.pending = nil
+	amqpPublishBuffered.Set(0)
+
+	if p.channel != nil && !p.channel.IsClosed() {
 		return p.channel.Close()
 	}
 	return nil
@@ -0,0 +1,67 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"book_boy/api/internal/logging"
+)
+
+// A publisher with no connection behaves as one whose broker is down.
+func disconnectedPublisher() *EventPublisher {
+	return &EventPublisher{exchange: "book_events", logger: logging.Discard}
+}
+
+func TestEventPublisher_BuffersWhileDisconnected(t *testing.T) {
+	p := disconnectedPublisher()
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+
+	if err := p.Publish(ctx, "book.created", map[string]int{"book_id": 1}); err != nil {
+		t.Fatalf("expected the event to be buffered, got %v", err)
+	}
+	if err := p.Publish(ctx, "book.created", map[string]int{"book_id": 2}); err != nil {
+		t.Fatalf("expected the event to be buffered, got %v", err)
+	}
+
+	if len(p.pending) != 2 {
+		t.Fatalf("expected 2 buffered events, got %d", len(p.pending))
+	}
+	if string(p.pending[0].msg.Body) != `{"book_id":1}` {
+		t.Errorf("expected events in publish order, got %s first", p.pending[0].msg.Body)
+	}
+	if p.pending[0].msg.Headers[logging.RequestIDHeader] != "req-123" {
+		t.Errorf("expected the request ID to be kept, got %v", p.pending[0].msg.Headers)
+	}
+}
+
+func TestEventPublisher_FailsWhenBufferFull(t *testing.T) {
+	p := disconnectedPublisher()
+	ctx := context.Background()
+	for i := 0; i < publishBufferSize; i++ {
+		if err := p.Publish(ctx, "book.created", i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := p.Publish(ctx, "book.created", publishBufferSize); err != ErrPublishBufferFull {
+		t.Errorf("expected ErrPublishBufferFull, got %v", err)
+	}
+}
+
+func TestEventPublisher_FailsWhenClosed(t *testing.T) {
+	p := disconnectedPublisher()
+	ctx := context.Background()
+	if err := p.Publish(ctx, "book.created", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.pending) != 0 {
+		t.Errorf("expected buffered events to be dropped, got %d", len(p.pending))
+	}
+	if err := p.Publish(ctx, "book.created", 2); err != ErrPublisherClosed {
+		t.Errorf("expected ErrPublisherClosed, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package infra
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const defaultMemoryCacheSize = 10000
+
+// MemoryCache is an in-process LRU with per-entry expiry. Values are stored
+// JSON-encoded like in Redis, so the backends are interchangeable and callers
+// can't mutate what's cached.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache holds up to capacity entries, evicting the least recently
+// used beyond that. A capacity of zero or less uses the default.
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = defaultMemoryCacheSize
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return ErrCacheMiss
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		c.mu.Unlock()
+		return ErrCacheMiss
+	}
+	c.order.MoveToFront(elem)
+	value := entry.value
+	c.mu.Unlock()
+
+	return json.Unmarshal(value, dest)
+}
+
+// Set stores value for ttl; a ttl of zero keeps it until it's evicted.
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = bytes
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: bytes, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Ping always succeeds; there's nothing to reach.
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close is a no-op; the entries go with the process.
+func (c *MemoryCache) Close() error {
+	return nil
+}
+
+// Len reports how many entries are held, including expired ones not yet
+// evicted.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *MemoryCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,69 @@
+package infra
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered with the default Prometheus registry, which
+// promhttp.Handler serves at /metrics.
+var (
+	amqpPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_published_total",
+		Help: "Events published to RabbitMQ, by routing key.",
+	}, []string{"routing_key"})
+	amqpPublishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_publish_failures_total",
+		Help: "Events that failed to publish to RabbitMQ, by routing key.",
+	}, []string{"routing_key"})
+	amqpPublishBuffered = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "amqp_publish_buffered",
+		Help: "Events held for publishing until RabbitMQ is reachable again.",
+	})
+	amqpReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "amqp_reconnects_total",
+		Help: "Times the RabbitMQ connection was lost and re-established.",
+	})
+
+	// AMQPConsumed and AMQPConsumeFailures are counted by the consumers in
+	// workers, per queue.
+	AMQPConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_consumed_total",
+		Help: "Messages consumed from RabbitMQ and acknowledged, by queue.",
+	}, []string{"queue"})
+	AMQPConsumeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_consume_failures_total",
+		Help: "Messages whose handling failed and were requeued, by queue.",
+	}, []string{"queue"})
+
+	sseClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "sse_clients",
+		Help: "Connected server-sent event streams.",
+	})
+)
+
+// cacheCollector reports CacheStats as counters, so the hit ratio of each key
+// kind is rate(cache_requests_total{result="hit"}) over the sum of results.
+type cacheCollector struct{}
+
+var cacheRequestsDesc = prometheus.NewDesc(
+	"cache_requests_total",
+	"Cache lookups by key kind and result (hit, miss or error).",
+	[]string{"kind", "result"}, nil,
+)
+
+func (cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheRequestsDesc
+}
+
+func (cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for kind, counts := range CacheStats() {
+		ch <- prometheus.MustNewConstMetric(cacheRequestsDesc, prometheus.CounterValue, float64(counts.Hits), kind, "hit")
+		ch <- prometheus.MustNewConstMetric(cacheRequestsDesc, prometheus.CounterValue, float64(counts.Misses), kind, "miss")
+		ch <- prometheus.MustNewConstMetric(cacheRequestsDesc, prometheus.CounterValue, float64(counts.Errors), kind, "error")
+	}
+}
+
+func init() {
+	prometheus.MustRegister(cacheCollector{})
+}
@@ -4,37 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
 	"sync"
 	"time"
 
+	"book_boy/api/internal/logging"
+
 	"github.com/gin-gonic/gin"
 )
 
 type SSEClient struct {
+	UserID  int
 	Channel chan []byte
 }
 
 type SSEManager struct {
 	clients map[*SSEClient]bool
 	mu      sync.RWMutex
+	logger  *slog.Logger
+	// closed is closed by Close to end every stream.
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
-func NewSSEManager() *SSEManager {
+func NewSSEManager(logger *slog.Logger) *SSEManager {
 	return &SSEManager{
 		clients: make(map[*SSEClient]bool),
+		logger:  logging.OrDiscard(logger),
+		closed:  make(chan struct{}),
 	}
 }
 
+// Close ends every open stream with a close event, telling clients to
+// reconnect (to another instance) rather than treating it as an error. New
+// streams are refused from then on. Call it before shutting the HTTP server
+// down, which otherwise waits on the streams forever.
+func (m *SSEManager) Close() {
+	m.closeOnce.Do(func() { close(m.closed) })
+}
+
 func (m *SSEManager) AddClient(client *SSEClient) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.clients[client] = true
+	sseClients.Inc()
 }
 
 func (m *SSEManager) RemoveClient(client *SSEClient) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.clients, client)
+	sseClients.Dec()
 	close(client.Channel)
 }
 
@@ -44,31 +65,69 @@ func (m *SSEManager) Broadcast(eventType string, data interface{}) {
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		fmt.Printf("Failed to marshal SSE data: %v\n", err)
+		m.logger.Error("failed to marshal SSE event", "event", eventType, "error", err)
 		return
 	}
 
 	message := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, jsonData))
 
-	fmt.Printf("Broadcasting SSE event '%s' to %d clients: %s\n", eventType, len(m.clients), string(jsonData))
+	dropped := 0
+	for client := range m.clients {
+		select {
+		case client.Channel <- message:
+		default:
+			dropped++
+		}
+	}
+	m.logger.Debug("broadcast SSE event", "event", eventType, "clients", len(m.clients), "dropped", dropped)
+	if dropped > 0 {
+		m.logger.Warn("dropped SSE event for slow clients", "event", eventType, "dropped", dropped)
+	}
+}
+
+// SendToUser delivers an event only to the streams opened by userID.
+func (m *SSEManager) SendToUser(userID int, eventType string, data interface{}) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		m.logger.Error("failed to marshal SSE event", "event", eventType, "error", err)
+		return
+	}
+
+	message := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, jsonData))
 
 	for client := range m.clients {
+		if client.UserID != userID {
+			continue
+		}
 		select {
 		case client.Channel <- message:
-			fmt.Printf("Sent to client\n")
 		default:
-			fmt.Printf("Failed to send to client (channel full)\n")
+			m.logger.Warn("dropped SSE event for slow client", "event", eventType, "user_id", userID)
 		}
 	}
 }
 
 func (m *SSEManager) ServeHTTP(c *gin.Context) {
+	select {
+	case <-m.closed:
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
+	// Send the headers now rather than with the first event or keepalive,
+	// so clients know the stream is open.
+	c.Writer.Flush()
 
 	client := &SSEClient{
+		UserID:  c.GetInt("user_id"),
 		Channel: make(chan []byte, 10),
 	}
 
@@ -89,6 +148,9 @@ func (m *SSEManager) ServeHTTP(c *gin.Context) {
 		case <-ticker.C:
 			w.Write([]byte(":keepalive\n\n"))
 			return true
+		case <-m.closed:
+			w.Write([]byte("event: close\ndata: {\"reason\":\"shutdown\"}\n\n"))
+			return false
 		}
 	})
 }
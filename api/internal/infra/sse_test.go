@@ -0,0 +1,52 @@
+package infra
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSSEManager_CloseEndsStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewSSEManager(nil)
+	r := gin.New()
+	r.GET("/events", m.ServeHTTP)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	m.Close()
+
+	body := make(chan string, 1)
+	go func() {
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	select {
+	case b := <-body:
+		if !strings.Contains(b, "event: close") {
+			t.Errorf("expected a close event, got %q", b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Close to end the stream")
+	}
+
+	resp, err = http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected new streams to be refused, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const defaultL1TTL = 30 * time.Second
+
+// TieredCache serves hot keys from process memory (L1) and falls back to a
+// shared cache (L2). Deletes only reach this instance's L1, so other
+// instances may serve a stale value for up to the L1 TTL.
+type TieredCache struct {
+	l1    Cache
+	l2    Cache
+	l1TTL time.Duration
+}
+
+// NewTieredCache keeps values in l1 for at most l1TTL, or the default when
+// l1TTL is zero or less.
+func NewTieredCache(l1, l2 Cache, l1TTL time.Duration) *TieredCache {
+	if l1TTL <= 0 {
+		l1TTL = defaultL1TTL
+	}
+	return &TieredCache{l1: l1, l2: l2, l1TTL: l1TTL}
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string, dest interface{}) error {
+	if err := c.l1.Get(ctx, key, dest); err == nil {
+		return nil
+	}
+	if err := c.l2.Get(ctx, key, dest); err != nil {
+		return err
+	}
+	// dest now holds the decoded value; promote it so the next read stays
+	// in process.
+	c.l1.Set(ctx, key, dest, c.l1TTL)
+	return nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	l1TTL := c.l1TTL
+	if ttl > 0 && ttl < l1TTL {
+		l1TTL = ttl
+	}
+	if err := c.l1.Set(ctx, key, value, l1TTL); err != nil {
+		return err
+	}
+	return c.l2.Set(ctx, key, value, ttl)
+}
+
+func (c *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	c.l1.Delete(ctx, keys...)
+	return c.l2.Delete(ctx, keys...)
+}
+
+// Ping checks the shared tier; the in-process one is always there.
+func (c *TieredCache) Ping(ctx context.Context) error {
+	return c.l2.Ping(ctx)
+}
+
+func (c *TieredCache) Close() error {
+	return errors.Join(c.l1.Close(), c.l2.Close())
+}
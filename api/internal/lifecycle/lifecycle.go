@@ -0,0 +1,85 @@
+// Package lifecycle starts the application's components in order and stops
+// them in reverse, so each one drains while what it depends on is still up.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"book_boy/api/internal/logging"
+)
+
+// Component is one piece of the application with a start and a stop. Either
+// may be nil. Stop should return once the component has drained or ctx is
+// done, whichever comes first.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Lifecycle runs components in the order they were appended.
+type Lifecycle struct {
+	components []Component
+	started    int
+	ready      atomic.Bool
+	logger     *slog.Logger
+}
+
+func New(logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{logger: logging.OrDiscard(logger)}
+}
+
+// Append adds a component to be started after those already appended and
+// stopped before them.
+func (l *Lifecycle) Append(c Component) {
+	l.components = append(l.components, c)
+}
+
+// Ready reports whether every component has started and shutdown hasn't
+// begun.
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// Start starts each component in turn. If one fails, those already started
+// are stopped and its error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for _, c := range l.components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				l.Stop(context.WithoutCancel(ctx))
+				return fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+		}
+		l.started++
+		l.logger.Debug("started component", "component", c.Name)
+	}
+	l.ready.Store(true)
+	return nil
+}
+
+// Stop marks the application not ready, then stops the started components in
+// reverse order. Each stop shares ctx's deadline; a component that fails or
+// overruns is logged and the rest are still stopped.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.ready.Store(false)
+
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		c := l.components[l.started-1]
+		if c.Stop == nil {
+			continue
+		}
+		if err := c.Stop(ctx); err != nil {
+			l.logger.Error("failed to stop component", "component", c.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+			continue
+		}
+		l.logger.Info("stopped component", "component", c.Name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,92 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func recording(events *[]string, name string, startErr error) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			*events = append(*events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycle_StopsInReverseOrder(t *testing.T) {
+	var events []string
+	app := New(nil)
+	app.Append(recording(&events, "db", nil))
+	app.Append(recording(&events, "consumer", nil))
+	app.Append(recording(&events, "http", nil))
+
+	if app.Ready() {
+		t.Fatal("expected not ready before start")
+	}
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !app.Ready() {
+		t.Fatal("expected ready once started")
+	}
+	if err := app.Stop(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if app.Ready() {
+		t.Fatal("expected not ready once stopping")
+	}
+
+	want := []string{"start db", "start consumer", "start http", "stop http", "stop consumer", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+}
+
+func TestLifecycle_StartFailureStopsWhatStarted(t *testing.T) {
+	var events []string
+	app := New(nil)
+	app.Append(recording(&events, "db", nil))
+	app.Append(recording(&events, "consumer", errors.New("no channel")))
+	app.Append(recording(&events, "http", nil))
+
+	if err := app.Start(context.Background()); err == nil {
+		t.Fatal("expected the consumer's error")
+	}
+	want := []string{"start db", "start consumer", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+	if app.Ready() {
+		t.Error("expected not ready after a failed start")
+	}
+}
+
+func TestLifecycle_StopContinuesPastFailures(t *testing.T) {
+	var events []string
+	app := New(nil)
+	app.Append(recording(&events, "db", nil))
+	app.Append(Component{Name: "consumer", Stop: func(context.Context) error {
+		events = append(events, "stop consumer")
+		return context.DeadlineExceeded
+	}})
+
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := app.Stop(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the consumer's error, got %v", err)
+	}
+	want := []string{"start db", "stop consumer", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+}
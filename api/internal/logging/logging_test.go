@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogger_AddsRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logger.With("component", "test").InfoContext(ctx, "hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record["request_id"] != "req-123" {
+		t.Errorf("expected request_id req-123, got %v", record["request_id"])
+	}
+	if record["component"] != "test" {
+		t.Errorf("expected the logger's attrs to be kept, got %v", record["component"])
+	}
+}
+
+func TestLogger_OmitsMissingRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.InfoContext(context.Background(), "hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := record["request_id"]; ok {
+		t.Errorf("expected no request_id, got %v", record["request_id"])
+	}
+}
+
+func TestNew_RejectsUnknownSettings(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", ""); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+	if _, err := New(&bytes.Buffer{}, "", "loud"); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+}
+
+func TestLogger_AddsTraceFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	logger.InfoContext(ctx, "hello")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record["trace_id"] != traceID.String() || record["span_id"] != spanID.String() {
+		t.Errorf("expected the span's IDs, got trace_id %v span_id %v", record["trace_id"], record["span_id"])
+	}
+}
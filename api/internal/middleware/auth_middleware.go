@@ -1,8 +1,8 @@
 package middleware
 
 import (
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/service"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,21 +12,21 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			c.Error(errors.Unauthorized("authorization header required"))
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+			c.Error(errors.Unauthorized("invalid authorization header format"))
 			c.Abort()
 			return
 		}
 
-		user, err := authService.GetUserFromToken(parts[1])
+		user, err := authService.GetUserFromToken(c.Request.Context(), parts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			c.Error(errors.Unauthorized("invalid or expired token"))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"book_boy/api/internal/errors"
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Problem is an RFC 7807 problem details body. Code is stable across releases
+// and is what clients should branch on; Detail is for people.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Code     string         `json:"code"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []FieldProblem `json:"errors,omitempty"`
+}
+
+// FieldProblem points a validation failure at the JSON field that caused it.
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type problemKind struct {
+	sentinel error
+	status   int
+	code     string
+}
+
+var problemKinds = []problemKind{
+	{errors.ErrNotFound, http.StatusNotFound, "not_found"},
+	{errors.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{errors.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{errors.ErrConflict, http.StatusConflict, "conflict"},
+	{errors.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+}
+
+// Errors renders the last error a handler recorded with c.Error as
+// application/problem+json. Handlers that already wrote a response are left
+// alone. Unexpected errors are logged, since their detail is withheld.
+func Errors(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		problem := ProblemFor(err)
+		if problem.Status == http.StatusInternalServerError {
+			logger.ErrorContext(c.Request.Context(), "unhandled error",
+				"method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+		}
+		problem.Instance = c.Request.URL.Path
+
+		c.Header("Content-Type", "application/problem+json")
+		c.AbortWithStatusJSON(problem.Status, problem)
+	}
+}
+
+// ProblemFor maps err onto a problem. Errors outside the taxonomy become a 500
+// whose detail doesn't leak internals.
+func ProblemFor(err error) Problem {
+	var validation errors.ValidationError
+	if errors.As(err, &validation) {
+		problem := newProblem(http.StatusBadRequest, "validation_failed", validation.Error())
+		if validation.Field != "" {
+			problem.Errors = []FieldProblem{{Field: validation.Field, Message: validation.Message}}
+		}
+		return problem
+	}
+	var validations errors.ValidationErrors
+	if errors.As(err, &validations) {
+		problem := newProblem(http.StatusBadRequest, "validation_failed", validations.Error())
+		for _, v := range validations {
+			problem.Errors = append(problem.Errors, FieldProblem{Field: v.Field, Message: v.Message})
+		}
+		return problem
+	}
+
+	for _, kind := range problemKinds {
+		if !errors.Is(err, kind.sentinel) {
+			continue
+		}
+		code := kind.code
+		var typed *errors.Error
+		if errors.As(err, &typed) && typed.Code != "" {
+			code = typed.Code
+		}
+		return newProblem(kind.status, code, err.Error())
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return newProblem(http.StatusGatewayTimeout, "timeout", "the request took too long to complete")
+	}
+	return newProblem(http.StatusInternalServerError, "internal_error", "an unexpected error occurred")
+}
+
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+		fields []FieldProblem
+	}{
+		{
+			name:   "not found sentinel",
+			err:    errors.ErrNotFound,
+			status: http.StatusNotFound,
+			code:   "not_found",
+			detail: "resource not found",
+		},
+		{
+			name:   "not found with message",
+			err:    errors.NotFound("book not found"),
+			status: http.StatusNotFound,
+			code:   "not_found",
+			detail: "book not found",
+		},
+		{
+			name:   "unauthorized",
+			err:    errors.Unauthorized("user not authenticated"),
+			status: http.StatusUnauthorized,
+			code:   "unauthorized",
+			detail: "user not authenticated",
+		},
+		{
+			name:   "forbidden",
+			err:    errors.Forbidden("this profile is not visible to you"),
+			status: http.StatusForbidden,
+			code:   "forbidden",
+			detail: "this profile is not visible to you",
+		},
+		{
+			name:   "conflict",
+			err:    errors.Conflict("a record with these values already exists"),
+			status: http.StatusConflict,
+			code:   "conflict",
+			detail: "a record with these values already exists",
+		},
+		{
+			name:   "precondition failed",
+			err:    errors.PreconditionFailed("progress has changed"),
+			status: http.StatusPreconditionFailed,
+			code:   "precondition_failed",
+			detail: "progress has changed",
+		},
+		{
+			name:   "kind with its own code",
+			err:    &errors.Error{Kind: errors.ErrForbidden, Code: "checkpoint_locked", Message: "read up to this checkpoint"},
+			status: http.StatusForbidden,
+			code:   "checkpoint_locked",
+			detail: "read up to this checkpoint",
+		},
+		{
+			name:   "validation without a field",
+			err:    errors.ErrInvalidInput("invalid cursor"),
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			detail: "invalid cursor",
+		},
+		{
+			name:   "validation on a field",
+			err:    errors.ErrInvalidField("title", "cannot be empty"),
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			detail: "title cannot be empty",
+			fields: []FieldProblem{{Field: "title", Message: "cannot be empty"}},
+		},
+		{
+			name: "several fields",
+			err: errors.ValidationErrors{
+				{Field: "name", Message: "is required"},
+				{Field: "target", Message: "must be at least 1"},
+			},
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			detail: "name is required; target must be at least 1",
+			fields: []FieldProblem{{Field: "name", Message: "is required"}, {Field: "target", Message: "must be at least 1"}},
+		},
+		{
+			name:   "wrapped kind",
+			err:    fmt.Errorf("loading shelf: %w", errors.NotFound("shelf not found")),
+			status: http.StatusNotFound,
+			code:   "not_found",
+			detail: "loading shelf: shelf not found",
+		},
+		{
+			name:   "wrapped kind keeps its code",
+			err:    fmt.Errorf("posting: %w", &errors.Error{Kind: errors.ErrForbidden, Code: "checkpoint_locked", Message: "locked"}),
+			status: http.StatusForbidden,
+			code:   "checkpoint_locked",
+			detail: "posting: locked",
+		},
+		{
+			name:   "wrapped validation",
+			err:    fmt.Errorf("saving goal: %w", errors.ErrInvalidField("target", "must be positive")),
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			detail: "target must be positive",
+			fields: []FieldProblem{{Field: "target", Message: "must be positive"}},
+		},
+		{
+			name:   "deadline exceeded",
+			err:    fmt.Errorf("query: %w", context.DeadlineExceeded),
+			status: http.StatusGatewayTimeout,
+			code:   "timeout",
+			detail: "the request took too long to complete",
+		},
+		{
+			name:   "unexpected error hides its detail",
+			err:    fmt.Errorf("pq: connection refused"),
+			status: http.StatusInternalServerError,
+			code:   "internal_error",
+			detail: "an unexpected error occurred",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProblemFor(tt.err)
+			if got.Status != tt.status || got.Code != tt.code || got.Detail != tt.detail {
+				t.Errorf("ProblemFor() = %d %s %q, want %d %s %q", got.Status, got.Code, got.Detail, tt.status, tt.code, tt.detail)
+			}
+			if got.Title != http.StatusText(tt.status) || got.Type != "about:blank" {
+				t.Errorf("unexpected title or type: %+v", got)
+			}
+			if !reflect.DeepEqual(got.Errors, tt.fields) {
+				t.Errorf("Errors = %+v, want %+v", got.Errors, tt.fields)
+			}
+		})
+	}
+}
+
+func serveErrors(t *testing.T, handler gin.HandlerFunc) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Errors(logging.Discard))
+	r.GET("/shelves/:id", handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shelves/7", nil))
+
+	var problem Problem
+	if w.Header().Get("Content-Type") == "application/problem+json" {
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return w, problem
+}
+
+func TestErrors_RendersLastError(t *testing.T) {
+	w, problem := serveErrors(t, func(c *gin.Context) {
+		c.Error(fmt.Errorf("first"))
+		c.Error(errors.NotFound("shelf not found"))
+	})
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected problem+json, got %q", w.Header().Get("Content-Type"))
+	}
+	if problem.Code != "not_found" || problem.Detail != "shelf not found" || problem.Instance != "/shelves/7" {
+		t.Fatalf("unexpected problem: %+v", problem)
+	}
+}
+
+func TestErrors_LeavesWrittenResponsesAlone(t *testing.T) {
+	w, _ := serveErrors(t, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "ok"})
+		c.Error(fmt.Errorf("logged after the response went out"))
+	})
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"data":"ok"}` {
+		t.Fatalf("expected the handler's response untouched, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"book_boy/api/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedRoutes are probes and long-lived streams, which would only add noise.
+var untracedRoutes = map[string]bool{
+	"/health":       true,
+	"/livez":        true,
+	"/readyz":       true,
+	"/metrics":      true,
+	"/openapi.json": true,
+	"/docs":         true,
+	"/events":       true,
+}
+
+// Tracing starts a server span per request, continuing the caller's trace
+// when it sends a traceparent header. Register it before RequestID and
+// RequestLog so their work falls inside the span.
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !untracedRoutes[c.FullPath()]
+	}))
+}
@@ -0,0 +1,276 @@
+// Package openapi builds the API's OpenAPI 3 document from a table of
+// operations, deriving schemas from the Go types handlers bind and return.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents one route. Method and Path match what the controller
+// registers with gin, so the document can be checked against the router.
+type Operation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	// Public routes don't need a bearer token.
+	Public bool
+	Query  []Param
+	// Request is a value of the JSON body's type, or nil when there is none.
+	Request any
+	// Response is a value of the success payload's type, or nil for an empty
+	// 204. It is wrapped in {"data": ...} unless Raw is set.
+	Response any
+	Raw      bool
+	// Status is the success status; it defaults to 200, or 204 without a
+	// Response.
+	Status int
+}
+
+// Param is a query string parameter.
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Spec is everything needed to build a document.
+type Spec struct {
+	Title       string
+	Version     string
+	Description string
+	Operations  []Operation
+	// Error is a value of the type error responses carry.
+	Error any
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+	Security   []map[string][]string           `json:"security"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem is a single operation under a path and method.
+type PathItem struct {
+	Tags        []string               `json:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	OperationID string                 `json:"operationId"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]Response    `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+const bearerAuth = "bearerAuth"
+
+// Build assembles the document. Operations are keyed by method and path, so a
+// route documented twice keeps the last entry.
+func Build(spec Spec) *Document {
+	schemas := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: spec.Title, Version: spec.Version, Description: spec.Description},
+		// Relative, so the document works behind the web app's /api prefix.
+		Servers:  []Server{{URL: "."}},
+		Paths:    make(map[string]map[string]*PathItem),
+		Security: []map[string][]string{{bearerAuth: {}}},
+	}
+
+	var errorResponse Response
+	if spec.Error != nil {
+		errorResponse = Response{
+			Description: "Problem details",
+			Content:     map[string]MediaType{"application/problem+json": {Schema: schemas.schemaFor(spec.Error)}},
+		}
+	}
+
+	for _, op := range spec.Operations {
+		path, pathParams := openAPIPath(op.Path)
+		item := &PathItem{
+			Summary:     op.Summary,
+			OperationID: operationID(op.Method, op.Path),
+			Responses:   make(map[string]Response),
+		}
+		if op.Tag != "" {
+			item.Tags = []string{op.Tag}
+		}
+		if op.Public {
+			item.Security = &[]map[string][]string{}
+		}
+
+		for _, name := range pathParams {
+			item.Parameters = append(item.Parameters, Parameter{
+				Name: name, In: "path", Required: true, Schema: paramSchema(""),
+			})
+		}
+		for _, q := range op.Query {
+			item.Parameters = append(item.Parameters, Parameter{
+				Name: q.Name, In: "query", Description: q.Description, Required: q.Required, Schema: paramSchema(q.Type),
+			})
+		}
+
+		if op.Request != nil {
+			item.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: schemas.schemaFor(op.Request)}},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+			if op.Response == nil {
+				status = http.StatusNoContent
+			}
+		}
+		success := Response{Description: http.StatusText(status)}
+		if op.Response != nil {
+			schema := schemas.schemaFor(op.Response)
+			if !op.Raw {
+				schema = &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"data": schema},
+					Required:   []string{"data"},
+				}
+			}
+			success.Content = map[string]MediaType{"application/json": {Schema: schema}}
+		}
+		item.Responses[fmt.Sprint(status)] = success
+		if spec.Error != nil {
+			item.Responses["default"] = errorResponse
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*PathItem)
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = item
+	}
+
+	doc.Components = Components{
+		Schemas: schemas.components,
+		SecuritySchemes: map[string]SecurityScheme{
+			bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		},
+	}
+	return doc
+}
+
+// Drift compares the documented operations against the routes a router
+// serves. It returns routes nobody documented and documentation for routes
+// that no longer exist, both as sorted "METHOD /path" strings.
+func Drift(routes gin.RoutesInfo, ops []Operation) (undocumented, stale []string) {
+	served := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		served[route.Method+" "+route.Path] = true
+	}
+	documented := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		key := op.Method + " " + op.Path
+		documented[key] = true
+		if !served[key] {
+			stale = append(stale, key)
+		}
+	}
+	for key := range served {
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+	return undocumented, stale
+}
+
+// openAPIPath turns gin's /books/:id into /books/{id} and lists the params.
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a stable id such as getBooksById from the route.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			b.WriteString("By")
+			segment = segment[1:]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '_' || r == '-' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// paramSchema types a parameter. Path params are all ids or versions, so
+// integer is the default.
+func paramSchema(typ string) *Schema {
+	if typ == "" {
+		typ = "integer"
+	}
+	return &Schema{Type: typ}
+}
@@ -0,0 +1,246 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object the API's types need.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaRegistry turns Go types into schemas, putting named structs under
+// components/schemas and referring to them by $ref.
+type schemaRegistry struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+func (r *schemaRegistry) schemaFor(v any) *Schema {
+	return r.schema(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	s := r.bareSchema(t)
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+func (r *schemaRegistry) bareSchema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() == reflect.Struct && (reflect.PointerTo(t).Implements(jsonMarshalerType) || t.Implements(jsonMarshalerType)):
+		// Structs with their own JSON form, like CustomDuration's "HH:MM:SS",
+		// serialise as strings.
+		return &Schema{Type: "string"}
+	case t.Kind() != reflect.String && reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		format := ""
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			format = "int64"
+		}
+		return &Schema{Type: "integer", Format: format}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		return r.structSchema(t)
+	}
+	return &Schema{}
+}
+
+// structSchema registers named structs as components. Anonymous structs are
+// described inline.
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return r.objectSchema(t)
+	}
+	if name, ok := r.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := r.componentName(t)
+	r.names[t] = name
+	// Reserve the name first so self-referencing types terminate.
+	r.components[name] = &Schema{}
+	*r.components[name] = *r.objectSchema(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName is the type's name, capitalised so unexported request types
+// read like the rest, and prefixed with its package when two packages use the
+// same one.
+func (r *schemaRegistry) componentName(t reflect.Type) string {
+	name := capitalise(t.Name())
+	if _, taken := r.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return capitalise(pkg) + name
+}
+
+func capitalise(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func (r *schemaRegistry) objectSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(s, t)
+	return s
+}
+
+func (r *schemaRegistry) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a json name are flattened, as
+		// encoding/json does.
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !reflect.PointerTo(embedded).Implements(jsonMarshalerType) {
+				r.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := r.schema(field.Type)
+		if applyBinding(prop, field.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding carries gin's validator tags over to the schema and reports
+// whether the field is required.
+func applyBinding(s *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				s.Enum = append(s.Enum, option)
+			}
+		case "min", "max":
+			applyBound(s, key == "min", value)
+		}
+	}
+	return required
+}
+
+// applyBound sets the min or max that fits the schema's type: a length for
+// strings, a count for arrays and a value for numbers.
+func applyBound(s *Schema, isMin bool, value string) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || s.Ref != "" {
+		return
+	}
+	count := int(n)
+	switch s.Type {
+	case "string":
+		if isMin {
+			s.MinLength = &count
+		} else {
+			s.MaxLength = &count
+		}
+	case "array":
+		if isMin {
+			s.MinItems = &count
+		} else {
+			s.MaxItems = &count
+		}
+	case "integer", "number":
+		if isMin {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+)
+
+type child struct {
+	Name string `json:"name"`
+}
+
+type parent struct {
+	ID       int               `json:"id"`
+	Title    string            `json:"title" binding:"required,min=1,max=100"`
+	Email    string            `json:"email" binding:"omitempty,email"`
+	Kind     string            `json:"kind" binding:"required,oneof=book audiobook"`
+	Rating   int               `json:"rating" binding:"min=1,max=5"`
+	Tags     []string          `json:"tags" binding:"max=10"`
+	Note     *string           `json:"note"`
+	Secret   string            `json:"-"`
+	When     time.Time         `json:"when"`
+	Children []child           `json:"children"`
+	Extra    map[string]string `json:"extra"`
+	child
+}
+
+func TestSchemaFor_Struct(t *testing.T) {
+	r := newSchemaRegistry()
+
+	ref := r.schemaFor(parent{})
+	if ref.Ref != "#/components/schemas/Parent" {
+		t.Fatalf("expected a $ref to parent, got %+v", ref)
+	}
+	s := r.components["Parent"]
+
+	if len(s.Required) != 2 || s.Required[0] != "title" || s.Required[1] != "kind" {
+		t.Fatalf("expected title and kind required, got %v", s.Required)
+	}
+	if title := s.Properties["title"]; *title.MinLength != 1 || *title.MaxLength != 100 {
+		t.Fatalf("unexpected title bounds: %+v", title)
+	}
+	if s.Properties["email"].Format != "email" {
+		t.Fatal("expected email format")
+	}
+	if kind := s.Properties["kind"]; len(kind.Enum) != 2 || kind.Enum[1] != "audiobook" {
+		t.Fatalf("unexpected kind enum: %v", kind.Enum)
+	}
+	if rating := s.Properties["rating"]; *rating.Minimum != 1 || *rating.Maximum != 5 {
+		t.Fatalf("unexpected rating bounds: %+v", rating)
+	}
+	if tags := s.Properties["tags"]; tags.Type != "array" || *tags.MaxItems != 10 {
+		t.Fatalf("unexpected tags schema: %+v", tags)
+	}
+	if !s.Properties["note"].Nullable {
+		t.Fatal("expected pointer fields to be nullable")
+	}
+	if _, ok := s.Properties["Secret"]; ok {
+		t.Fatal(`expected json:"-" fields to be skipped`)
+	}
+	if when := s.Properties["when"]; when.Type != "string" || when.Format != "date-time" {
+		t.Fatalf("unexpected time schema: %+v", when)
+	}
+	if children := s.Properties["children"]; children.Items.Ref != "#/components/schemas/Child" {
+		t.Fatalf("expected children to reference child, got %+v", children.Items)
+	}
+	if _, ok := s.Properties["name"]; !ok {
+		t.Fatal("expected the embedded struct's fields to be inlined")
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	path, params := openAPIPath("/clubs/:id/checkpoints/:checkpointId/posts")
+	if path != "/clubs/{id}/checkpoints/{checkpointId}/posts" {
+		t.Fatalf("unexpected path %q", path)
+	}
+	if len(params) != 2 || params[0] != "id" || params[1] != "checkpointId" {
+		t.Fatalf("unexpected params %v", params)
+	}
+	if id := operationID("GET", "/books/:id/history"); id != "getBooksByIdHistory" {
+		t.Fatalf("unexpected operation id %q", id)
+	}
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"book_boy/api/internal/domain"
+)
+
+type ActivityRepo interface {
+	Create(ctx context.Context, event *domain.ActivityEvent) error
+	GetByID(ctx context.Context, id int) (*domain.ActivityEvent, error)
+	GetFeed(ctx context.Context, viewerID int, beforeID int, limit int) ([]domain.ActivityEvent, error)
+}
+
+type activityRepo struct {
+	db *sql.DB
+}
+
+func NewActivityRepo(db *sql.DB) ActivityRepo {
+	return &activityRepo{db: db}
+}
+
+const activitySelect = `
+	SELECT e.id, e.user_id, u.username, e.type, e.progress_id, e.book_id, e.audiobook_id,
+		COALESCE(b.title, a.title, ''), e.rating, e.created_at
+	FROM activity_events e
+	JOIN users u ON u.id = e.user_id
+	LEFT JOIN books b ON b.id = e.book_id
+	LEFT JOIN audiobooks a ON a.id = e.audiobook_id
+`
+
+func (r *activityRepo) Create(ctx context.Context, event *domain.ActivityEvent) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO activity_events (user_id, type, progress_id, book_id, audiobook_id, rating)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, event.UserID, event.Type, event.ProgressID, event.BookID, event.AudiobookID, event.Rating).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (r *activityRepo) GetByID(ctx context.Context, id int) (*domain.ActivityEvent, error) {
+	event, err := scanActivity(conn(ctx, r.db).QueryRowContext(ctx, activitySelect+" WHERE e.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetFeed returns events from users the viewer follows, newest first. As with
+// CheckProgressVisible, the author's profile_privacy must let followers in,
+// and then rating events follow their review_privacy and everything else their
+// progress_privacy; public and followers-only levels are both visible here
+// because the viewer is a follower by construction. beforeID of 0 starts at
+// the top.
+func (r *activityRepo) GetFeed(ctx context.Context, viewerID int, beforeID int, limit int) ([]domain.ActivityEvent, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, activitySelect+`
+		JOIN follows f ON f.followee_id = e.user_id AND f.follower_id = $1
+		WHERE ($2 = 0 OR e.id < $2)
+			AND u.profile_privacy <> 'private'
+			AND CASE WHEN e.type = 'rated' THEN u.review_privacy ELSE u.progress_privacy END <> 'private'
+		ORDER BY e.id DESC
+		LIMIT $3
+	`, viewerID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.ActivityEvent
+	for rows.Next() {
+		event, err := scanActivity(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+func scanActivity(row rowScanner) (*domain.ActivityEvent, error) {
+	var event domain.ActivityEvent
+	if err := row.Scan(
+		&event.ID, &event.UserID, &event.Username, &event.Type, &event.ProgressID, &event.BookID,
+		&event.AudiobookID, &event.Title, &event.Rating, &event.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
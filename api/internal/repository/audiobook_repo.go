@@ -1,18 +1,23 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 
 	"book_boy/api/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 type AudiobookRepo interface {
-	GetAll() ([]domain.Audiobook, error)
-	GetByID(id int) (*domain.Audiobook, error)
-	Create(audiobook *domain.Audiobook) (int, error)
-	Update(audiobook *domain.Audiobook) error
-	Delete(id int) error
-	GetSimilarTitles(title string) ([]domain.Audiobook, error)
+	GetAll(ctx context.Context) ([]domain.Audiobook, error)
+	GetByID(ctx context.Context, id int) (*domain.Audiobook, error)
+	Create(ctx context.Context, audiobook *domain.Audiobook) (int, error)
+	Update(ctx context.Context, audiobook *domain.Audiobook, revision *domain.CatalogRevision) error
+	Delete(ctx context.Context, id int) error
+	GetSimilarTitles(ctx context.Context, title string) ([]domain.Audiobook, error)
+	GetRevisions(ctx context.Context, audiobookID int) ([]domain.CatalogRevision, error)
+	GetRevision(ctx context.Context, audiobookID int, version int) (*domain.CatalogRevision, error)
 }
 
 type audiobookRepo struct {
@@ -23,8 +28,23 @@ func NewAudiobookRepo(db *sql.DB) AudiobookRepo {
 	return &audiobookRepo{db: db}
 }
 
-func (r *audiobookRepo) GetAll() ([]domain.Audiobook, error) {
-	rows, err := r.db.Query("SELECT id, title, total_length FROM audiobooks")
+const audiobookSelect = `
+	SELECT a.id, a.title, a.total_length, COALESCE(a.author, ''), a.locked_fields, s.average_rating, COALESCE(s.rating_count, 0)
+	FROM audiobooks a
+	LEFT JOIN audiobook_rating_stats s ON s.audiobook_id = a.id
+`
+
+func scanAudiobook(row rowScanner) (*domain.Audiobook, error) {
+	var audiobook domain.Audiobook
+	err := row.Scan(&audiobook.ID, &audiobook.Title, &audiobook.TotalLength, &audiobook.Author, pq.Array(&audiobook.LockedFields), &audiobook.AverageRating, &audiobook.RatingCount)
+	if err != nil {
+		return nil, err
+	}
+	return &audiobook, nil
+}
+
+func (r *audiobookRepo) queryAudiobooks(ctx context.Context, query string, args ...interface{}) ([]domain.Audiobook, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -32,67 +52,78 @@ func (r *audiobookRepo) GetAll() ([]domain.Audiobook, error) {
 
 	var audiobooks []domain.Audiobook
 	for rows.Next() {
-		var audiobook domain.Audiobook
-		if err := rows.Scan(&audiobook.ID, &audiobook.Title, &audiobook.TotalLength); err != nil {
+		audiobook, err := scanAudiobook(rows)
+		if err != nil {
 			return nil, err
 		}
-		audiobooks = append(audiobooks, audiobook)
+		audiobooks = append(audiobooks, *audiobook)
 	}
 	return audiobooks, nil
 }
 
-func (r *audiobookRepo) GetByID(id int) (*domain.Audiobook, error) {
-	row := r.db.QueryRow("SELECT id, title, total_length FROM audiobooks WHERE id = $1", id)
+func (r *audiobookRepo) GetAll(ctx context.Context) ([]domain.Audiobook, error) {
+	return r.queryAudiobooks(ctx, audiobookSelect)
+}
 
-	var audiobook domain.Audiobook
-	if err := row.Scan(&audiobook.ID, &audiobook.Title, &audiobook.TotalLength); err != nil {
+func (r *audiobookRepo) GetByID(ctx context.Context, id int) (*domain.Audiobook, error) {
+	audiobook, err := scanAudiobook(conn(ctx, r.db).QueryRowContext(ctx, audiobookSelect+" WHERE a.id = $1", id))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &audiobook, nil
+	return audiobook, nil
 }
 
-func (r *audiobookRepo) Create(audiobook *domain.Audiobook) (int, error) {
+// Create inserts the audiobook together with its version 1 revision.
+func (r *audiobookRepo) Create(ctx context.Context, audiobook *domain.Audiobook) (int, error) {
 	var id int
-	err := r.db.QueryRow(
-		"INSERT INTO audiobooks (title, total_length) VALUES ($1, $2) RETURNING id",
-		audiobook.Title, audiobook.TotalLength,
-	).Scan(&id)
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			"INSERT INTO audiobooks (title, total_length, author, locked_fields) VALUES ($1, $2, NULLIF($3, ''), $4) RETURNING id",
+			audiobook.Title, audiobook.TotalLength, audiobook.Author, pq.Array(lockedOrEmpty(audiobook.LockedFields)),
+		).Scan(&id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		revision := &domain.CatalogRevision{Source: domain.RevisionCreated, Snapshot: audiobook.Fields()}
+		return insertRevision(ctx, tx, revisionEntityAudiobook, id, revision)
+	})
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	return id, nil
 }
 
-func (r *audiobookRepo) Update(audiobook *domain.Audiobook) error {
-	_, err := r.db.Exec(
-		"UPDATE audiobooks SET title = $1, total_length = $2 WHERE id = $3",
-		audiobook.Title, audiobook.TotalLength, audiobook.ID,
-	)
-	return err
+// Update saves the audiobook and records the revision describing the change.
+func (r *audiobookRepo) Update(ctx context.Context, audiobook *domain.Audiobook, revision *domain.CatalogRevision) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE audiobooks SET title = $1, total_length = $2, author = NULLIF($3, ''), locked_fields = $4 WHERE id = $5",
+			audiobook.Title, audiobook.TotalLength, audiobook.Author, pq.Array(lockedOrEmpty(audiobook.LockedFields)), audiobook.ID,
+		)
+		if err != nil {
+			return dbError(err)
+		}
+		return insertRevision(ctx, tx, revisionEntityAudiobook, audiobook.ID, revision)
+	})
 }
 
-func (r *audiobookRepo) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM audiobooks WHERE id = $1", id)
-	return err
+func (r *audiobookRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM audiobooks WHERE id = $1", id)
+	return dbError(err)
 }
 
-func (r *audiobookRepo) GetSimilarTitles(title string) ([]domain.Audiobook, error) {
-	rows, err := r.db.Query("SELECT id, title, total_length FROM audiobooks WHERE title % $1 ORDER BY similarity(title, $1) DESC", title)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (r *audiobookRepo) GetSimilarTitles(ctx context.Context, title string) ([]domain.Audiobook, error) {
+	return r.queryAudiobooks(ctx, audiobookSelect+" WHERE a.title % $1 ORDER BY similarity(a.title, $1) DESC", title)
+}
 
-	var audiobooks []domain.Audiobook
-	for rows.Next() {
-		var audiobook domain.Audiobook
-		if err := rows.Scan(&audiobook.ID, &audiobook.Title, &audiobook.TotalLength); err != nil {
-			return nil, err
-		}
-		audiobooks = append(audiobooks, audiobook)
-	}
-	return audiobooks, nil
+func (r *audiobookRepo) GetRevisions(ctx context.Context, audiobookID int) ([]domain.CatalogRevision, error) {
+	return getRevisions(ctx, conn(ctx, r.db), revisionEntityAudiobook, audiobookID)
+}
+
+func (r *audiobookRepo) GetRevision(ctx context.Context, audiobookID int, version int) (*domain.CatalogRevision, error) {
+	return getRevision(ctx, conn(ctx, r.db), revisionEntityAudiobook, audiobookID, version)
 }
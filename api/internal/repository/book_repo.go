@@ -1,22 +1,27 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
 	"book_boy/api/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 type BookRepo interface {
-	GetAll() ([]domain.Book, error)
-	GetByID(id int) (*domain.Book, error)
-	Create(book *domain.Book) (int, error)
-	Update(book *domain.Book) error
-	Delete(id int) error
-	GetByTitle(title string) (*domain.Book, error)
-	GetSimilarTitles(title string) ([]domain.Book, error)
-	FilterBooks(filter BookFilter) ([]domain.Book, error)
+	GetAll(ctx context.Context) ([]domain.Book, error)
+	GetByID(ctx context.Context, id int) (*domain.Book, error)
+	Create(ctx context.Context, book *domain.Book) (int, error)
+	Update(ctx context.Context, book *domain.Book, revision *domain.CatalogRevision) error
+	Delete(ctx context.Context, id int) error
+	GetByTitle(ctx context.Context, title string) (*domain.Book, error)
+	GetSimilarTitles(ctx context.Context, title string) ([]domain.Book, error)
+	FilterBooks(ctx context.Context, filter BookFilter) ([]domain.Book, error)
+	GetRevisions(ctx context.Context, bookID int) ([]domain.CatalogRevision, error)
+	GetRevision(ctx context.Context, bookID int, version int) (*domain.CatalogRevision, error)
 }
 
 type bookRepo struct {
@@ -27,8 +32,27 @@ func NewBookRepo(db *sql.DB) BookRepo {
 	return &bookRepo{db: db}
 }
 
-func (r *bookRepo) GetAll() ([]domain.Book, error) {
-	rows, err := r.db.Query("SELECT id, isbn, title, total_pages FROM books")
+const bookSelect = `
+	SELECT b.id, b.isbn, b.title, b.total_pages, COALESCE(b.author, ''), b.locked_fields, s.average_rating, COALESCE(s.rating_count, 0)
+	FROM books b
+	LEFT JOIN book_rating_stats s ON s.book_id = b.id
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBook(row rowScanner) (*domain.Book, error) {
+	var book domain.Book
+	err := row.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages, &book.Author, pq.Array(&book.LockedFields), &book.AverageRating, &book.RatingCount)
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+func (r *bookRepo) queryBooks(ctx context.Context, query string, args ...interface{}) ([]domain.Book, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -36,20 +60,21 @@ func (r *bookRepo) GetAll() ([]domain.Book, error) {
 
 	var books []domain.Book
 	for rows.Next() {
-		var book domain.Book
-		if err := rows.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages); err != nil {
+		book, err := scanBook(rows)
+		if err != nil {
 			return nil, err
 		}
-		books = append(books, book)
+		books = append(books, *book)
 	}
 	return books, nil
 }
 
-func (r *bookRepo) GetByID(id int) (*domain.Book, error) {
-	row := r.db.QueryRow("SELECT id, isbn, title, total_pages FROM books WHERE id = $1", id)
+func (r *bookRepo) GetAll(ctx context.Context) ([]domain.Book, error) {
+	return r.queryBooks(ctx, bookSelect)
+}
 
-	var book domain.Book
-	err := row.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages)
+func (r *bookRepo) GetByID(ctx context.Context, id int) (*domain.Book, error) {
+	book, err := scanBook(conn(ctx, r.db).QueryRowContext(ctx, bookSelect+" WHERE b.id = $1", id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -57,39 +82,51 @@ func (r *bookRepo) GetByID(id int) (*domain.Book, error) {
 		return nil, err
 	}
 
-	return &book, nil
+	return book, nil
 }
 
-func (r *bookRepo) Create(book *domain.Book) (int, error) {
+// Create inserts the book together with its version 1 revision.
+func (r *bookRepo) Create(ctx context.Context, book *domain.Book) (int, error) {
 	var id int
-	err := r.db.QueryRow(
-		"INSERT INTO books (isbn, title, total_pages) VALUES ($1, $2, $3) RETURNING id",
-		book.ISBN, book.Title, book.TotalPages,
-	).Scan(&id)
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			"INSERT INTO books (isbn, title, total_pages, author, locked_fields) VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id",
+			book.ISBN, book.Title, book.TotalPages, book.Author, pq.Array(lockedOrEmpty(book.LockedFields)),
+		).Scan(&id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		revision := &domain.CatalogRevision{Source: domain.RevisionCreated, Snapshot: book.Fields()}
+		return insertRevision(ctx, tx, revisionEntityBook, id, revision)
+	})
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	return id, nil
 }
 
-func (r *bookRepo) Update(book *domain.Book) error {
-	_, err := r.db.Exec(
-		"UPDATE books SET isbn = $1, title = $2, total_pages = $3 WHERE id = $4",
-		book.ISBN, book.Title, book.TotalPages, book.ID,
-	)
-	return err
+// Update saves the book and records the revision describing the change.
+func (r *bookRepo) Update(ctx context.Context, book *domain.Book, revision *domain.CatalogRevision) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE books SET isbn = $1, title = $2, total_pages = $3, author = NULLIF($4, ''), locked_fields = $5 WHERE id = $6",
+			book.ISBN, book.Title, book.TotalPages, book.Author, pq.Array(lockedOrEmpty(book.LockedFields)), book.ID,
+		)
+		if err != nil {
+			return dbError(err)
+		}
+		return insertRevision(ctx, tx, revisionEntityBook, book.ID, revision)
+	})
 }
 
-func (r *bookRepo) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM books WHERE id = $1", id)
-	return err
+func (r *bookRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM books WHERE id = $1", id)
+	return dbError(err)
 }
 
-func (r *bookRepo) GetByTitle(title string) (*domain.Book, error) {
-	row := r.db.QueryRow("SELECT id, isbn, title, total_pages FROM books WHERE title = $1", title)
-
-	var book domain.Book
-	err := row.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages)
+func (r *bookRepo) GetByTitle(ctx context.Context, title string) (*domain.Book, error) {
+	book, err := scanBook(conn(ctx, r.db).QueryRowContext(ctx, bookSelect+" WHERE b.title = $1", title))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -97,71 +134,56 @@ func (r *bookRepo) GetByTitle(title string) (*domain.Book, error) {
 		return nil, err
 	}
 
-	return &book, nil
+	return book, nil
 }
 
-func (r *bookRepo) GetSimilarTitles(title string) ([]domain.Book, error) {
-	rows, err := r.db.Query("SELECT id, isbn, title, total_pages FROM books WHERE title % $1 ORDER BY similarity(title, $1) DESC", title)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var books []domain.Book
-	for rows.Next() {
-		var book domain.Book
-		if err := rows.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages); err != nil {
-			return nil, err
-		}
-		books = append(books, book)
-	}
-	return books, nil
+func (r *bookRepo) GetSimilarTitles(ctx context.Context, title string) ([]domain.Book, error) {
+	return r.queryBooks(ctx, bookSelect+" WHERE b.title % $1 ORDER BY similarity(b.title, $1) DESC", title)
 }
 
-func (r *bookRepo) FilterBooks(filter BookFilter) ([]domain.Book, error) {
-	query := "SELECT id, isbn, title, total_pages FROM books"
+func (r *bookRepo) FilterBooks(ctx context.Context, filter BookFilter) ([]domain.Book, error) {
+	query := bookSelect
 	var conditions []string
 	var args []interface{}
 	argIndex := 1
 
 	if filter.ID != nil {
-		conditions = append(conditions, fmt.Sprintf("id = $%d", argIndex))
+		conditions = append(conditions, fmt.Sprintf("b.id = $%d", argIndex))
 		args = append(args, *filter.ID)
 		argIndex++
 	}
 	if filter.ISBN != nil {
-		conditions = append(conditions, fmt.Sprintf("isbn = $%d", argIndex))
+		conditions = append(conditions, fmt.Sprintf("b.isbn = $%d", argIndex))
 		args = append(args, *filter.ISBN)
 		argIndex++
 	}
 	if filter.Title != nil {
-		conditions = append(conditions, fmt.Sprintf("title = $%d", argIndex))
+		conditions = append(conditions, fmt.Sprintf("b.title = $%d", argIndex))
 		args = append(args, *filter.Title)
 		argIndex++
 	}
 	if filter.TotalPages != nil {
-		conditions = append(conditions, fmt.Sprintf("total_pages = $%d", argIndex))
+		conditions = append(conditions, fmt.Sprintf("b.total_pages = $%d", argIndex))
 		args = append(args, *filter.TotalPages)
 		argIndex++
 	}
+	if filter.MinRating != nil {
+		conditions = append(conditions, fmt.Sprintf("COALESCE(s.average_rating, 0) >= $%d", argIndex))
+		args = append(args, *filter.MinRating)
+		argIndex++
+	}
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	return r.queryBooks(ctx, query, args...)
+}
 
-	var books []domain.Book
-	for rows.Next() {
-		var book domain.Book
-		if err := rows.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages); err != nil {
-			return nil, err
-		}
-		books = append(books, book)
-	}
-	return books, nil
+func (r *bookRepo) GetRevisions(ctx context.Context, bookID int) ([]domain.CatalogRevision, error) {
+	return getRevisions(ctx, conn(ctx, r.db), revisionEntityBook, bookID)
+}
+
+func (r *bookRepo) GetRevision(ctx context.Context, bookID int, version int) (*domain.CatalogRevision, error) {
+	return getRevision(ctx, conn(ctx, r.db), revisionEntityBook, bookID, version)
 }
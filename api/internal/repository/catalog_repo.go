@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"book_boy/api/internal/domain"
+)
+
+type CatalogRepo interface {
+	GetBookDuplicateCandidates(ctx context.Context, bookID int, limit int) ([]domain.DuplicateCandidate, error)
+	GetAudiobookDuplicateCandidates(ctx context.Context, audiobookID int, limit int) ([]domain.DuplicateCandidate, error)
+	MergeBooks(ctx context.Context, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error)
+	MergeAudiobooks(ctx context.Context, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error)
+}
+
+type catalogRepo struct {
+	db *sql.DB
+}
+
+func NewCatalogRepo(db *sql.DB) CatalogRepo {
+	return &catalogRepo{db: db}
+}
+
+// isbnCoreSQL reduces an ISBN column to the 9 digits ISBN-10 and ISBN-13 share,
+// so "0-441-17271-7" and "9780441172719" compare equal.
+func isbnCoreSQL(column string) string {
+	n := fmt.Sprintf("regexp_replace(%s, '[^0-9Xx]', '', 'g')", column)
+	return fmt.Sprintf(
+		"CASE WHEN length(%[1]s) = 13 AND left(%[1]s, 3) IN ('978', '979') THEN substr(%[1]s, 4, 9) WHEN length(%[1]s) = 10 THEN left(%[1]s, 9) ELSE %[1]s END",
+		n,
+	)
+}
+
+func (r *catalogRepo) GetBookDuplicateCandidates(ctx context.Context, bookID int, limit int) ([]domain.DuplicateCandidate, error) {
+	query := fmt.Sprintf(`
+		SELECT b.id, b.isbn, b.title, b.total_pages, COALESCE(b.author, ''), s.average_rating, COALESCE(s.rating_count, 0),
+			similarity(b.title, t.title)
+		FROM books t
+		JOIN books b ON b.id <> t.id AND (b.title %% t.title OR %s = %s)
+		LEFT JOIN book_rating_stats s ON s.book_id = b.id
+		WHERE t.id = $1
+		ORDER BY similarity(b.title, t.title) DESC, b.id
+		LIMIT $2
+	`, isbnCoreSQL("b.isbn"), isbnCoreSQL("t.isbn"))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, bookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []domain.DuplicateCandidate
+	for rows.Next() {
+		var book domain.Book
+		var candidate domain.DuplicateCandidate
+		if err := rows.Scan(&book.ID, &book.ISBN, &book.Title, &book.TotalPages, &book.Author, &book.AverageRating, &book.RatingCount,
+			&candidate.TitleSimilarity); err != nil {
+			return nil, err
+		}
+		candidate.Book = &book
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+func (r *catalogRepo) GetAudiobookDuplicateCandidates(ctx context.Context, audiobookID int, limit int) ([]domain.DuplicateCandidate, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT a.id, a.title, a.total_length, COALESCE(a.author, ''), s.average_rating, COALESCE(s.rating_count, 0),
+			similarity(a.title, t.title)
+		FROM audiobooks t
+		JOIN audiobooks a ON a.id <> t.id AND a.title % t.title
+		LEFT JOIN audiobook_rating_stats s ON s.audiobook_id = a.id
+		WHERE t.id = $1
+		ORDER BY similarity(a.title, t.title) DESC, a.id
+		LIMIT $2
+	`, audiobookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []domain.DuplicateCandidate
+	for rows.Next() {
+		var audiobook domain.Audiobook
+		var candidate domain.DuplicateCandidate
+		if err := rows.Scan(&audiobook.ID, &audiobook.Title, &audiobook.TotalLength, &audiobook.Author, &audiobook.AverageRating, &audiobook.RatingCount,
+			&candidate.TitleSimilarity); err != nil {
+			return nil, err
+		}
+		candidate.Audiobook = &audiobook
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// catalogKind names the columns a merge touches for one format.
+type catalogKind struct {
+	table     string
+	fkColumn  string
+	posColumn string
+	posZero   string
+	// extraTables reference the catalog row without a uniqueness rule.
+	extraTables []string
+}
+
+var (
+	bookKind = catalogKind{
+		table:       "books",
+		fkColumn:    "book_id",
+		posColumn:   "book_page",
+		posZero:     "0",
+		extraTables: []string{"activity_events", "clubs"},
+	}
+	audiobookKind = catalogKind{
+		table:       "audiobooks",
+		fkColumn:    "audiobook_id",
+		posColumn:   "audiobook_time",
+		posZero:     "INTERVAL '0'",
+		extraTables: []string{"activity_events"},
+	}
+)
+
+// mergeCollisions are tables with a unique index per owner on the catalog
+// column, so a duplicate's row must go when the canonical record already has one.
+var mergeCollisions = []struct{ table, owner string }{
+	{"shelf_items", "shelf_id"},
+	{"reviews", "user_id"},
+}
+
+func (r *catalogRepo) MergeBooks(ctx context.Context, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error) {
+	return r.merge(ctx, bookKind, canonicalID, duplicateIDs)
+}
+
+func (r *catalogRepo) MergeAudiobooks(ctx context.Context, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error) {
+	return r.merge(ctx, audiobookKind, canonicalID, duplicateIDs)
+}
+
+// merge folds each duplicate into the canonical record inside one transaction.
+// Where a user tracked both, the entry further along survives and inherits the
+// other's notes, tags and history. Shelf items and reviews that would collide
+// with the canonical record's are dropped in its favour.
+func (r *catalogRepo) merge(ctx context.Context, kind catalogKind, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error) {
+	result := &domain.MergeResult{CanonicalID: canonicalID, MergedIDs: duplicateIDs}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		for _, duplicateID := range duplicateIDs {
+			combined, err := combineOverlappingProgress(ctx, tx, kind, canonicalID, duplicateID)
+			if err != nil {
+				return dbError(err)
+			}
+			result.ProgressCombined += combined
+
+			moved, err := tx.ExecContext(ctx,
+				fmt.Sprintf("UPDATE progress SET %[1]s = $1, version = version + 1 WHERE %[1]s = $2", kind.fkColumn),
+				canonicalID, duplicateID,
+			)
+			if err != nil {
+				return dbError(err)
+			}
+			count, err := moved.RowsAffected()
+			if err != nil {
+				return dbError(err)
+			}
+			result.ProgressMoved += int(count)
+
+			for _, c := range mergeCollisions {
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+					DELETE FROM %[1]s d
+					WHERE d.%[2]s = $2 AND EXISTS (
+						SELECT 1 FROM %[1]s c WHERE c.%[2]s = $1 AND c.%[3]s = d.%[3]s
+					)
+				`, c.table, kind.fkColumn, c.owner), canonicalID, duplicateID); err != nil {
+					return dbError(err)
+				}
+				if _, err := tx.ExecContext(ctx,
+					fmt.Sprintf("UPDATE %[1]s SET %[2]s = $1 WHERE %[2]s = $2", c.table, kind.fkColumn),
+					canonicalID, duplicateID,
+				); err != nil {
+					return dbError(err)
+				}
+			}
+
+			for _, table := range kind.extraTables {
+				if _, err := tx.ExecContext(ctx,
+					fmt.Sprintf("UPDATE %[1]s SET %[2]s = $1 WHERE %[2]s = $2", table, kind.fkColumn),
+					canonicalID, duplicateID,
+				); err != nil {
+					return dbError(err)
+				}
+			}
+
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", kind.table), duplicateID); err != nil {
+				return dbError(err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return result, nil
+}
+
+// combineOverlappingProgress resolves users tracking both records, which the
+// unique (user_id, book_id) index would otherwise reject on repoint.
+func combineOverlappingProgress(ctx context.Context, tx *sql.Tx, kind catalogKind, canonicalID int, duplicateID int) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT c.id, d.id, COALESCE(d.%[2]s, %[3]s) > COALESCE(c.%[2]s, %[3]s)
+		FROM progress c
+		JOIN progress d ON d.user_id = c.user_id AND d.%[1]s = $2
+		WHERE c.%[1]s = $1
+	`, kind.fkColumn, kind.posColumn, kind.posZero), canonicalID, duplicateID)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	type pair struct{ keep, drop int }
+	var pairs []pair
+	for rows.Next() {
+		var canonicalProgress, duplicateProgress int
+		var duplicateAhead bool
+		if err := rows.Scan(&canonicalProgress, &duplicateProgress, &duplicateAhead); err != nil {
+			rows.Close()
+			return 0, dbError(err)
+		}
+		if duplicateAhead {
+			pairs = append(pairs, pair{keep: duplicateProgress, drop: canonicalProgress})
+		} else {
+			pairs = append(pairs, pair{keep: canonicalProgress, drop: duplicateProgress})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, dbError(err)
+	}
+
+	for _, p := range pairs {
+		statements := []string{
+			"UPDATE progress_notes SET progress_id = $1 WHERE progress_id = $2",
+			"INSERT INTO progress_tags (progress_id, tag) SELECT $1, tag FROM progress_tags WHERE progress_id = $2 ON CONFLICT DO NOTHING",
+			"UPDATE progress_activity SET progress_id = $1 WHERE progress_id = $2",
+			"UPDATE activity_events SET progress_id = $1 WHERE progress_id = $2",
+			"DELETE FROM progress WHERE id = $2",
+		}
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement, p.keep, p.drop); err != nil {
+				return 0, dbError(err)
+			}
+		}
+	}
+	return len(pairs), nil
+}
@@ -0,0 +1,421 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"book_boy/api/internal/domain"
+)
+
+type ClubRepo interface {
+	GetAllByUser(ctx context.Context, userID int) ([]domain.Club, error)
+	GetByID(ctx context.Context, id int) (*domain.Club, error)
+	Create(ctx context.Context, club *domain.Club) (int, error)
+	Update(ctx context.Context, club *domain.Club) error
+	Delete(ctx context.Context, id int) error
+
+	GetMembers(ctx context.Context, clubID int) ([]domain.ClubMember, error)
+	GetMember(ctx context.Context, clubID int, userID int) (*domain.ClubMember, error)
+	AddMember(ctx context.Context, clubID int, userID int, role domain.ClubRole) error
+	RemoveMember(ctx context.Context, clubID int, userID int) error
+	SetMemberProgress(ctx context.Context, clubID int, userID int, progressID *int) error
+	GetMemberPositions(ctx context.Context, clubID int) ([]MemberPosition, error)
+
+	CreateInvite(ctx context.Context, invite *domain.ClubInvite) (int, error)
+	GetInvite(ctx context.Context, id int) (*domain.ClubInvite, error)
+	GetPendingInvites(ctx context.Context, userID int) ([]domain.ClubInvite, error)
+	UpdateInviteStatus(ctx context.Context, id int, status domain.InviteStatus) error
+
+	GetCheckpoints(ctx context.Context, clubID int) ([]domain.Checkpoint, error)
+	GetCheckpoint(ctx context.Context, id int) (*domain.Checkpoint, error)
+	CreateCheckpoint(ctx context.Context, checkpoint *domain.Checkpoint) (int, error)
+	DeleteCheckpoint(ctx context.Context, id int) error
+	GetDueCheckpoints(ctx context.Context, now time.Time) ([]domain.Checkpoint, error)
+	MarkCheckpointNotified(ctx context.Context, id int) (bool, error)
+
+	GetPosts(ctx context.Context, checkpointID int) ([]domain.ClubPost, error)
+	CreatePost(ctx context.Context, post *domain.ClubPost) (int, error)
+}
+
+// MemberPosition is one of a member's progress entries on the club's book,
+// with the totals needed to turn a linked audiobook's time into a page.
+type MemberPosition struct {
+	UserID        int
+	BookPage      *int
+	AudiobookTime *domain.CustomDuration
+	TotalPages    int
+	TotalLength   *domain.CustomDuration
+}
+
+type clubRepo struct {
+	db *sql.DB
+}
+
+func NewClubRepo(db *sql.DB) ClubRepo {
+	return &clubRepo{db: db}
+}
+
+const clubSelect = `SELECT c.id, c.owner_id, c.book_id, c.name, c.description, c.created_at, c.updated_at FROM clubs c`
+
+func scanClub(row rowScanner) (*domain.Club, error) {
+	var club domain.Club
+	if err := row.Scan(&club.ID, &club.OwnerID, &club.BookID, &club.Name, &club.Description, &club.CreatedAt, &club.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &club, nil
+}
+
+func (r *clubRepo) GetAllByUser(ctx context.Context, userID int) ([]domain.Club, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, clubSelect+`
+		JOIN club_members m ON m.club_id = c.id
+		WHERE m.user_id = $1
+		ORDER BY c.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clubs []domain.Club
+	for rows.Next() {
+		club, err := scanClub(rows)
+		if err != nil {
+			return nil, err
+		}
+		clubs = append(clubs, *club)
+	}
+	return clubs, nil
+}
+
+func (r *clubRepo) GetByID(ctx context.Context, id int) (*domain.Club, error) {
+	club, err := scanClub(conn(ctx, r.db).QueryRowContext(ctx, clubSelect+" WHERE c.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return club, nil
+}
+
+// Create inserts the club and its owner's membership together.
+func (r *clubRepo) Create(ctx context.Context, club *domain.Club) (int, error) {
+	var id int
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO clubs (owner_id, book_id, name, description)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, club.OwnerID, club.BookID, club.Name, club.Description).Scan(&id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO club_members (club_id, user_id, role) VALUES ($1, $2, $3)",
+			id, club.OwnerID, domain.ClubRoleOwner,
+		); err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *clubRepo) Update(ctx context.Context, club *domain.Club) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE clubs SET name = $1, description = $2 WHERE id = $3",
+		club.Name, club.Description, club.ID,
+	)
+	return dbError(err)
+}
+
+func (r *clubRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM clubs WHERE id = $1", id)
+	return dbError(err)
+}
+
+const clubMemberSelect = `
+	SELECT m.club_id, m.user_id, u.username, m.role, m.progress_id, m.joined_at
+	FROM club_members m
+	JOIN users u ON u.id = m.user_id
+`
+
+func (r *clubRepo) GetMembers(ctx context.Context, clubID int) ([]domain.ClubMember, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, clubMemberSelect+" WHERE m.club_id = $1 ORDER BY m.joined_at", clubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []domain.ClubMember
+	for rows.Next() {
+		var member domain.ClubMember
+		if err := rows.Scan(&member.ClubID, &member.UserID, &member.Username, &member.Role, &member.ProgressID, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (r *clubRepo) GetMember(ctx context.Context, clubID int, userID int) (*domain.ClubMember, error) {
+	var member domain.ClubMember
+	err := conn(ctx, r.db).QueryRowContext(ctx, clubMemberSelect+" WHERE m.club_id = $1 AND m.user_id = $2", clubID, userID).
+		Scan(&member.ClubID, &member.UserID, &member.Username, &member.Role, &member.ProgressID, &member.JoinedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *clubRepo) AddMember(ctx context.Context, clubID int, userID int, role domain.ClubRole) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO club_members (club_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, clubID, userID, role)
+	return dbError(err)
+}
+
+func (r *clubRepo) RemoveMember(ctx context.Context, clubID int, userID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM club_members WHERE club_id = $1 AND user_id = $2", clubID, userID)
+	return dbError(err)
+}
+
+func (r *clubRepo) SetMemberProgress(ctx context.Context, clubID int, userID int, progressID *int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE club_members SET progress_id = $1 WHERE club_id = $2 AND user_id = $3",
+		progressID, clubID, userID,
+	)
+	return dbError(err)
+}
+
+// GetMemberPositions returns the members' progress entries on the club's
+// book. A member's linked entry is used when they have one. Otherwise any
+// entry on the club's books row, or on a book or audiobook with the same
+// title or ISBN, counts: StartTracking gives each reader their own books row,
+// so the club's row alone would miss most of them. The fuzzier title match
+// duplicate detection uses is left out, since a sequel passing for the club's
+// book would unlock spoilers. Members with no progress on the book have none.
+func (r *clubRepo) GetMemberPositions(ctx context.Context, clubID int) ([]MemberPosition, error) {
+	query := fmt.Sprintf(`
+		SELECT m.user_id, p.book_page, p.audiobook_time, b.total_pages, a.total_length
+		FROM club_members m
+		JOIN clubs c ON c.id = m.club_id
+		JOIN books b ON b.id = c.book_id
+		JOIN progress p ON p.user_id = m.user_id
+		LEFT JOIN books pb ON pb.id = p.book_id
+		LEFT JOIN audiobooks a ON a.id = p.audiobook_id
+		WHERE m.club_id = $1 AND (p.book_page IS NOT NULL OR p.audiobook_time IS NOT NULL)
+			AND (p.id = m.progress_id OR (m.progress_id IS NULL AND (
+				p.book_id = c.book_id
+				OR lower(pb.title) = lower(b.title)
+				OR lower(a.title) = lower(b.title)
+				OR (%[1]s <> '' AND %[1]s = %[2]s)
+			)))
+	`, isbnCoreSQL("b.isbn"), isbnCoreSQL("pb.isbn"))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, clubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []MemberPosition
+	for rows.Next() {
+		var pos MemberPosition
+		if err := rows.Scan(&pos.UserID, &pos.BookPage, &pos.AudiobookTime, &pos.TotalPages, &pos.TotalLength); err != nil {
+			return nil, err
+		}
+		positions = append(positions, pos)
+	}
+	return positions, rows.Err()
+}
+
+const clubInviteSelect = `
+	SELECT i.id, i.club_id, c.name, i.inviter_id, i.invitee_id, i.status, i.created_at
+	FROM club_invites i
+	JOIN clubs c ON c.id = i.club_id
+`
+
+func scanClubInvite(row rowScanner) (*domain.ClubInvite, error) {
+	var invite domain.ClubInvite
+	if err := row.Scan(&invite.ID, &invite.ClubID, &invite.ClubName, &invite.InviterID, &invite.InviteeID, &invite.Status, &invite.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// CreateInvite inserts a pending invite, re-opening an earlier declined one
+// for the same user.
+func (r *clubRepo) CreateInvite(ctx context.Context, invite *domain.ClubInvite) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO club_invites (club_id, inviter_id, invitee_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (club_id, invitee_id)
+		DO UPDATE SET inviter_id = EXCLUDED.inviter_id, status = 'pending', created_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`, invite.ClubID, invite.InviterID, invite.InviteeID).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *clubRepo) GetInvite(ctx context.Context, id int) (*domain.ClubInvite, error) {
+	invite, err := scanClubInvite(conn(ctx, r.db).QueryRowContext(ctx, clubInviteSelect+" WHERE i.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (r *clubRepo) GetPendingInvites(ctx context.Context, userID int) ([]domain.ClubInvite, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, clubInviteSelect+`
+		WHERE i.invitee_id = $1 AND i.status = 'pending'
+		ORDER BY i.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []domain.ClubInvite
+	for rows.Next() {
+		invite, err := scanClubInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *invite)
+	}
+	return invites, nil
+}
+
+func (r *clubRepo) UpdateInviteStatus(ctx context.Context, id int, status domain.InviteStatus) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE club_invites SET status = $1 WHERE id = $2", status, id)
+	return dbError(err)
+}
+
+const checkpointSelect = `SELECT id, club_id, kind, chapter, label, page, opens_at, created_at FROM club_checkpoints`
+
+func (r *clubRepo) queryCheckpoints(ctx context.Context, query string, args ...interface{}) ([]domain.Checkpoint, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []domain.Checkpoint
+	for rows.Next() {
+		var cp domain.Checkpoint
+		if err := rows.Scan(&cp.ID, &cp.ClubID, &cp.Kind, &cp.Chapter, &cp.Label, &cp.Page, &cp.OpensAt, &cp.CreatedAt); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+func (r *clubRepo) GetCheckpoints(ctx context.Context, clubID int) ([]domain.Checkpoint, error) {
+	return r.queryCheckpoints(ctx, checkpointSelect+" WHERE club_id = $1 ORDER BY page", clubID)
+}
+
+func (r *clubRepo) GetCheckpoint(ctx context.Context, id int) (*domain.Checkpoint, error) {
+	var cp domain.Checkpoint
+	err := conn(ctx, r.db).QueryRowContext(ctx, checkpointSelect+" WHERE id = $1", id).
+		Scan(&cp.ID, &cp.ClubID, &cp.Kind, &cp.Chapter, &cp.Label, &cp.Page, &cp.OpensAt, &cp.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (r *clubRepo) CreateCheckpoint(ctx context.Context, checkpoint *domain.Checkpoint) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO club_checkpoints (club_id, kind, chapter, label, page, opens_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, checkpoint.ClubID, checkpoint.Kind, checkpoint.Chapter, checkpoint.Label, checkpoint.Page, checkpoint.OpensAt).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *clubRepo) DeleteCheckpoint(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM club_checkpoints WHERE id = $1", id)
+	return dbError(err)
+}
+
+func (r *clubRepo) GetDueCheckpoints(ctx context.Context, now time.Time) ([]domain.Checkpoint, error) {
+	return r.queryCheckpoints(ctx, checkpointSelect+" WHERE NOT notified AND opens_at <= $1 ORDER BY opens_at", now)
+}
+
+// MarkCheckpointNotified flips the notified flag and reports whether this call
+// was the one that did it, so concurrent schedulers only notify once.
+func (r *clubRepo) MarkCheckpointNotified(ctx context.Context, id int) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE club_checkpoints SET notified = TRUE WHERE id = $1 AND NOT notified", id)
+	if err != nil {
+		return false, dbError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+	return n == 1, nil
+}
+
+func (r *clubRepo) GetPosts(ctx context.Context, checkpointID int) ([]domain.ClubPost, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT p.id, p.checkpoint_id, p.user_id, u.username, p.body, p.created_at
+		FROM club_posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.checkpoint_id = $1
+		ORDER BY p.created_at
+	`, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []domain.ClubPost
+	for rows.Next() {
+		var post domain.ClubPost
+		if err := rows.Scan(&post.ID, &post.CheckpointID, &post.UserID, &post.Username, &post.Body, &post.CreatedAt); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func (r *clubRepo) CreatePost(ctx context.Context, post *domain.ClubPost) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO club_posts (checkpoint_id, user_id, body)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, post.CheckpointID, post.UserID, post.Body).Scan(&id, &post.CreatedAt)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Members rarely track the club's own books row, so positions have to come
+// from their own copy of the title, a listened audiobook, or an explicit link.
+func TestClubRepo_GetMemberPositions_MatchesOwnCopies(t *testing.T) {
+	database := testDB(t)
+	ctx := context.Background()
+	repo := NewClubRepo(database)
+	name := fmt.Sprintf("club-test-%d", time.Now().UnixNano())
+
+	insert := func(query string, args ...interface{}) int {
+		t.Helper()
+		var id int
+		if err := database.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+		return id
+	}
+
+	var users [4]int
+	for i := range users {
+		users[i] = insert(`INSERT INTO users (username, email, password_hash) VALUES ($1, $1 || '@example.com', 'x') RETURNING id`, fmt.Sprintf("%s-%d", name, i))
+	}
+	t.Cleanup(func() {
+		for _, id := range users {
+			database.Exec(`DELETE FROM users WHERE id = $1`, id)
+		}
+	})
+
+	title := name + " Dune"
+	clubBook := insert(`INSERT INTO books (isbn, title, total_pages) VALUES ($1, $2, 400) RETURNING id`, name+"-0441172717", title)
+	ownCopy := insert(`INSERT INTO books (isbn, title, total_pages) VALUES ($1, upper($2), 400) RETURNING id`, name+"-copy", title)
+	sequel := insert(`INSERT INTO books (isbn, title, total_pages) VALUES ($1, $2, 300) RETURNING id`, name+"-sequel", title+" Messiah")
+	audiobook := insert(`INSERT INTO audiobooks (title, total_length) VALUES ($1, '10:00:00') RETURNING id`, title)
+	t.Cleanup(func() {
+		// Book-only progress can't outlive its book, so it goes first.
+		database.Exec(`DELETE FROM progress WHERE book_id = ANY(ARRAY[$1, $2, $3]::int[])`, clubBook, ownCopy, sequel)
+		database.Exec(`DELETE FROM books WHERE id = ANY(ARRAY[$1, $2, $3]::int[])`, clubBook, ownCopy, sequel)
+		database.Exec(`DELETE FROM audiobooks WHERE id = $1`, audiobook)
+	})
+
+	clubID := insert(`INSERT INTO clubs (owner_id, book_id, name) VALUES ($1, $2, $3) RETURNING id`, users[0], clubBook, name)
+	for i, userID := range users {
+		role := "member"
+		if i == 0 {
+			role = "owner"
+		}
+		if _, err := database.ExecContext(ctx, `INSERT INTO club_members (club_id, user_id, role) VALUES ($1, $2, $3)`, clubID, userID, role); err != nil {
+			t.Fatalf("failed to add member: %v", err)
+		}
+	}
+
+	insert(`INSERT INTO progress (user_id, book_id, book_page) VALUES ($1, $2, 120) RETURNING id`, users[0], ownCopy)
+	insert(`INSERT INTO progress (user_id, audiobook_id, audiobook_time) VALUES ($1, $2, '05:00:00') RETURNING id`, users[1], audiobook)
+	insert(`INSERT INTO progress (user_id, book_id, book_page) VALUES ($1, $2, 250) RETURNING id`, users[2], sequel)
+	linked := insert(`INSERT INTO progress (user_id, book_id, book_page) VALUES ($1, $2, 80) RETURNING id`, users[3], sequel)
+	if err := repo.SetMemberProgress(ctx, clubID, users[3], &linked); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	positions, err := repo.GetMemberPositions(ctx, clubID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byUser := make(map[int]MemberPosition)
+	for _, pos := range positions {
+		byUser[pos.UserID] = pos
+	}
+
+	if pos, ok := byUser[users[0]]; !ok || pos.BookPage == nil || *pos.BookPage != 120 {
+		t.Errorf("expected the owner's own copy to count, got %+v", pos)
+	}
+	if pos, ok := byUser[users[1]]; !ok || pos.AudiobookTime == nil || pos.TotalLength == nil {
+		t.Errorf("expected the listener's audiobook to count, got %+v", pos)
+	}
+	if pos, ok := byUser[users[2]]; ok {
+		t.Errorf("expected the sequel not to pass for the club's book, got %+v", pos)
+	}
+	if pos, ok := byUser[users[3]]; !ok || pos.BookPage == nil || *pos.BookPage != 80 {
+		t.Errorf("expected the linked entry to count, got %+v", pos)
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"book_boy/api/internal/errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes the repos translate.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+)
+
+// dbError translates driver errors into the typed errors services and
+// handlers understand. Anything it doesn't recognise passes through.
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch string(pqErr.Code) {
+	case pgUniqueViolation:
+		return errors.Conflict("a record with these values already exists")
+	case pgForeignKeyViolation:
+		// Deleting a row others still point at, versus pointing at a row
+		// that isn't there.
+		if strings.HasPrefix(pqErr.Message, "update or delete") {
+			return errors.Conflict("the record is still referenced by others")
+		}
+		return errors.NotFound("a referenced record does not exist")
+	case pgCheckViolation:
+		return errors.ErrInvalidInput("the values break a constraint: " + pqErr.Constraint)
+	case pgNotNullViolation:
+		return errors.ErrInvalidField(pqErr.Column, "is required")
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"book_boy/api/internal/middleware"
+	"book_boy/api/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// Constraint violations are the client's doing and must not surface as 500s.
+func TestDBError_Problems(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{
+			name:   "no rows",
+			err:    sql.ErrNoRows,
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		{
+			name:   "unique violation",
+			err:    &pq.Error{Code: "23505", Constraint: "books_isbn_key"},
+			status: http.StatusConflict,
+			code:   "conflict",
+		},
+		{
+			name:   "foreign key to a missing row",
+			err:    &pq.Error{Code: "23503", Message: `insert or update on table "progress" violates foreign key constraint`},
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		{
+			name:   "deleting a referenced row",
+			err:    &pq.Error{Code: "23503", Message: `update or delete on table "books" violates foreign key constraint`},
+			status: http.StatusConflict,
+			code:   "conflict",
+		},
+		{
+			name:   "check violation",
+			err:    &pq.Error{Code: "23514", Constraint: "goals_target_check"},
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+		},
+		{
+			name:   "not null violation",
+			err:    &pq.Error{Code: "23502", Column: "title"},
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			field:  "title",
+		},
+		{
+			name:   "wrapped unique violation",
+			err:    fmt.Errorf("creating shelf: %w", &pq.Error{Code: "23505"}),
+			status: http.StatusConflict,
+			code:   "conflict",
+		},
+		{
+			name:   "unrecognised code",
+			err:    &pq.Error{Code: "40001"},
+			status: http.StatusInternalServerError,
+			code:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := middleware.ProblemFor(repository.DBError(tt.err))
+			if problem.Status != tt.status || problem.Code != tt.code {
+				t.Fatalf("got %d %s, want %d %s", problem.Status, problem.Code, tt.status, tt.code)
+			}
+			if tt.field != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tt.field) {
+				t.Fatalf("expected a problem on %q, got %+v", tt.field, problem.Errors)
+			}
+		})
+	}
+}
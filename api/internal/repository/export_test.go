@@ -0,0 +1,5 @@
+package repository
+
+// DBError exposes dbError to the external tests, which check how its errors
+// render through the problem middleware.
+var DBError = dbError
@@ -8,6 +8,7 @@ type BookFilter struct {
 	ISBN       *string
 	Title      *string
 	TotalPages *int
+	MinRating  *float64
 }
 
 type ProgressFilter struct {
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"book_boy/api/internal/domain"
+)
+
+type FollowRepo interface {
+	Follow(ctx context.Context, followerID int, followeeID int) error
+	Unfollow(ctx context.Context, followerID int, followeeID int) error
+	IsFollowing(ctx context.Context, followerID int, followeeID int) (bool, error)
+	GetFollowers(ctx context.Context, userID int) ([]domain.UserSummary, error)
+	GetFollowing(ctx context.Context, userID int) ([]domain.UserSummary, error)
+	Counts(ctx context.Context, userID int) (followers int, following int, err error)
+	RequestFollow(ctx context.Context, followerID int, followeeID int) error
+	GetFollowRequests(ctx context.Context, userID int) ([]domain.UserSummary, error)
+	ApproveFollowRequest(ctx context.Context, followerID int, followeeID int) (bool, error)
+	DeleteFollowRequest(ctx context.Context, followerID int, followeeID int) (bool, error)
+}
+
+type followRepo struct {
+	db *sql.DB
+}
+
+func NewFollowRepo(db *sql.DB) FollowRepo {
+	return &followRepo{db: db}
+}
+
+func (r *followRepo) Follow(ctx context.Context, followerID int, followeeID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, followerID, followeeID)
+	return dbError(err)
+}
+
+func (r *followRepo) Unfollow(ctx context.Context, followerID int, followeeID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+	return dbError(err)
+}
+
+func (r *followRepo) IsFollowing(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)",
+		followerID, followeeID,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (r *followRepo) GetFollowers(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	return r.queryUsers(ctx, `
+		SELECT u.id, u.username
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		ORDER BY f.created_at DESC
+	`, userID)
+}
+
+func (r *followRepo) GetFollowing(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	return r.queryUsers(ctx, `
+		SELECT u.id, u.username
+		FROM follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC
+	`, userID)
+}
+
+func (r *followRepo) Counts(ctx context.Context, userID int) (int, int, error) {
+	var followers, following int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM follows WHERE followee_id = $1),
+			(SELECT COUNT(*) FROM follows WHERE follower_id = $1)
+	`, userID).Scan(&followers, &following)
+	return followers, following, err
+}
+
+func (r *followRepo) RequestFollow(ctx context.Context, followerID int, followeeID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO follow_requests (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, followerID, followeeID)
+	return dbError(err)
+}
+
+// GetFollowRequests lists the users waiting for userID to approve them, oldest
+// first.
+func (r *followRepo) GetFollowRequests(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	return r.queryUsers(ctx, `
+		SELECT u.id, u.username
+		FROM follow_requests fr
+		JOIN users u ON u.id = fr.follower_id
+		WHERE fr.followee_id = $1
+		ORDER BY fr.created_at
+	`, userID)
+}
+
+// ApproveFollowRequest turns a pending request into a follow in one statement,
+// reporting false if there was no request to approve.
+func (r *followRepo) ApproveFollowRequest(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	var approved int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		WITH req AS (
+			DELETE FROM follow_requests
+			WHERE follower_id = $1 AND followee_id = $2
+			RETURNING follower_id, followee_id
+		), ins AS (
+			INSERT INTO follows (follower_id, followee_id)
+			SELECT follower_id, followee_id FROM req
+			ON CONFLICT DO NOTHING
+		)
+		SELECT COUNT(*) FROM req
+	`, followerID, followeeID).Scan(&approved)
+	if err != nil {
+		return false, dbError(err)
+	}
+	return approved > 0, nil
+}
+
+func (r *followRepo) DeleteFollowRequest(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM follow_requests WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+	if err != nil {
+		return false, dbError(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *followRepo) queryUsers(ctx context.Context, query string, args ...interface{}) ([]domain.UserSummary, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.UserSummary
+	for rows.Next() {
+		var user domain.UserSummary
+		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
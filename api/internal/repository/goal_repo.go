@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"book_boy/api/internal/domain"
+)
+
+type GoalRepo interface {
+	GetAllByUser(ctx context.Context, userID int) ([]domain.Goal, error)
+	GetByID(ctx context.Context, id int) (*domain.Goal, error)
+	Create(ctx context.Context, goal *domain.Goal) (int, error)
+	Update(ctx context.Context, goal *domain.Goal) error
+	Delete(ctx context.Context, id int) error
+	UpdateLastMilestone(ctx context.Context, id int, milestone int) error
+	RecordActivity(ctx context.Context, activity *domain.ProgressActivity) error
+	SumActivity(ctx context.Context, userID int, from time.Time, to time.Time) (*domain.ActivityTotals, error)
+}
+
+type goalRepo struct {
+	db *sql.DB
+}
+
+func NewGoalRepo(db *sql.DB) GoalRepo {
+	return &goalRepo{db: db}
+}
+
+func (r *goalRepo) GetAllByUser(ctx context.Context, userID int) ([]domain.Goal, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, period, metric, target, year, month, last_milestone, created_at, updated_at
+		FROM reading_goals WHERE user_id = $1
+		ORDER BY year DESC, COALESCE(month, 0) DESC, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []domain.Goal
+	for rows.Next() {
+		var goal domain.Goal
+		if err := rows.Scan(
+			&goal.ID, &goal.UserID, &goal.Period, &goal.Metric, &goal.Target,
+			&goal.Year, &goal.Month, &goal.LastMilestone, &goal.CreatedAt, &goal.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+	return goals, nil
+}
+
+func (r *goalRepo) GetByID(ctx context.Context, id int) (*domain.Goal, error) {
+	var goal domain.Goal
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, user_id, period, metric, target, year, month, last_milestone, created_at, updated_at
+		FROM reading_goals WHERE id = $1
+	`, id).Scan(
+		&goal.ID, &goal.UserID, &goal.Period, &goal.Metric, &goal.Target,
+		&goal.Year, &goal.Month, &goal.LastMilestone, &goal.CreatedAt, &goal.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+func (r *goalRepo) Create(ctx context.Context, goal *domain.Goal) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO reading_goals (user_id, period, metric, target, year, month)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, goal.UserID, goal.Period, goal.Metric, goal.Target, goal.Year, goal.Month).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *goalRepo) Update(ctx context.Context, goal *domain.Goal) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE reading_goals
+		SET period = $1, metric = $2, target = $3, year = $4, month = $5, last_milestone = $6
+		WHERE id = $7
+	`, goal.Period, goal.Metric, goal.Target, goal.Year, goal.Month, goal.LastMilestone, goal.ID)
+	return dbError(err)
+}
+
+func (r *goalRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM reading_goals WHERE id = $1", id)
+	return dbError(err)
+}
+
+func (r *goalRepo) UpdateLastMilestone(ctx context.Context, id int, milestone int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE reading_goals SET last_milestone = $1 WHERE id = $2", milestone, id)
+	return dbError(err)
+}
+
+// RecordActivity dates the activity at RecordedAt when set, so reading logged
+// offline counts towards the day it happened, and at NOW() otherwise.
+func (r *goalRepo) RecordActivity(ctx context.Context, activity *domain.ProgressActivity) error {
+	var recordedAt *time.Time
+	if !activity.RecordedAt.IsZero() {
+		recordedAt = &activity.RecordedAt
+	}
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO progress_activity (user_id, progress_id, pages_delta, seconds_delta, finished, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6::timestamp, NOW()))
+		RETURNING id, recorded_at
+	`, activity.UserID, activity.ProgressID, activity.PagesDelta, activity.SecondsDelta, activity.Finished, recordedAt).Scan(&activity.ID, &activity.RecordedAt)
+}
+
+func (r *goalRepo) SumActivity(ctx context.Context, userID int, from time.Time, to time.Time) (*domain.ActivityTotals, error) {
+	var totals domain.ActivityTotals
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(pages_delta), 0),
+			COALESCE(SUM(seconds_delta), 0),
+			COUNT(*) FILTER (WHERE finished)
+		FROM progress_activity
+		WHERE user_id = $1 AND recorded_at >= $2 AND recorded_at < $3
+	`, userID, from, to).Scan(&totals.Pages, &totals.Seconds, &totals.BooksFinished)
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"book_boy/api/internal/domain"
+)
+
+type NoteRepo interface {
+	GetByProgress(ctx context.Context, progressID int) ([]domain.Note, error)
+	GetByID(ctx context.Context, id int) (*domain.Note, error)
+	Create(ctx context.Context, note *domain.Note) (int, error)
+	Update(ctx context.Context, note *domain.Note) error
+	Delete(ctx context.Context, id int) error
+	Search(ctx context.Context, userID int, query string, limit int) ([]domain.NoteSearchResult, error)
+	GetTags(ctx context.Context, progressID int) ([]string, error)
+	SetTags(ctx context.Context, progressID int, tags []string) error
+}
+
+type noteRepo struct {
+	db *sql.DB
+}
+
+func NewNoteRepo(db *sql.DB) NoteRepo {
+	return &noteRepo{db: db}
+}
+
+func (r *noteRepo) GetByProgress(ctx context.Context, progressID int) ([]domain.Note, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, progress_id, user_id, kind, body, page, audiobook_time, created_at, updated_at
+		FROM progress_notes WHERE progress_id = $1
+		ORDER BY COALESCE(page, 0), audiobook_time NULLS FIRST, created_at
+	`, progressID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []domain.Note
+	for rows.Next() {
+		var note domain.Note
+		if err := rows.Scan(
+			&note.ID, &note.ProgressID, &note.UserID, &note.Kind, &note.Body,
+			&note.Page, &note.AudiobookTime, &note.CreatedAt, &note.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (r *noteRepo) GetByID(ctx context.Context, id int) (*domain.Note, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, progress_id, user_id, kind, body, page, audiobook_time, created_at, updated_at
+		FROM progress_notes WHERE id = $1
+	`, id)
+
+	var note domain.Note
+	err := row.Scan(
+		&note.ID, &note.ProgressID, &note.UserID, &note.Kind, &note.Body,
+		&note.Page, &note.AudiobookTime, &note.CreatedAt, &note.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *noteRepo) Create(ctx context.Context, note *domain.Note) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO progress_notes (progress_id, user_id, kind, body, page, audiobook_time)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, note.ProgressID, note.UserID, note.Kind, note.Body, note.Page, note.AudiobookTime).Scan(&id, &note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *noteRepo) Update(ctx context.Context, note *domain.Note) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE progress_notes
+		SET kind = $1, body = $2, page = $3, audiobook_time = $4
+		WHERE id = $5
+	`, note.Kind, note.Body, note.Page, note.AudiobookTime, note.ID)
+	return dbError(err)
+}
+
+func (r *noteRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM progress_notes WHERE id = $1", id)
+	return dbError(err)
+}
+
+func (r *noteRepo) Search(ctx context.Context, userID int, query string, limit int) ([]domain.NoteSearchResult, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT
+			n.id, n.progress_id, n.user_id, n.kind, n.body, n.page, n.audiobook_time, n.created_at, n.updated_at,
+			ts_headline('english', n.body, q, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'),
+			ts_rank(n.search_vector, q)
+		FROM progress_notes n, websearch_to_tsquery('english', $2) q
+		WHERE n.user_id = $1 AND n.search_vector @@ q
+		ORDER BY ts_rank(n.search_vector, q) DESC, n.updated_at DESC
+		LIMIT $3
+	`, userID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []domain.NoteSearchResult
+	for rows.Next() {
+		var result domain.NoteSearchResult
+		note := &result.Note
+		if err := rows.Scan(
+			&note.ID, &note.ProgressID, &note.UserID, &note.Kind, &note.Body,
+			&note.Page, &note.AudiobookTime, &note.CreatedAt, &note.UpdatedAt,
+			&result.Headline, &result.Rank,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (r *noteRepo) GetTags(ctx context.Context, progressID int) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT tag FROM progress_tags WHERE progress_id = $1 ORDER BY tag", progressID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (r *noteRepo) SetTags(ctx context.Context, progressID int, tags []string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM progress_tags WHERE progress_id = $1", progressID); err != nil {
+			return dbError(err)
+		}
+		for _, tag := range tags {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO progress_tags (progress_id, tag) VALUES ($1, $2)", progressID, tag); err != nil {
+				return dbError(err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -1,22 +1,26 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 )
 
 type ProgressRepo interface {
-	GetAll() ([]domain.Progress, error)
-	GetByID(id int) (*domain.Progress, error)
-	Create(progress *domain.Progress) (int, error)
-	Update(progress *domain.Progress) error
-	Delete(id int) error
-	GetByIDWithTotals(id int) (*domain.Progress, int, *domain.CustomDuration, error)
-	FilterProgress(filter ProgressFilter) ([]domain.Progress, error)
-	GetAllEnrichedByUser(userID int) ([]domain.EnrichedProgress, error)
+	GetAll(ctx context.Context) ([]domain.Progress, error)
+	GetByID(ctx context.Context, id int) (*domain.Progress, error)
+	Create(ctx context.Context, progress *domain.Progress) (int, error)
+	Update(ctx context.Context, progress *domain.Progress) error
+	Delete(ctx context.Context, id int) error
+	GetByIDWithTotals(ctx context.Context, id int) (*domain.Progress, int, *domain.CustomDuration, error)
+	UpdatePositions(ctx context.Context, progresses []*domain.Progress) error
+	FilterProgress(ctx context.Context, filter ProgressFilter) ([]domain.Progress, error)
+	GetAllEnrichedByUser(ctx context.Context, userID int) ([]domain.EnrichedProgress, error)
+	GetAllEnrichedByUserShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error)
 }
 
 type progressRepo struct {
@@ -27,9 +31,9 @@ func NewProgressRepo(db *sql.DB) ProgressRepo {
 	return &progressRepo{db: db}
 }
 
-func (r *progressRepo) GetAll() ([]domain.Progress, error) {
-	rows, err := r.db.Query(`
-		SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, created_at, updated_at
+func (r *progressRepo) GetAll(ctx context.Context) ([]domain.Progress, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, version, created_at, updated_at
 		FROM progress
 	`)
 	if err != nil {
@@ -41,7 +45,7 @@ func (r *progressRepo) GetAll() ([]domain.Progress, error) {
 		var progress domain.Progress
 		err := rows.Scan(
 			&progress.ID, &progress.UserID, &progress.BookID, &progress.AudiobookID,
-			&progress.BookPage, &progress.AudiobookTime, &progress.CreatedAt, &progress.UpdatedAt,
+			&progress.BookPage, &progress.AudiobookTime, &progress.Version, &progress.CreatedAt, &progress.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -53,17 +57,20 @@ func (r *progressRepo) GetAll() ([]domain.Progress, error) {
 	return progresses, nil
 }
 
-func (r *progressRepo) GetByID(id int) (*domain.Progress, error) {
-	row := r.db.QueryRow(`
-		SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, created_at, updated_at
+func (r *progressRepo) GetByID(ctx context.Context, id int) (*domain.Progress, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, version, created_at, updated_at
 		FROM progress WHERE id = $1
 	`, id)
 
 	var p domain.Progress
 	err := row.Scan(
 		&p.ID, &p.UserID, &p.BookID, &p.AudiobookID,
-		&p.BookPage, &p.AudiobookTime, &p.CreatedAt, &p.UpdatedAt,
+		&p.BookPage, &p.AudiobookTime, &p.Version, &p.CreatedAt, &p.UpdatedAt,
 	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -71,37 +78,46 @@ func (r *progressRepo) GetByID(id int) (*domain.Progress, error) {
 	return &p, nil
 }
 
-func (r *progressRepo) Create(progress *domain.Progress) (int, error) {
+func (r *progressRepo) Create(ctx context.Context, progress *domain.Progress) (int, error) {
 	var id int
-	err := r.db.QueryRow(`
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		INSERT INTO progress (user_id, book_id, audiobook_id, book_page, audiobook_time)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id
-	`, progress.UserID, progress.BookID, progress.AudiobookID, progress.BookPage, progress.AudiobookTime).Scan(&id)
+		RETURNING id, version
+	`, progress.UserID, progress.BookID, progress.AudiobookID, progress.BookPage, progress.AudiobookTime).Scan(&id, &progress.Version)
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	return id, nil
 }
 
-func (r *progressRepo) Update(progress *domain.Progress) error {
-	_, err := r.db.Exec(`
+// Update bumps the version on every write. When progress.Version is set it is
+// the version the caller read, and the write only lands if nobody has written
+// since; otherwise it returns ErrPreconditionFailed.
+func (r *progressRepo) Update(ctx context.Context, progress *domain.Progress) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
 		UPDATE progress
-		SET user_id = $1, book_id = $2, audiobook_id = $3, book_page = $4, audiobook_time = $5, updated_at = NOW()
-		WHERE id = $6
-	`, progress.UserID, progress.BookID, progress.AudiobookID, progress.BookPage, progress.AudiobookTime, progress.ID)
-	return err
+		SET user_id = $1, book_id = $2, audiobook_id = $3, book_page = $4, audiobook_time = $5,
+			version = version + 1, updated_at = NOW()
+		WHERE id = $6 AND ($7 = 0 OR version = $7)
+		RETURNING version
+	`, progress.UserID, progress.BookID, progress.AudiobookID, progress.BookPage, progress.AudiobookTime, progress.ID, progress.Version).
+		Scan(&progress.Version)
+	if err == sql.ErrNoRows {
+		return errors.ErrPreconditionFailed
+	}
+	return dbError(err)
 }
 
-func (r *progressRepo) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM progress WHERE id = $1", id)
-	return err
+func (r *progressRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM progress WHERE id = $1", id)
+	return dbError(err)
 }
 
-func (r *progressRepo) GetByIDWithTotals(id int) (*domain.Progress, int, *domain.CustomDuration, error) {
+func (r *progressRepo) GetByIDWithTotals(ctx context.Context, id int) (*domain.Progress, int, *domain.CustomDuration, error) {
 	query := `
     SELECT
-    	p.id, p.user_id, p.book_id, p.audiobook_id, p.book_page, p.audiobook_time, p.created_at, p.updated_at,
+    	p.id, p.user_id, p.book_id, p.audiobook_id, p.book_page, p.audiobook_time, p.version, p.created_at, p.updated_at,
     	COALESCE(b.total_pages, 0),
     	a.total_length
     FROM progress p
@@ -113,20 +129,46 @@ func (r *progressRepo) GetByIDWithTotals(id int) (*domain.Progress, int, *domain
 	var totalPages int
 	var totalLength *domain.CustomDuration
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&pr.ID, &pr.UserID, &pr.BookID, &pr.AudiobookID,
-		&pr.BookPage, &pr.AudiobookTime, &pr.CreatedAt, &pr.UpdatedAt,
+		&pr.BookPage, &pr.AudiobookTime, &pr.Version, &pr.CreatedAt, &pr.UpdatedAt,
 		&totalPages,
 		&totalLength,
 	)
+	if err == sql.ErrNoRows {
+		return nil, 0, nil, nil
+	}
 	if err != nil {
 		return nil, 0, nil, err
 	}
 	return &pr, totalPages, totalLength, nil
 }
 
-func (r *progressRepo) FilterProgress(filter ProgressFilter) ([]domain.Progress, error) {
-	query := "SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, created_at, updated_at FROM progress"
+// UpdatePositions writes the page and time of every entry in one transaction.
+// Each write is guarded by the version the caller read; if any entry changed
+// since, nothing is written and ErrPreconditionFailed is returned.
+func (r *progressRepo) UpdatePositions(ctx context.Context, progresses []*domain.Progress) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		for _, p := range progresses {
+			err := tx.QueryRowContext(ctx, `
+				UPDATE progress
+				SET book_page = $1, audiobook_time = $2, version = version + 1, updated_at = NOW()
+				WHERE id = $3 AND version = $4
+				RETURNING version
+			`, p.BookPage, p.AudiobookTime, p.ID, p.Version).Scan(&p.Version)
+			if err == sql.ErrNoRows {
+				return errors.ErrPreconditionFailed
+			}
+			if err != nil {
+				return dbError(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *progressRepo) FilterProgress(ctx context.Context, filter ProgressFilter) ([]domain.Progress, error) {
+	query := "SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, version, created_at, updated_at FROM progress"
 	var conditions []string
 	var args []interface{}
 	argIndex := 1
@@ -163,7 +205,7 @@ func (r *progressRepo) FilterProgress(filter ProgressFilter) ([]domain.Progress,
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +214,7 @@ func (r *progressRepo) FilterProgress(filter ProgressFilter) ([]domain.Progress,
 	var progresses []domain.Progress
 	for rows.Next() {
 		var progress domain.Progress
-		if err := rows.Scan(&progress.ID, &progress.UserID, &progress.BookID, &progress.AudiobookID, &progress.BookPage, &progress.AudiobookTime, &progress.CreatedAt, &progress.UpdatedAt); err != nil {
+		if err := rows.Scan(&progress.ID, &progress.UserID, &progress.BookID, &progress.AudiobookID, &progress.BookPage, &progress.AudiobookTime, &progress.Version, &progress.CreatedAt, &progress.UpdatedAt); err != nil {
 			return nil, err
 		}
 		progresses = append(progresses, progress)
@@ -180,20 +222,39 @@ func (r *progressRepo) FilterProgress(filter ProgressFilter) ([]domain.Progress,
 	return progresses, nil
 }
 
-func (r *progressRepo) GetAllEnrichedByUser(userID int) ([]domain.EnrichedProgress, error) {
-	query := `
-		SELECT
-			p.id, p.user_id, p.book_id, p.audiobook_id, p.book_page, p.audiobook_time, p.created_at, p.updated_at,
-			b.id, b.isbn, b.title, b.total_pages,
-			a.id, a.title, a.total_length
-		FROM progress p
-		LEFT JOIN books b ON p.book_id = b.id
-		LEFT JOIN audiobooks a ON p.audiobook_id = a.id
+const enrichedProgressSelect = `
+	SELECT
+		p.id, p.user_id, p.book_id, p.audiobook_id, p.book_page, p.audiobook_time, p.version, p.created_at, p.updated_at,
+		b.id, b.isbn, b.title, b.total_pages,
+		a.id, a.title, a.total_length
+	FROM progress p
+	LEFT JOIN books b ON p.book_id = b.id
+	LEFT JOIN audiobooks a ON p.audiobook_id = a.id
+`
+
+func (r *progressRepo) GetAllEnrichedByUser(ctx context.Context, userID int) ([]domain.EnrichedProgress, error) {
+	query := enrichedProgressSelect + `
+		WHERE p.user_id = $1
+		ORDER BY p.updated_at DESC
+	`
+	return r.queryEnriched(ctx, query, userID)
+}
+
+func (r *progressRepo) GetAllEnrichedByUserShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error) {
+	query := enrichedProgressSelect + `
 		WHERE p.user_id = $1
+		AND EXISTS (
+			SELECT 1 FROM shelf_items si
+			WHERE si.shelf_id = $2
+			AND (si.book_id = p.book_id OR si.audiobook_id = p.audiobook_id)
+		)
 		ORDER BY p.updated_at DESC
 	`
+	return r.queryEnriched(ctx, query, userID, shelfID)
+}
 
-	rows, err := r.db.Query(query, userID)
+func (r *progressRepo) queryEnriched(ctx context.Context, query string, args ...interface{}) ([]domain.EnrichedProgress, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -210,7 +271,7 @@ func (r *progressRepo) GetAllEnrichedByUser(userID int) ([]domain.EnrichedProgre
 
 		err := rows.Scan(
 			&e.Progress.ID, &e.Progress.UserID, &e.Progress.BookID, &e.Progress.AudiobookID,
-			&e.Progress.BookPage, &e.Progress.AudiobookTime, &e.Progress.CreatedAt, &e.Progress.UpdatedAt,
+			&e.Progress.BookPage, &e.Progress.AudiobookTime, &e.Progress.Version, &e.Progress.CreatedAt, &e.Progress.UpdatedAt,
 			&bookID, &bookISBN, &bookTitle, &bookTotalPages,
 			&audiobookID, &audiobookTitle, &audiobookTotalLength,
 		)
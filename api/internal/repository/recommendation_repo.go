@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"book_boy/api/internal/domain"
+)
+
+type RecommendationRepo interface {
+	GetCoReadCandidates(ctx context.Context, userID int, limit int) ([]domain.RecommendationCandidate, error)
+	GetSimilarTitleCandidates(ctx context.Context, userID int, limit int) ([]domain.RecommendationCandidate, error)
+	GetActiveUserIDs(ctx context.Context) ([]int, error)
+}
+
+type recommendationRepo struct {
+	db *sql.DB
+}
+
+func NewRecommendationRepo(db *sql.DB) RecommendationRepo {
+	return &recommendationRepo{db: db}
+}
+
+// GetCoReadCandidates finds books read by people who share books with the
+// user ("people who read X also read Y"). Each peer contributes to a book's
+// score by how many books they have in common with the user, so closer
+// readers count for more.
+func (r *recommendationRepo) GetCoReadCandidates(ctx context.Context, userID int, limit int) ([]domain.RecommendationCandidate, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		WITH mine AS (
+			SELECT DISTINCT book_id FROM progress WHERE user_id = $1 AND book_id IS NOT NULL
+		),
+		peers AS (
+			SELECT p.user_id, COUNT(DISTINCT p.book_id) AS overlap
+			FROM progress p
+			JOIN mine m ON m.book_id = p.book_id
+			WHERE p.user_id <> $1
+			GROUP BY p.user_id
+		)
+		SELECT p.book_id, COUNT(DISTINCT p.user_id), SUM(peers.overlap)::float8
+		FROM progress p
+		JOIN peers ON peers.user_id = p.user_id
+		WHERE p.book_id IS NOT NULL
+			AND p.book_id NOT IN (SELECT book_id FROM mine)
+		GROUP BY p.book_id
+		ORDER BY 3 DESC, 2 DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []domain.RecommendationCandidate
+	for rows.Next() {
+		var c domain.RecommendationCandidate
+		if err := rows.Scan(&c.BookID, &c.CoReaders, &c.CoScore); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// GetSimilarTitleCandidates uses the pg_trgm title index to find books whose
+// titles resemble something the user is already reading.
+func (r *recommendationRepo) GetSimilarTitleCandidates(ctx context.Context, userID int, limit int) ([]domain.RecommendationCandidate, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		WITH mine AS (
+			SELECT DISTINCT b.id, b.title
+			FROM progress p
+			JOIN books b ON b.id = p.book_id
+			WHERE p.user_id = $1
+		)
+		SELECT id, score, similar_to FROM (
+			SELECT DISTINCT ON (b.id) b.id, similarity(b.title, m.title)::float8 AS score, m.title AS similar_to
+			FROM books b
+			JOIN mine m ON b.title % m.title
+			WHERE b.id NOT IN (SELECT id FROM mine)
+			ORDER BY b.id, similarity(b.title, m.title) DESC
+		) best
+		ORDER BY score DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []domain.RecommendationCandidate
+	for rows.Next() {
+		var c domain.RecommendationCandidate
+		if err := rows.Scan(&c.BookID, &c.Similarity, &c.SimilarTo); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// GetActiveUserIDs lists users with at least one book in progress, which is
+// everyone the recommendation worker has something to compute for.
+func (r *recommendationRepo) GetActiveUserIDs(ctx context.Context) ([]int, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT DISTINCT user_id FROM progress WHERE book_id IS NOT NULL ORDER BY user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
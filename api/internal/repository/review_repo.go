@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"book_boy/api/internal/domain"
+)
+
+type ReviewRepo interface {
+	GetVisibleByBook(ctx context.Context, bookID int, viewerID int) ([]domain.Review, error)
+	GetVisibleByAudiobook(ctx context.Context, audiobookID int, viewerID int) ([]domain.Review, error)
+	UpsertBookReview(ctx context.Context, review *domain.Review) error
+	UpsertAudiobookReview(ctx context.Context, review *domain.Review) error
+	DeleteBookReview(ctx context.Context, userID int, bookID int) error
+	DeleteAudiobookReview(ctx context.Context, userID int, audiobookID int) error
+}
+
+type reviewRepo struct {
+	db *sql.DB
+}
+
+func NewReviewRepo(db *sql.DB) ReviewRepo {
+	return &reviewRepo{db: db}
+}
+
+// reviewVisibleSelect returns reviews the viewer ($2) is allowed to read:
+// their own, anyone whose review_privacy is public, and followers-only
+// reviews from people the viewer follows.
+const reviewVisibleSelect = `
+	SELECT r.id, r.user_id, u.username, r.book_id, r.audiobook_id, r.rating, r.body, r.spoiler, r.created_at, r.updated_at
+	FROM reviews r
+	JOIN users u ON u.id = r.user_id
+	WHERE (
+		r.user_id = $2
+		OR u.review_privacy = 'public'
+		OR (u.review_privacy = 'followers' AND EXISTS (
+			SELECT 1 FROM follows f WHERE f.follower_id = $2 AND f.followee_id = r.user_id
+		))
+	)
+`
+
+func (r *reviewRepo) GetVisibleByBook(ctx context.Context, bookID int, viewerID int) ([]domain.Review, error) {
+	return r.queryReviews(ctx, reviewVisibleSelect+" AND r.book_id = $1 ORDER BY r.updated_at DESC", bookID, viewerID)
+}
+
+func (r *reviewRepo) GetVisibleByAudiobook(ctx context.Context, audiobookID int, viewerID int) ([]domain.Review, error) {
+	return r.queryReviews(ctx, reviewVisibleSelect+" AND r.audiobook_id = $1 ORDER BY r.updated_at DESC", audiobookID, viewerID)
+}
+
+func (r *reviewRepo) queryReviews(ctx context.Context, query string, args ...interface{}) ([]domain.Review, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []domain.Review
+	for rows.Next() {
+		var review domain.Review
+		if err := rows.Scan(
+			&review.ID, &review.UserID, &review.Username, &review.BookID, &review.AudiobookID,
+			&review.Rating, &review.Body, &review.Spoiler, &review.CreatedAt, &review.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+func (r *reviewRepo) UpsertBookReview(ctx context.Context, review *domain.Review) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO reviews (user_id, book_id, rating, body, spoiler)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, book_id) WHERE book_id IS NOT NULL
+		DO UPDATE SET rating = EXCLUDED.rating, body = EXCLUDED.body, spoiler = EXCLUDED.spoiler
+		RETURNING id, created_at, updated_at
+	`, review.UserID, review.BookID, review.Rating, review.Body, review.Spoiler).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+}
+
+func (r *reviewRepo) UpsertAudiobookReview(ctx context.Context, review *domain.Review) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO reviews (user_id, audiobook_id, rating, body, spoiler)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, audiobook_id) WHERE audiobook_id IS NOT NULL
+		DO UPDATE SET rating = EXCLUDED.rating, body = EXCLUDED.body, spoiler = EXCLUDED.spoiler
+		RETURNING id, created_at, updated_at
+	`, review.UserID, review.AudiobookID, review.Rating, review.Body, review.Spoiler).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+}
+
+func (r *reviewRepo) DeleteBookReview(ctx context.Context, userID int, bookID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM reviews WHERE user_id = $1 AND book_id = $2", userID, bookID)
+	return dbError(err)
+}
+
+func (r *reviewRepo) DeleteAudiobookReview(ctx context.Context, userID int, audiobookID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM reviews WHERE user_id = $1 AND audiobook_id = $2", userID, audiobookID)
+	return dbError(err)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"book_boy/api/internal/domain"
+)
+
+const (
+	revisionEntityBook      = "book"
+	revisionEntityAudiobook = "audiobook"
+)
+
+// insertRevision appends the next version for an entity. Callers update the
+// entity row in the same transaction first, so its row lock serializes
+// concurrent edits and the version numbers never collide.
+func insertRevision(ctx context.Context, tx *sql.Tx, entityType string, entityID int, revision *domain.CatalogRevision) error {
+	changes := revision.Changes
+	if changes == nil {
+		changes = map[string]domain.FieldChange{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return dbError(err)
+	}
+	snapshotJSON, err := json.Marshal(revision.Snapshot)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO catalog_revisions (entity_type, entity_id, version, source, editor_id, reverted_to, changes, snapshot)
+		VALUES ($1, $2,
+			(SELECT COALESCE(MAX(version), 0) + 1 FROM catalog_revisions WHERE entity_type = $1 AND entity_id = $2),
+			$3, $4, $5, $6, $7)
+		RETURNING id, version, created_at
+	`, entityType, entityID, revision.Source, revision.EditorID, revision.RevertedTo, changesJSON, snapshotJSON).
+		Scan(&revision.ID, &revision.Version, &revision.CreatedAt)
+}
+
+const revisionSelect = `
+	SELECT id, version, source, editor_id, reverted_to, changes, snapshot, created_at
+	FROM catalog_revisions
+`
+
+func scanRevision(row rowScanner) (*domain.CatalogRevision, error) {
+	var revision domain.CatalogRevision
+	var changesJSON, snapshotJSON []byte
+	err := row.Scan(&revision.ID, &revision.Version, &revision.Source, &revision.EditorID, &revision.RevertedTo,
+		&changesJSON, &snapshotJSON, &revision.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changesJSON, &revision.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshotJSON, &revision.Snapshot); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func getRevisions(ctx context.Context, db DBTX, entityType string, entityID int) ([]domain.CatalogRevision, error) {
+	rows, err := db.QueryContext(ctx, revisionSelect+" WHERE entity_type = $1 AND entity_id = $2 ORDER BY version DESC", entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []domain.CatalogRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, rows.Err()
+}
+
+func getRevision(ctx context.Context, db DBTX, entityType string, entityID int, version int) (*domain.CatalogRevision, error) {
+	revision, err := scanRevision(db.QueryRowContext(ctx,
+		revisionSelect+" WHERE entity_type = $1 AND entity_id = $2 AND version = $3",
+		entityType, entityID, version,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return revision, nil
+}
+
+// lockedOrEmpty keeps a nil slice from being written as NULL into the NOT NULL column.
+func lockedOrEmpty(fields []string) []string {
+	if fields == nil {
+		return []string{}
+	}
+	return fields
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"book_boy/api/internal/domain"
+)
+
+type SearchRepo interface {
+	Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error)
+}
+
+type searchRepo struct {
+	db *sql.DB
+}
+
+func NewSearchRepo(db *sql.DB) SearchRepo {
+	return &searchRepo{db: db}
+}
+
+// Both formats share one ranking: best trigram similarity across title and
+// author, plus full-text rank, with an exact ISBN match always on top.
+// $1 is the raw query, $2 the tsquery, $3 the ILIKE prefix pattern (prefix mode
+// only), $4 the limit and $5 the format filter.
+const searchSQL = `
+	WITH q AS (
+		SELECT $1::text AS term,
+			CASE WHEN $2 = '' THEN plainto_tsquery('simple', $1) ELSE to_tsquery('simple', $2) END AS tsq
+	),
+	candidates AS (
+		SELECT 'book' AS type, b.id, b.title, COALESCE(b.author, '') AS author, b.isbn,
+			GREATEST(similarity(b.title, q.term), similarity(COALESCE(b.author, ''), q.term)) * 0.6
+				+ ts_rank(to_tsvector('simple', b.title || ' ' || COALESCE(b.author, '')), q.tsq) * 0.4
+				+ CASE WHEN b.isbn = q.term THEN 1 ELSE 0 END AS score
+		FROM books b, q
+		WHERE $5 IN ('all', 'book') AND (
+			b.title % q.term
+			OR b.author % q.term
+			OR to_tsvector('simple', b.title || ' ' || COALESCE(b.author, '')) @@ q.tsq
+			OR b.isbn = q.term
+			OR ($3 <> '' AND (b.title ILIKE $3 OR b.author ILIKE $3 OR b.isbn LIKE $3))
+		)
+		UNION ALL
+		SELECT 'audiobook', a.id, a.title, COALESCE(a.author, ''), '',
+			GREATEST(similarity(a.title, q.term), similarity(COALESCE(a.author, ''), q.term)) * 0.6
+				+ ts_rank(to_tsvector('simple', a.title || ' ' || COALESCE(a.author, '')), q.tsq) * 0.4
+		FROM audiobooks a, q
+		WHERE $5 IN ('all', 'audiobook') AND (
+			a.title % q.term
+			OR a.author % q.term
+			OR to_tsvector('simple', a.title || ' ' || COALESCE(a.author, '')) @@ q.tsq
+			OR ($3 <> '' AND (a.title ILIKE $3 OR a.author ILIKE $3))
+		)
+	)
+	SELECT type, id, title, author, isbn, score
+	FROM candidates
+	ORDER BY score DESC, title, id
+	LIMIT $4
+`
+
+func (r *searchRepo) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	prefixPattern := ""
+	if query.Prefix {
+		prefixPattern = escapeLike(query.Query) + "%"
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, searchSQL, query.Query, query.TSQuery, prefixPattern, query.Limit, string(query.Format))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []domain.SearchResult
+	for rows.Next() {
+		var result domain.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.Title, &result.Author, &result.ISBN, &result.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
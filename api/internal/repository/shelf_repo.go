@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"book_boy/api/internal/domain"
+)
+
+type ShelfRepo interface {
+	GetAllByUser(ctx context.Context, userID int) ([]domain.Shelf, error)
+	GetByID(ctx context.Context, id int) (*domain.Shelf, error)
+	Create(ctx context.Context, shelf *domain.Shelf) (int, error)
+	Update(ctx context.Context, shelf *domain.Shelf) error
+	Delete(ctx context.Context, id int) error
+	GetItems(ctx context.Context, shelfID int) ([]domain.ShelfItem, error)
+	AddItem(ctx context.Context, item *domain.ShelfItem) (int, error)
+	RemoveItem(ctx context.Context, shelfID int, itemID int) error
+	ReorderItems(ctx context.Context, shelfID int, itemIDs []int) error
+}
+
+type shelfRepo struct {
+	db *sql.DB
+}
+
+func NewShelfRepo(db *sql.DB) ShelfRepo {
+	return &shelfRepo{db: db}
+}
+
+func (r *shelfRepo) GetAllByUser(ctx context.Context, userID int) ([]domain.Shelf, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, name, COALESCE(description, ''), created_at, updated_at
+		FROM shelves WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shelves []domain.Shelf
+	for rows.Next() {
+		var shelf domain.Shelf
+		if err := rows.Scan(&shelf.ID, &shelf.UserID, &shelf.Name, &shelf.Description, &shelf.CreatedAt, &shelf.UpdatedAt); err != nil {
+			return nil, err
+		}
+		shelves = append(shelves, shelf)
+	}
+	return shelves, nil
+}
+
+func (r *shelfRepo) GetByID(ctx context.Context, id int) (*domain.Shelf, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, user_id, name, COALESCE(description, ''), created_at, updated_at
+		FROM shelves WHERE id = $1
+	`, id)
+
+	var shelf domain.Shelf
+	err := row.Scan(&shelf.ID, &shelf.UserID, &shelf.Name, &shelf.Description, &shelf.CreatedAt, &shelf.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shelf, nil
+}
+
+func (r *shelfRepo) Create(ctx context.Context, shelf *domain.Shelf) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"INSERT INTO shelves (user_id, name, description) VALUES ($1, $2, $3) RETURNING id",
+		shelf.UserID, shelf.Name, shelf.Description,
+	).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *shelfRepo) Update(ctx context.Context, shelf *domain.Shelf) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE shelves SET name = $1, description = $2 WHERE id = $3",
+		shelf.Name, shelf.Description, shelf.ID,
+	)
+	return dbError(err)
+}
+
+func (r *shelfRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM shelves WHERE id = $1", id)
+	return dbError(err)
+}
+
+func (r *shelfRepo) GetItems(ctx context.Context, shelfID int) ([]domain.ShelfItem, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT
+			si.id, si.shelf_id, si.book_id, si.audiobook_id, si.position, si.added_at,
+			b.isbn, b.title, b.total_pages,
+			a.title, a.total_length
+		FROM shelf_items si
+		LEFT JOIN books b ON si.book_id = b.id
+		LEFT JOIN audiobooks a ON si.audiobook_id = a.id
+		WHERE si.shelf_id = $1
+		ORDER BY si.position, si.id
+	`, shelfID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.ShelfItem
+	for rows.Next() {
+		var item domain.ShelfItem
+		var bookISBN, bookTitle, audiobookTitle *string
+		var bookTotalPages *int
+		var audiobookTotalLength *domain.CustomDuration
+
+		err := rows.Scan(
+			&item.ID, &item.ShelfID, &item.BookID, &item.AudiobookID, &item.Position, &item.AddedAt,
+			&bookISBN, &bookTitle, &bookTotalPages,
+			&audiobookTitle, &audiobookTotalLength,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if item.BookID != nil {
+			item.Book = &domain.Book{ID: *item.BookID}
+			if bookISBN != nil {
+				item.Book.ISBN = *bookISBN
+			}
+			if bookTitle != nil {
+				item.Book.Title = *bookTitle
+			}
+			if bookTotalPages != nil {
+				item.Book.TotalPages = *bookTotalPages
+			}
+		}
+
+		if item.AudiobookID != nil {
+			item.Audiobook = &domain.Audiobook{ID: *item.AudiobookID, TotalLength: audiobookTotalLength}
+			if audiobookTitle != nil {
+				item.Audiobook.Title = *audiobookTitle
+			}
+		}
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *shelfRepo) AddItem(ctx context.Context, item *domain.ShelfItem) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO shelf_items (shelf_id, book_id, audiobook_id, position)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position) + 1, 0) FROM shelf_items WHERE shelf_id = $1))
+		RETURNING id, position
+	`, item.ShelfID, item.BookID, item.AudiobookID).Scan(&id, &item.Position)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
+
+func (r *shelfRepo) RemoveItem(ctx context.Context, shelfID int, itemID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM shelf_items WHERE id = $1 AND shelf_id = $2", itemID, shelfID)
+	return dbError(err)
+}
+
+func (r *shelfRepo) ReorderItems(ctx context.Context, shelfID int, itemIDs []int) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		for position, itemID := range itemIDs {
+			result, err := tx.ExecContext(ctx,
+				"UPDATE shelf_items SET position = $1 WHERE id = $2 AND shelf_id = $3",
+				position, itemID, shelfID,
+			)
+			if err != nil {
+				return dbError(err)
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return dbError(err)
+			}
+			if affected == 0 {
+				return fmt.Errorf("shelf item %d not found on shelf %d", itemID, shelfID)
+			}
+		}
+
+		return nil
+	})
+}
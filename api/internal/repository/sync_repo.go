@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"book_boy/api/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// SyncResolveFunc decides the new state of the locked entries. It returns
+// only the entries that changed.
+type SyncResolveFunc func(targets []domain.SyncTarget) ([]domain.Progress, error)
+
+type SyncRepo interface {
+	ApplyMutations(ctx context.Context, userID int, progressIDs []int, resolve SyncResolveFunc) error
+	GetChangesSince(ctx context.Context, userID int, horizon int64) (*domain.SyncChanges, error)
+}
+
+type syncRepo struct {
+	db *sql.DB
+}
+
+func NewSyncRepo(db *sql.DB) SyncRepo {
+	return &syncRepo{db: db}
+}
+
+// ApplyMutations locks the user's entries among progressIDs, passes them to
+// resolve and writes back what it returns in the same transaction, so
+// concurrent syncs and PATCHes of those entries wait for this one.
+func (r *syncRepo) ApplyMutations(ctx context.Context, userID int, progressIDs []int, resolve SyncResolveFunc) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT p.id, p.user_id, p.book_id, p.audiobook_id, p.book_page, p.audiobook_time, p.version, p.created_at, p.updated_at,
+				COALESCE(b.total_pages, 0), a.total_length
+			FROM progress p
+			LEFT JOIN books b ON p.book_id = b.id
+			LEFT JOIN audiobooks a ON p.audiobook_id = a.id
+			WHERE p.user_id = $1 AND p.id = ANY($2)
+			ORDER BY p.id
+			FOR UPDATE OF p
+		`, userID, pq.Array(progressIDs))
+		if err != nil {
+			return dbError(err)
+		}
+
+		var targets []domain.SyncTarget
+		for rows.Next() {
+			var target domain.SyncTarget
+			p := &target.Progress
+			if err := rows.Scan(&p.ID, &p.UserID, &p.BookID, &p.AudiobookID, &p.BookPage, &p.AudiobookTime, &p.Version, &p.CreatedAt, &p.UpdatedAt,
+				&target.TotalPages, &target.TotalLength); err != nil {
+				rows.Close()
+				return dbError(err)
+			}
+			targets = append(targets, target)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return dbError(err)
+		}
+
+		changed, err := resolve(targets)
+		if err != nil {
+			return dbError(err)
+		}
+		for i := range changed {
+			p := &changed[i]
+			if err := tx.QueryRowContext(ctx, `
+				UPDATE progress
+				SET book_page = $1, audiobook_time = $2, version = version + 1, updated_at = NOW()
+				WHERE id = $3
+				RETURNING version, updated_at
+			`, p.BookPage, p.AudiobookTime, p.ID).Scan(&p.Version, &p.UpdatedAt); err != nil {
+				return dbError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetChangesSince returns the user's entries written by transactions from
+// horizon on, and the ids of entries deleted by them. With horizon 0 it returns
+// every entry and no deletions, since a client without a token holds nothing to
+// delete.
+//
+// The returned horizon is the oldest transaction still running before the rows
+// are read. Everything older has finished, so whatever a running transaction
+// commits later is picked up by the next call even if its rows were stamped
+// before ones already returned. Rows near the horizon can come back twice,
+// which clients absorb since each carries its full state.
+func (r *syncRepo) GetChangesSince(ctx context.Context, userID int, horizon int64) (*domain.SyncChanges, error) {
+	changes := &domain.SyncChanges{Progress: []domain.Progress{}, DeletedIDs: []int{}}
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+	`).Scan(&changes.Horizon); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, book_id, audiobook_id, book_page, audiobook_time, version, created_at, updated_at
+		FROM progress
+		WHERE user_id = $1 AND sync_xid >= $2::text::xid8
+		ORDER BY sync_seq
+	`, userID, horizon)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.Progress
+		if err := rows.Scan(&p.ID, &p.UserID, &p.BookID, &p.AudiobookID, &p.BookPage, &p.AudiobookTime, &p.Version, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		changes.Progress = append(changes.Progress, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if horizon == 0 {
+		return changes, nil
+	}
+
+	deleted, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT progress_id
+		FROM progress_deletions
+		WHERE user_id = $1 AND sync_xid >= $2::text::xid8
+		ORDER BY sync_seq
+	`, userID, horizon)
+	if err != nil {
+		return nil, err
+	}
+	defer deleted.Close()
+
+	for deleted.Next() {
+		var id int
+		if err := deleted.Scan(&id); err != nil {
+			return nil, err
+		}
+		changes.DeletedIDs = append(changes.DeletedIDs, id)
+	}
+	return changes, deleted.Err()
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/db"
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/logging"
+)
+
+// testDB connects to the database named by TEST_DATABASE_URL, which must hold
+// the init.sql schema (docker compose's db does), and brings it up to date.
+// Without it the test is skipped.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	database, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := db.RunMigrations(database, logging.Discard); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return database
+}
+
+// A transaction that stamps its row first but commits after a later one must
+// still reach a client that synced in between.
+func TestSyncRepo_GetChangesSince_OverlappingTransactions(t *testing.T) {
+	database := testDB(t)
+	ctx := context.Background()
+	repo := NewSyncRepo(database)
+
+	var userID, audiobookID int
+	name := fmt.Sprintf("sync-test-%d", time.Now().UnixNano())
+	if err := database.QueryRowContext(ctx, `
+		INSERT INTO users (username, email, password_hash) VALUES ($1, $1 || '@example.com', 'x') RETURNING id
+	`, name).Scan(&userID); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		database.Exec(`DELETE FROM users WHERE id = $1`, userID)
+		database.Exec(`DELETE FROM progress_deletions WHERE user_id = $1`, userID)
+	})
+	if err := database.QueryRowContext(ctx, `
+		INSERT INTO audiobooks (title, total_length) VALUES ($1, '10:00:00') RETURNING id
+	`, name).Scan(&audiobookID); err != nil {
+		t.Fatalf("failed to create audiobook: %v", err)
+	}
+	t.Cleanup(func() { database.Exec(`DELETE FROM audiobooks WHERE id = $1`, audiobookID) })
+
+	var slowID, fastID int
+	for _, id := range []*int{&slowID, &fastID} {
+		if err := database.QueryRowContext(ctx, `
+			INSERT INTO progress (user_id, audiobook_id, audiobook_time) VALUES ($1, $2, '00:10:00') RETURNING id
+		`, userID, audiobookID).Scan(id); err != nil {
+			t.Fatalf("failed to create progress: %v", err)
+		}
+	}
+
+	initial, err := repo.GetChangesSince(ctx, userID, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	slow, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	defer slow.Rollback()
+	if _, err := slow.ExecContext(ctx, `UPDATE progress SET audiobook_time = '01:00:00' WHERE id = $1`, slowID); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+
+	fast, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := fast.ExecContext(ctx, `UPDATE progress SET audiobook_time = '02:00:00' WHERE id = $1`, fastID); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := fast.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	between, err := repo.GetChangesSince(ctx, userID, initial.Horizon)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !containsProgress(between.Progress, fastID) || containsProgress(between.Progress, slowID) {
+		t.Fatalf("expected only the committed update, got %+v", between.Progress)
+	}
+
+	if err := slow.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	after, err := repo.GetChangesSince(ctx, userID, between.Horizon)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !containsProgress(after.Progress, slowID) {
+		t.Fatalf("expected the late commit after token %d, got %+v", between.Horizon, after.Progress)
+	}
+}
+
+func containsProgress(progress []domain.Progress, id int) bool {
+	for _, p := range progress {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is what a repo query runs on: the pool, or the transaction of the unit
+// of work its ctx belongs to.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// TxManager runs a unit of work in a single transaction. Repos called with the
+// ctx handed to fn share that transaction, so writes across several repos
+// commit or roll back together.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) TxManager {
+	return &txManager{db: db}
+}
+
+type unitOfWorkKey struct{}
+
+type unitOfWork struct {
+	tx          *sql.Tx
+	afterCommit []func(ctx context.Context)
+}
+
+func unitOfWorkFrom(ctx context.Context) *unitOfWork {
+	uow, _ := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	return uow
+}
+
+// WithinTx commits when fn returns nil and rolls back otherwise. Called inside
+// another unit of work it joins it, leaving the outermost call to commit.
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if unitOfWorkFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	uow := &unitOfWork{tx: tx}
+	if err := fn(context.WithValue(ctx, unitOfWorkKey{}, uow)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, hook := range uow.afterCommit {
+		hook(ctx)
+	}
+	return nil
+}
+
+// AfterCommit defers fn until ctx's unit of work commits, and drops it if the
+// work rolls back. Outside a unit of work fn runs straight away. Side effects
+// others can see, such as events and pushes, go through here so they never
+// announce rows that end up rolled back.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if uow := unitOfWorkFrom(ctx); uow != nil {
+		uow.afterCommit = append(uow.afterCommit, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// conn returns the transaction of ctx's unit of work, or db outside one.
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if uow := unitOfWorkFrom(ctx); uow != nil {
+		return uow.tx
+	}
+	return db
+}
+
+// withTx runs fn in ctx's unit of work, or in a transaction of its own that
+// commits when fn returns nil.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if uow := unitOfWorkFrom(ctx); uow != nil {
+		return fn(uow.tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,18 +1,20 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 
 	"book_boy/api/internal/domain"
 )
 
 type UserRepo interface {
-	GetAll() ([]domain.User, error)
-	GetByID(id int) (*domain.User, error)
-	GetByEmail(email string) (*domain.User, error)
-	Create(user *domain.User) (int, error)
-	Update(user *domain.User) error
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]domain.User, error)
+	GetByID(ctx context.Context, id int) (*domain.User, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	Create(ctx context.Context, user *domain.User) (int, error)
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, id int) error
+	UpdatePrivacy(ctx context.Context, id int, settings *domain.PrivacySettings) error
 }
 
 type userRepo struct {
@@ -23,8 +25,8 @@ func NewUserRepo(db *sql.DB) UserRepo {
 	return &userRepo{db: db}
 }
 
-func (r *userRepo) GetAll() ([]domain.User, error) {
-	rows, err := r.db.Query("SELECT id, username, email, password_hash, created_at FROM users")
+func (r *userRepo) GetAll(ctx context.Context) ([]domain.User, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT id, username, email, password_hash, review_privacy, profile_privacy, progress_privacy, created_at FROM users")
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +35,7 @@ func (r *userRepo) GetAll() ([]domain.User, error) {
 	var users []domain.User
 	for rows.Next() {
 		var user domain.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt); err != nil {
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.ReviewPrivacy, &user.ProfilePrivacy, &user.ProgressPrivacy, &user.CreatedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, user)
@@ -41,10 +43,10 @@ func (r *userRepo) GetAll() ([]domain.User, error) {
 	return users, nil
 }
 
-func (r *userRepo) GetByID(id int) (*domain.User, error) {
+func (r *userRepo) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow("SELECT id, username, email, password_hash, created_at FROM users WHERE id = $1", id).
-		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, username, email, password_hash, review_privacy, profile_privacy, progress_privacy, created_at FROM users WHERE id = $1", id).
+		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.ReviewPrivacy, &user.ProfilePrivacy, &user.ProgressPrivacy, &user.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -54,10 +56,10 @@ func (r *userRepo) GetByID(id int) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *userRepo) GetByEmail(email string) (*domain.User, error) {
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow("SELECT id, username, email, password_hash, created_at FROM users WHERE email = $1", email).
-		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, username, email, password_hash, review_privacy, profile_privacy, progress_privacy, created_at FROM users WHERE email = $1", email).
+		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.ReviewPrivacy, &user.ProfilePrivacy, &user.ProgressPrivacy, &user.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -67,27 +69,35 @@ func (r *userRepo) GetByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *userRepo) Create(user *domain.User) (int, error) {
+func (r *userRepo) Create(ctx context.Context, user *domain.User) (int, error) {
 	var id int
-	err := r.db.QueryRow(
+	err := conn(ctx, r.db).QueryRowContext(ctx,
 		"INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id",
 		user.Username, user.Email, user.PasswordHash,
 	).Scan(&id)
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	return id, nil
 }
 
-func (r *userRepo) Update(user *domain.User) error {
-	_, err := r.db.Exec(
+func (r *userRepo) Update(ctx context.Context, user *domain.User) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
 		"UPDATE users SET username = $1, email = $2 WHERE id = $3",
 		user.Username, user.Email, user.ID,
 	)
-	return err
+	return dbError(err)
 }
 
-func (r *userRepo) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM users WHERE id = $1", id)
-	return err
+func (r *userRepo) Delete(ctx context.Context, id int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	return dbError(err)
+}
+
+func (r *userRepo) UpdatePrivacy(ctx context.Context, id int, settings *domain.PrivacySettings) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE users SET profile_privacy = $1, progress_privacy = $2, review_privacy = $3 WHERE id = $4",
+		settings.ProfilePrivacy, settings.ProgressPrivacy, settings.ReviewPrivacy, id,
+	)
+	return dbError(err)
 }
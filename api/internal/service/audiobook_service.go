@@ -2,6 +2,7 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
 	"book_boy/api/internal/infra"
 	"context"
@@ -10,79 +11,151 @@ import (
 )
 
 type AudiobookService interface {
-	GetAll() ([]domain.Audiobook, error)
-	GetByID(id int) (*domain.Audiobook, error)
-	Create(audiobook *domain.Audiobook) (int, error)
-	Update(audiobook *domain.Audiobook) error
-	GetSimilarTitles(title string) ([]domain.Audiobook, error)
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]domain.Audiobook, error)
+	GetByID(ctx context.Context, id int) (*domain.Audiobook, error)
+	Create(ctx context.Context, audiobook *domain.Audiobook) (int, error)
+	Update(ctx context.Context, audiobook *domain.Audiobook, editorID int) error
+	GetHistory(ctx context.Context, audiobookID int) ([]domain.CatalogRevision, error)
+	Revert(ctx context.Context, audiobookID int, version int, editorID int) (*domain.Audiobook, error)
+	GetSimilarTitles(ctx context.Context, title string) ([]domain.Audiobook, error)
+	Delete(ctx context.Context, id int) error
+}
+
+const audiobookCacheTTL = 10 * time.Minute
+
+func audiobookCacheKey(id int) string {
+	return fmt.Sprintf("audiobook:%d", id)
 }
 
 type audiobookService struct {
 	repo  repository.AudiobookRepo
-	cache *infra.Cache
+	cache infra.Cache
+	views ProgressViews
 }
 
-func NewAudiobookService(repo repository.AudiobookRepo, cache *infra.Cache) AudiobookService {
-	return &audiobookService{repo: repo, cache: cache}
+func NewAudiobookService(repo repository.AudiobookRepo, cache infra.Cache, views ProgressViews) AudiobookService {
+	return &audiobookService{repo: repo, cache: cache, views: views}
 }
 
-func (s *audiobookService) GetAll() ([]domain.Audiobook, error) {
-	return s.repo.GetAll()
+func (s *audiobookService) GetAll(ctx context.Context) ([]domain.Audiobook, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *audiobookService) GetByID(id int) (*domain.Audiobook, error) {
-	if s.cache != nil {
-		ctx := context.Background()
-		cacheKey := fmt.Sprintf("audiobook:%d", id)
+func (s *audiobookService) GetByID(ctx context.Context, id int) (*domain.Audiobook, error) {
+	return infra.GetOrLoad(ctx, s.cache, audiobookCacheKey(id), audiobookCacheTTL, func(ctx context.Context) (*domain.Audiobook, error) {
+		return s.repo.GetByID(ctx, id)
+	})
+}
 
-		var audiobook domain.Audiobook
-		if err := s.cache.Get(ctx, cacheKey, &audiobook); err == nil {
-			return &audiobook, nil
-		}
+func (s *audiobookService) Create(ctx context.Context, audiobook *domain.Audiobook) (int, error) {
+	if err := audiobook.Validate(); err != nil {
+		return 0, err
 	}
+	return s.repo.Create(ctx, audiobook)
+}
 
-	result, err := s.repo.GetByID(id)
+func (s *audiobookService) Update(ctx context.Context, audiobook *domain.Audiobook, editorID int) error {
+	if err := audiobook.Validate(); err != nil {
+		return err
+	}
+	current, err := s.repo.GetByID(ctx, audiobook.ID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if current == nil {
+		return errors.ErrNotFound
 	}
 
-	if s.cache != nil {
-		s.cache.Set(context.Background(), fmt.Sprintf("audiobook:%d", id), result, 10*time.Minute)
+	audiobook.FillOmitted(current)
+	changes := domain.DiffFields(current.Fields(), audiobook.Fields())
+	audiobook.LockedFields = domain.MergeLockedFields(current.LockedFields, changes)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	revision := &domain.CatalogRevision{
+		Source:   domain.RevisionUser,
+		EditorID: &editorID,
+		Changes:  changes,
+		Snapshot: audiobook.Fields(),
 	}
-	return result, nil
+	return s.save(ctx, audiobook, revision)
 }
 
-func (s *audiobookService) Create(audiobook *domain.Audiobook) (int, error) {
-	if err := audiobook.Validate(); err != nil {
-		return 0, err
+func (s *audiobookService) GetHistory(ctx context.Context, audiobookID int) ([]domain.CatalogRevision, error) {
+	audiobook, err := s.repo.GetByID(ctx, audiobookID)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.Create(audiobook)
+	if audiobook == nil {
+		return nil, errors.ErrNotFound
+	}
+	return s.repo.GetRevisions(ctx, audiobookID)
 }
 
-func (s *audiobookService) Update(audiobook *domain.Audiobook) error {
-	if err := audiobook.Validate(); err != nil {
-		return err
+func (s *audiobookService) Revert(ctx context.Context, audiobookID int, version int, editorID int) (*domain.Audiobook, error) {
+	current, err := s.repo.GetByID(ctx, audiobookID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, errors.ErrNotFound
+	}
+	target, err := s.repo.GetRevision(ctx, audiobookID, version)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, errors.ErrNotFound
 	}
-	if err := s.repo.Update(audiobook); err != nil {
+
+	reverted := *current
+	reverted.ApplyFields(target.Snapshot)
+	if err := reverted.Validate(); err != nil {
+		return nil, err
+	}
+	changes := domain.DiffFields(current.Fields(), reverted.Fields())
+	if len(changes) == 0 {
+		return current, nil
+	}
+	reverted.LockedFields = domain.MergeLockedFields(current.LockedFields, changes)
+
+	revision := &domain.CatalogRevision{
+		Source:     domain.RevisionRevert,
+		EditorID:   &editorID,
+		RevertedTo: &version,
+		Changes:    changes,
+		Snapshot:   reverted.Fields(),
+	}
+	if err := s.save(ctx, &reverted, revision); err != nil {
+		return nil, err
+	}
+	return &reverted, nil
+}
+
+func (s *audiobookService) save(ctx context.Context, audiobook *domain.Audiobook, revision *domain.CatalogRevision) error {
+	if err := s.repo.Update(ctx, audiobook, revision); err != nil {
 		return err
 	}
+	audiobookChanged(ctx, s.views, audiobook.ID)
 	if s.cache != nil {
-		s.cache.Delete(context.Background(), fmt.Sprintf("audiobook:%d", audiobook.ID))
+		s.cache.Delete(context.WithoutCancel(ctx), audiobookCacheKey(audiobook.ID))
 	}
 	return nil
 }
 
-func (s *audiobookService) Delete(id int) error {
-	if err := s.repo.Delete(id); err != nil {
+func (s *audiobookService) Delete(ctx context.Context, id int) error {
+	// Whoever tracks it has to be found while the entries still point here.
+	audiobookChanged(ctx, s.views, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
 		return err
 	}
 	if s.cache != nil {
-		s.cache.Delete(context.Background(), fmt.Sprintf("audiobook:%d", id))
+		s.cache.Delete(context.WithoutCancel(ctx), audiobookCacheKey(id))
 	}
 	return nil
 }
 
-func (s *audiobookService) GetSimilarTitles(title string) ([]domain.Audiobook, error) {
-	return s.repo.GetSimilarTitles(title)
+func (s *audiobookService) GetSimilarTitles(ctx context.Context, title string) ([]domain.Audiobook, error) {
+	return s.repo.GetSimilarTitles(ctx, title)
 }
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
@@ -15,13 +17,14 @@ type mockAudiobookRepo struct {
 	LastUpdated  *domain.Audiobook
 	LastDeleted  int
 	GetByIDInput int
+	Revisions    map[int][]domain.CatalogRevision
 }
 
-func (m *mockAudiobookRepo) GetAll() ([]domain.Audiobook, error) {
+func (m *mockAudiobookRepo) GetAll(ctx context.Context) ([]domain.Audiobook, error) {
 	return m.Audiobooks, m.Err
 }
 
-func (m *mockAudiobookRepo) GetByID(id int) (*domain.Audiobook, error) {
+func (m *mockAudiobookRepo) GetByID(ctx context.Context, id int) (*domain.Audiobook, error) {
 	m.GetByIDInput = id
 	if m.Err != nil {
 		return nil, m.Err
@@ -34,7 +37,7 @@ func (m *mockAudiobookRepo) GetByID(id int) (*domain.Audiobook, error) {
 	return nil, nil
 }
 
-func (m *mockAudiobookRepo) Create(audiobook *domain.Audiobook) (int, error) {
+func (m *mockAudiobookRepo) Create(ctx context.Context, audiobook *domain.Audiobook) (int, error) {
 	m.LastCreated = audiobook
 	if m.Err != nil {
 		return 0, m.Err
@@ -42,17 +45,43 @@ func (m *mockAudiobookRepo) Create(audiobook *domain.Audiobook) (int, error) {
 	return 123, nil
 }
 
-func (m *mockAudiobookRepo) Update(audiobook *domain.Audiobook) error {
+func (m *mockAudiobookRepo) Update(ctx context.Context, audiobook *domain.Audiobook, revision *domain.CatalogRevision) error {
 	m.LastUpdated = audiobook
-	return m.Err
+	if m.Err != nil {
+		return m.Err
+	}
+	for i := range m.Audiobooks {
+		if m.Audiobooks[i].ID == audiobook.ID {
+			m.Audiobooks[i] = *audiobook
+		}
+	}
+	if m.Revisions == nil {
+		m.Revisions = make(map[int][]domain.CatalogRevision)
+	}
+	revision.Version = len(m.Revisions[audiobook.ID]) + 1
+	m.Revisions[audiobook.ID] = append(m.Revisions[audiobook.ID], *revision)
+	return nil
+}
+
+func (m *mockAudiobookRepo) GetRevisions(ctx context.Context, audiobookID int) ([]domain.CatalogRevision, error) {
+	return m.Revisions[audiobookID], m.Err
 }
 
-func (m *mockAudiobookRepo) Delete(id int) error {
+func (m *mockAudiobookRepo) GetRevision(ctx context.Context, audiobookID int, version int) (*domain.CatalogRevision, error) {
+	for _, revision := range m.Revisions[audiobookID] {
+		if revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, m.Err
+}
+
+func (m *mockAudiobookRepo) Delete(ctx context.Context, id int) error {
 	m.LastDeleted = id
 	return m.Err
 }
 
-func (m *mockAudiobookRepo) GetSimilarTitles(title string) ([]domain.Audiobook, error) {
+func (m *mockAudiobookRepo) GetSimilarTitles(ctx context.Context, title string) ([]domain.Audiobook, error) {
 	//TODO IMPLEMENT
 	return nil, nil
 }
@@ -69,9 +98,9 @@ func TestAudiobookService_GetAll(t *testing.T) {
 	}
 
 	mockRepo := &mockAudiobookRepo{Audiobooks: mockData}
-	svc := NewAudiobookService(mockRepo, nil)
+	svc := NewAudiobookService(mockRepo, nil, nil)
 
-	result, err := svc.GetAll()
+	result, err := svc.GetAll(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -79,7 +108,7 @@ func TestAudiobookService_GetAll(t *testing.T) {
 		t.Fatalf("expected %d books, got %d", len(mockData), len(result))
 	}
 	for i := range result {
-		if result[i] != mockData[i] {
+		if !reflect.DeepEqual(result[i], mockData[i]) {
 			t.Errorf("mismatch at index %d: expected %+v, got %+v", i, mockData[i], result[i])
 		}
 	}
@@ -90,9 +119,9 @@ func TestAudiobookService_GetByID(t *testing.T) {
 	mockRepo := &mockAudiobookRepo{
 		Audiobooks: []domain.Audiobook{{ID: 1, Title: "One", TotalLength: &domain.CustomDuration{Duration: d1}}},
 	}
-	svc := NewAudiobookService(mockRepo, nil)
+	svc := NewAudiobookService(mockRepo, nil, nil)
 
-	result, err := svc.GetByID(1)
+	result, err := svc.GetByID(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -103,12 +132,12 @@ func TestAudiobookService_GetByID(t *testing.T) {
 
 func TestAudiobookService_Create(t *testing.T) {
 	mockRepo := &mockAudiobookRepo{}
-	svc := NewAudiobookService(mockRepo, nil)
+	svc := NewAudiobookService(mockRepo, nil, nil)
 
 	duration := &domain.CustomDuration{}
 	duration.Duration = 3600000000000
 	audiobook := &domain.Audiobook{Title: "New Book", TotalLength: duration}
-	id, err := svc.Create(audiobook)
+	id, err := svc.Create(context.Background(), audiobook)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -121,25 +150,33 @@ func TestAudiobookService_Create(t *testing.T) {
 }
 
 func TestAudiobookService_Update(t *testing.T) {
-	mockRepo := &mockAudiobookRepo{}
-	svc := NewAudiobookService(mockRepo, nil)
-
 	d1 := time.Hour + 45*time.Minute + 30*time.Second
+	mockRepo := &mockAudiobookRepo{Audiobooks: []domain.Audiobook{
+		{ID: 1, Title: "Old Book", TotalLength: &domain.CustomDuration{Duration: d1}, Author: "Jane Doe"},
+	}}
+	svc := NewAudiobookService(mockRepo, nil, nil)
+
 	audiobook := &domain.Audiobook{ID: 1, Title: "Updated Book", TotalLength: &domain.CustomDuration{Duration: d1}}
-	err := svc.Update(audiobook)
+	err := svc.Update(context.Background(), audiobook, 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if mockRepo.LastUpdated != audiobook {
 		t.Errorf("Update was not called with correct data")
 	}
+	if audiobook.Author != "Jane Doe" {
+		t.Errorf("expected omitted author kept, got %q", audiobook.Author)
+	}
+	if !reflect.DeepEqual(audiobook.LockedFields, []string{"title"}) {
+		t.Errorf("expected only title locked, got %v", audiobook.LockedFields)
+	}
 }
 
 func TestAudiobookService_Delete(t *testing.T) {
 	mockRepo := &mockAudiobookRepo{}
-	svc := NewAudiobookService(mockRepo, nil)
+	svc := NewAudiobookService(mockRepo, nil, nil)
 
-	err := svc.Delete(99)
+	err := svc.Delete(context.Background(), 99)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -150,21 +187,21 @@ func TestAudiobookService_Delete(t *testing.T) {
 
 func TestAudiobookService_Errors(t *testing.T) {
 	mockRepo := &mockAudiobookRepo{Err: errors.New("db error")}
-	svc := NewAudiobookService(mockRepo, nil)
+	svc := NewAudiobookService(mockRepo, nil, nil)
 
-	if _, err := svc.GetAll(); err == nil {
+	if _, err := svc.GetAll(context.Background()); err == nil {
 		t.Error("expected GetAll to return error")
 	}
-	if _, err := svc.GetByID(1); err == nil {
+	if _, err := svc.GetByID(context.Background(), 1); err == nil {
 		t.Error("expected GetByID to return error")
 	}
-	if _, err := svc.Create(&domain.Audiobook{}); err == nil {
+	if _, err := svc.Create(context.Background(), &domain.Audiobook{}); err == nil {
 		t.Error("expected Create to return error")
 	}
-	if err := svc.Update(&domain.Audiobook{}); err == nil {
+	if err := svc.Update(context.Background(), &domain.Audiobook{}, 1); err == nil {
 		t.Error("expected Update to return error")
 	}
-	if err := svc.Delete(1); err == nil {
+	if err := svc.Delete(context.Background(), 1); err == nil {
 		t.Error("expected Delete to return error")
 	}
 }
@@ -177,9 +214,9 @@ func TestAudiobookService_GetSimilarTitles(t *testing.T) {
 			{ID: 2, Title: "The Catcher in the Rye", TotalLength: &domain.CustomDuration{Duration: d1}},
 		},
 	}
-	svc := NewAudiobookService(mockRepo, nil)
+	svc := NewAudiobookService(mockRepo, nil, nil)
 
-	audiobooks, err := svc.GetSimilarTitles("Great")
+	audiobooks, err := svc.GetSimilarTitles(context.Background(), "Great")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
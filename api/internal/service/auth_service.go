@@ -2,8 +2,10 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
-	"errors"
+	"context"
+
 	"fmt"
 	"os"
 	"time"
@@ -13,10 +15,10 @@ import (
 )
 
 type AuthService interface {
-	Register(req *domain.RegisterRequest) (*domain.User, error)
-	Login(req *domain.LoginRequest) (string, *domain.User, error)
-	ValidateToken(tokenString string) (*jwt.Token, error)
-	GetUserFromToken(tokenString string) (*domain.User, error)
+	Register(ctx context.Context, req *domain.RegisterRequest) (*domain.User, error)
+	Login(ctx context.Context, req *domain.LoginRequest) (string, *domain.User, error)
+	ValidateToken(ctx context.Context, tokenString string) (*jwt.Token, error)
+	GetUserFromToken(ctx context.Context, tokenString string) (*domain.User, error)
 }
 
 type authService struct {
@@ -27,13 +29,13 @@ func NewAuthService(userRepo repository.UserRepo) AuthService {
 	return &authService{userRepo: userRepo}
 }
 
-func (s *authService) Register(req *domain.RegisterRequest) (*domain.User, error) {
-	existingUser, err := s.userRepo.GetByEmail(req.Email)
+func (s *authService) Register(ctx context.Context, req *domain.RegisterRequest) (*domain.User, error) {
+	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, errors.Conflict("user with this email already exists")
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -42,13 +44,16 @@ func (s *authService) Register(req *domain.RegisterRequest) (*domain.User, error
 	}
 
 	user := &domain.User{
-		Username:     req.Username,
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		CreatedAt:    time.Now(),
+		Username:        req.Username,
+		Email:           req.Email,
+		PasswordHash:    string(hashedPassword),
+		ReviewPrivacy:   domain.PrivacyPublic,
+		ProfilePrivacy:  domain.PrivacyPublic,
+		ProgressPrivacy: domain.PrivacyPublic,
+		CreatedAt:       time.Now(),
 	}
 
-	id, err := s.userRepo.Create(user)
+	id, err := s.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -57,18 +62,18 @@ func (s *authService) Register(req *domain.RegisterRequest) (*domain.User, error
 	return user, nil
 }
 
-func (s *authService) Login(req *domain.LoginRequest) (string, *domain.User, error) {
-	user, err := s.userRepo.GetByEmail(req.Email)
+func (s *authService) Login(ctx context.Context, req *domain.LoginRequest) (string, *domain.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return "", nil, err
 	}
 	if user == nil {
-		return "", nil, errors.New("invalid email or password")
+		return "", nil, errors.Unauthorized("invalid email or password")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
-		return "", nil, errors.New("invalid email or password")
+		return "", nil, errors.Unauthorized("invalid email or password")
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
@@ -81,7 +86,7 @@ func (s *authService) Login(req *domain.LoginRequest) (string, *domain.User, err
 
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
-		return "", nil, errors.New("JWT_SECRET environment variable is required")
+		return "", nil, fmt.Errorf("JWT_SECRET environment variable is required")
 	}
 
 	tokenString, err := token.SignedString([]byte(secret))
@@ -92,10 +97,10 @@ func (s *authService) Login(req *domain.LoginRequest) (string, *domain.User, err
 	return tokenString, user, nil
 }
 
-func (s *authService) ValidateToken(tokenString string) (*jwt.Token, error) {
+func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*jwt.Token, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
-		return nil, errors.New("JWT_SECRET environment variable is required")
+		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
 	}
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -108,28 +113,28 @@ func (s *authService) ValidateToken(tokenString string) (*jwt.Token, error) {
 	return token, err
 }
 
-func (s *authService) GetUserFromToken(tokenString string) (*domain.User, error) {
-	token, err := s.ValidateToken(tokenString)
+func (s *authService) GetUserFromToken(ctx context.Context, tokenString string) (*domain.User, error) {
+	token, err := s.ValidateToken(ctx, tokenString)
 	if err != nil || !token.Valid {
-		return nil, errors.New("invalid or expired token")
+		return nil, errors.Unauthorized("invalid or expired token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, errors.New("invalid token claims")
+		return nil, errors.Unauthorized("invalid token claims")
 	}
 
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
-		return nil, errors.New("invalid user_id in token")
+		return nil, errors.Unauthorized("invalid user_id in token")
 	}
 
-	user, err := s.userRepo.GetByID(int(userIDFloat))
+	user, err := s.userRepo.GetByID(ctx, int(userIDFloat))
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, errors.Unauthorized("user not found")
 	}
 
 	return user, nil
@@ -2,6 +2,8 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+	"context"
 	"log"
 	"os"
 	"testing"
@@ -30,7 +32,7 @@ type mockAuthUserRepo struct {
 	NextID       int
 }
 
-func (m *mockAuthUserRepo) GetAll() ([]domain.User, error) {
+func (m *mockAuthUserRepo) GetAll(ctx context.Context) ([]domain.User, error) {
 	var result []domain.User
 	for _, user := range m.Users {
 		result = append(result, user)
@@ -38,7 +40,7 @@ func (m *mockAuthUserRepo) GetAll() ([]domain.User, error) {
 	return result, m.Err
 }
 
-func (m *mockAuthUserRepo) GetByID(id int) (*domain.User, error) {
+func (m *mockAuthUserRepo) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -48,7 +50,7 @@ func (m *mockAuthUserRepo) GetByID(id int) (*domain.User, error) {
 	return nil, nil
 }
 
-func (m *mockAuthUserRepo) GetByEmail(email string) (*domain.User, error) {
+func (m *mockAuthUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -58,7 +60,7 @@ func (m *mockAuthUserRepo) GetByEmail(email string) (*domain.User, error) {
 	return nil, nil
 }
 
-func (m *mockAuthUserRepo) Create(user *domain.User) (int, error) {
+func (m *mockAuthUserRepo) Create(ctx context.Context, user *domain.User) (int, error) {
 	if m.Err != nil {
 		return 0, m.Err
 	}
@@ -69,7 +71,7 @@ func (m *mockAuthUserRepo) Create(user *domain.User) (int, error) {
 	return user.ID, nil
 }
 
-func (m *mockAuthUserRepo) Update(user *domain.User) error {
+func (m *mockAuthUserRepo) Update(ctx context.Context, user *domain.User) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -80,7 +82,7 @@ func (m *mockAuthUserRepo) Update(user *domain.User) error {
 	return nil
 }
 
-func (m *mockAuthUserRepo) Delete(id int) error {
+func (m *mockAuthUserRepo) Delete(ctx context.Context, id int) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -91,6 +93,10 @@ func (m *mockAuthUserRepo) Delete(id int) error {
 	return nil
 }
 
+func (m *mockAuthUserRepo) UpdatePrivacy(ctx context.Context, id int, settings *domain.PrivacySettings) error {
+	return m.Err
+}
+
 func setupAuthTest() *mockAuthUserRepo {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 
@@ -126,7 +132,7 @@ func TestAuthService_Register(t *testing.T) {
 			Password: "password123",
 		}
 
-		user, err := svc.Register(req)
+		user, err := svc.Register(context.Background(), req)
 		if err != nil {
 			t.Fatalf("expected no error, got %v", err)
 		}
@@ -159,7 +165,7 @@ func TestAuthService_Register(t *testing.T) {
 			Password: "password123",
 		}
 
-		user, err := svc.Register(req)
+		user, err := svc.Register(context.Background(), req)
 		if err == nil {
 			t.Fatal("expected error for duplicate email")
 		}
@@ -169,6 +175,9 @@ func TestAuthService_Register(t *testing.T) {
 		if err.Error() != "user with this email already exists" {
 			t.Errorf("expected duplicate error message, got: %v", err)
 		}
+		if !apperrors.Is(err, apperrors.ErrConflict) {
+			t.Errorf("expected a conflict, got: %v", err)
+		}
 	})
 
 	t.Run("repository GetByEmail error", func(t *testing.T) {
@@ -182,7 +191,7 @@ func TestAuthService_Register(t *testing.T) {
 			Password: "password123",
 		}
 
-		user, err := svc.Register(req)
+		user, err := svc.Register(context.Background(), req)
 		if err == nil {
 			t.Fatal("expected error from repository")
 		}
@@ -202,7 +211,7 @@ func TestAuthService_Login(t *testing.T) {
 			Password: "password123",
 		}
 
-		token, user, err := svc.Login(req)
+		token, user, err := svc.Login(context.Background(), req)
 		if err != nil {
 			t.Fatalf("expected no error, got %v", err)
 		}
@@ -240,7 +249,7 @@ func TestAuthService_Login(t *testing.T) {
 			Password: "password123",
 		}
 
-		token, user, err := svc.Login(req)
+		token, user, err := svc.Login(context.Background(), req)
 		if err == nil {
 			t.Fatal("expected error for invalid email")
 		}
@@ -253,6 +262,9 @@ func TestAuthService_Login(t *testing.T) {
 		if err.Error() != "invalid email or password" {
 			t.Errorf("expected 'invalid email or password', got: %v", err)
 		}
+		if !apperrors.Is(err, apperrors.ErrUnauthorized) {
+			t.Errorf("expected unauthorized, got: %v", err)
+		}
 	})
 
 	t.Run("invalid password", func(t *testing.T) {
@@ -264,7 +276,7 @@ func TestAuthService_Login(t *testing.T) {
 			Password: "wrongpassword",
 		}
 
-		token, user, err := svc.Login(req)
+		token, user, err := svc.Login(context.Background(), req)
 		if err == nil {
 			t.Fatal("expected error for invalid password")
 		}
@@ -289,12 +301,12 @@ func TestAuthService_ValidateToken(t *testing.T) {
 			Email:    "existing@example.com",
 			Password: "password123",
 		}
-		token, _, err := svc.Login(req)
+		token, _, err := svc.Login(context.Background(), req)
 		if err != nil {
 			t.Fatalf("login failed: %v", err)
 		}
 
-		parsedToken, err := svc.ValidateToken(token)
+		parsedToken, err := svc.ValidateToken(context.Background(), token)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
@@ -304,7 +316,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
-		_, err := svc.ValidateToken("invalid.token.here")
+		_, err := svc.ValidateToken(context.Background(), "invalid.token.here")
 		if err == nil {
 			t.Error("expected error for invalid token")
 		}
@@ -326,7 +338,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 
 		tokenString, _ := token.SignedString([]byte(secret))
 
-		parsedToken, err := svc.ValidateToken(tokenString)
+		parsedToken, err := svc.ValidateToken(context.Background(), tokenString)
 		if err == nil {
 			t.Error("expected error for expired token")
 		}
@@ -336,7 +348,7 @@ func TestAuthService_ValidateToken(t *testing.T) {
 	})
 
 	t.Run("malformed token", func(t *testing.T) {
-		_, err := svc.ValidateToken("not.a.valid.jwt.token.at.all")
+		_, err := svc.ValidateToken(context.Background(), "not.a.valid.jwt.token.at.all")
 		if err == nil {
 			t.Error("expected error for malformed token")
 		}
@@ -352,12 +364,12 @@ func TestAuthService_GetUserFromToken(t *testing.T) {
 			Email:    "existing@example.com",
 			Password: "password123",
 		}
-		token, _, err := svc.Login(req)
+		token, _, err := svc.Login(context.Background(), req)
 		if err != nil {
 			t.Fatalf("login failed: %v", err)
 		}
 
-		user, err := svc.GetUserFromToken(token)
+		user, err := svc.GetUserFromToken(context.Background(), token)
 		if err != nil {
 			t.Fatalf("expected no error, got %v", err)
 		}
@@ -376,7 +388,7 @@ func TestAuthService_GetUserFromToken(t *testing.T) {
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
-		user, err := svc.GetUserFromToken("invalid.token.here")
+		user, err := svc.GetUserFromToken(context.Background(), "invalid.token.here")
 		if err == nil {
 			t.Error("expected error for invalid token")
 		}
@@ -401,7 +413,7 @@ func TestAuthService_GetUserFromToken(t *testing.T) {
 
 		tokenString, _ := token.SignedString([]byte(secret))
 
-		user, err := svc.GetUserFromToken(tokenString)
+		user, err := svc.GetUserFromToken(context.Background(), tokenString)
 		if err == nil {
 			t.Error("expected error when user not found")
 		}
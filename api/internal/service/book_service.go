@@ -2,66 +2,65 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
 	"book_boy/api/internal/infra"
+	"book_boy/api/internal/logging"
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 )
 
 type BookService interface {
-	GetAll() ([]domain.Book, error)
-	GetByID(id int) (*domain.Book, error)
-	Create(book *domain.Book) (int, error)
-	Update(book *domain.Book) error
-	Delete(id int) error
-	GetByTitle(title string) (*domain.Book, error)
-	GetSimilarTitles(title string) ([]domain.Book, error)
-	FilterBooks(filter repository.BookFilter) ([]domain.Book, error)
+	GetAll(ctx context.Context) ([]domain.Book, error)
+	GetByID(ctx context.Context, id int) (*domain.Book, error)
+	Create(ctx context.Context, book *domain.Book) (int, error)
+	Update(ctx context.Context, book *domain.Book, editorID int) error
+	ApplyMetadata(ctx context.Context, event *domain.BookMetadataFetchedEvent) (*domain.Book, error)
+	GetHistory(ctx context.Context, bookID int) ([]domain.CatalogRevision, error)
+	Revert(ctx context.Context, bookID int, version int, editorID int) (*domain.Book, error)
+	Delete(ctx context.Context, id int) error
+	GetByTitle(ctx context.Context, title string) (*domain.Book, error)
+	GetSimilarTitles(ctx context.Context, title string) ([]domain.Book, error)
+	FilterBooks(ctx context.Context, filter repository.BookFilter) ([]domain.Book, error)
+}
+
+const bookCacheTTL = 10 * time.Minute
+
+func bookCacheKey(id int) string {
+	return fmt.Sprintf("book:%d", id)
 }
 
 type bookService struct {
 	repo      repository.BookRepo
-	cache     *infra.Cache
+	cache     infra.Cache
 	publisher *infra.EventPublisher
+	views     ProgressViews
+	logger    *slog.Logger
 }
 
-func NewBookService(repo repository.BookRepo, cache *infra.Cache, publisher *infra.EventPublisher) BookService {
-	return &bookService{repo: repo, cache: cache, publisher: publisher}
+func NewBookService(repo repository.BookRepo, cache infra.Cache, publisher *infra.EventPublisher, views ProgressViews, logger *slog.Logger) BookService {
+	return &bookService{repo: repo, cache: cache, publisher: publisher, views: views, logger: logging.OrDiscard(logger)}
 }
 
-func (s *bookService) GetAll() ([]domain.Book, error) {
-	return s.repo.GetAll()
+func (s *bookService) GetAll(ctx context.Context) ([]domain.Book, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *bookService) GetByID(id int) (*domain.Book, error) {
-	if s.cache != nil {
-		ctx := context.Background()
-		cacheKey := fmt.Sprintf("book:%d", id)
-
-		var book domain.Book
-		if err := s.cache.Get(ctx, cacheKey, &book); err == nil {
-			return &book, nil
-		}
-	}
-
-	result, err := s.repo.GetByID(id)
-	if err != nil {
-		return nil, err
-	}
-
-	if s.cache != nil {
-		s.cache.Set(context.Background(), fmt.Sprintf("book:%d", id), result, 10*time.Minute)
-	}
-	return result, nil
+func (s *bookService) GetByID(ctx context.Context, id int) (*domain.Book, error) {
+	return infra.GetOrLoad(ctx, s.cache, bookCacheKey(id), bookCacheTTL, func(ctx context.Context) (*domain.Book, error) {
+		return s.repo.GetByID(ctx, id)
+	})
 }
 
-func (s *bookService) Create(book *domain.Book) (int, error) {
+func (s *bookService) Create(ctx context.Context, book *domain.Book) (int, error) {
 	if err := book.Validate(); err != nil {
 		return 0, err
 	}
+	book.LockedFields = book.EnteredFields()
 
-	bookID, err := s.repo.Create(book)
+	bookID, err := s.repo.Create(ctx, book)
 	if err != nil {
 		return 0, err
 	}
@@ -72,45 +71,170 @@ func (s *bookService) Create(book *domain.Book) (int, error) {
 			ISBN:      book.ISBN,
 			CreatedAt: time.Now().Format(time.RFC3339),
 		}
-		if err := s.publisher.Publish("book.created", event); err != nil {
-			fmt.Printf("Warning: failed to publish book.created event: %v\n", err)
-		}
+		// The metadata worker looks the book up, so it must not hear of it
+		// before the row commits.
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			if err := s.publisher.Publish(ctx, "book.created", event); err != nil {
+				s.logger.WarnContext(ctx, "failed to publish book.created event", "book_id", book.ID, "error", err)
+			}
+		})
 	}
 
 	return bookID, nil
 }
 
-func (s *bookService) Update(book *domain.Book) error {
+// Update applies a user's edit. Optional fields left empty keep their current
+// value; every field the edit changes becomes locked against metadata
+// enrichment.
+func (s *bookService) Update(ctx context.Context, book *domain.Book, editorID int) error {
 	if err := book.Validate(); err != nil {
 		return err
 	}
-	if err := s.repo.Update(book); err != nil {
+	current, err := s.repo.GetByID(ctx, book.ID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return errors.ErrNotFound
+	}
+
+	book.FillOmitted(current)
+	changes := domain.DiffFields(current.Fields(), book.Fields())
+	book.LockedFields = domain.MergeLockedFields(current.LockedFields, changes)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	revision := &domain.CatalogRevision{
+		Source:   domain.RevisionUser,
+		EditorID: &editorID,
+		Changes:  changes,
+		Snapshot: book.Fields(),
+	}
+	return s.save(ctx, book, revision)
+}
+
+// ApplyMetadata fills in fetched metadata, skipping locked fields and empty
+// values. It returns the book as saved, unchanged if nothing applied.
+func (s *bookService) ApplyMetadata(ctx context.Context, event *domain.BookMetadataFetchedEvent) (*domain.Book, error) {
+	current, err := s.repo.GetByID(ctx, event.BookID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	enriched := *current
+	if event.Title != "" && !domain.IsLocked(current.LockedFields, "title") {
+		enriched.Title = event.Title
+	}
+	if event.TotalPages > 0 && !domain.IsLocked(current.LockedFields, "total_pages") {
+		enriched.TotalPages = event.TotalPages
+	}
+	if event.Author != "" && !domain.IsLocked(current.LockedFields, "author") {
+		enriched.Author = event.Author
+	}
+
+	changes := domain.DiffFields(current.Fields(), enriched.Fields())
+	if len(changes) == 0 {
+		return current, nil
+	}
+	revision := &domain.CatalogRevision{
+		Source:   domain.RevisionMetadata,
+		Changes:  changes,
+		Snapshot: enriched.Fields(),
+	}
+	if err := s.save(ctx, &enriched, revision); err != nil {
+		return nil, err
+	}
+	return &enriched, nil
+}
+
+func (s *bookService) GetHistory(ctx context.Context, bookID int) ([]domain.CatalogRevision, error) {
+	book, err := s.repo.GetByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, errors.ErrNotFound
+	}
+	return s.repo.GetRevisions(ctx, bookID)
+}
+
+// Revert restores the fields as they were after the given version. The revert
+// is itself a new revision, and counts as a user edit for locking.
+func (s *bookService) Revert(ctx context.Context, bookID int, version int, editorID int) (*domain.Book, error) {
+	current, err := s.repo.GetByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, errors.ErrNotFound
+	}
+	target, err := s.repo.GetRevision(ctx, bookID, version)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	reverted := *current
+	reverted.ApplyFields(target.Snapshot)
+	if err := reverted.Validate(); err != nil {
+		return nil, err
+	}
+	changes := domain.DiffFields(current.Fields(), reverted.Fields())
+	if len(changes) == 0 {
+		return current, nil
+	}
+	reverted.LockedFields = domain.MergeLockedFields(current.LockedFields, changes)
+
+	revision := &domain.CatalogRevision{
+		Source:     domain.RevisionRevert,
+		EditorID:   &editorID,
+		RevertedTo: &version,
+		Changes:    changes,
+		Snapshot:   reverted.Fields(),
+	}
+	if err := s.save(ctx, &reverted, revision); err != nil {
+		return nil, err
+	}
+	return &reverted, nil
+}
+
+func (s *bookService) save(ctx context.Context, book *domain.Book, revision *domain.CatalogRevision) error {
+	if err := s.repo.Update(ctx, book, revision); err != nil {
 		return err
 	}
+	bookChanged(ctx, s.views, book.ID)
 	if s.cache != nil {
-		s.cache.Delete(context.Background(), fmt.Sprintf("book:%d", book.ID))
+		s.cache.Delete(context.WithoutCancel(ctx), bookCacheKey(book.ID))
 	}
 	return nil
 }
 
-func (s *bookService) Delete(id int) error {
-	if err := s.repo.Delete(id); err != nil {
+func (s *bookService) Delete(ctx context.Context, id int) error {
+	// Whoever tracks it has to be found while the entries still point here.
+	bookChanged(ctx, s.views, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
 		return err
 	}
 	if s.cache != nil {
-		s.cache.Delete(context.Background(), fmt.Sprintf("book:%d", id))
+		s.cache.Delete(context.WithoutCancel(ctx), bookCacheKey(id))
 	}
 	return nil
 }
 
-func (s *bookService) GetByTitle(title string) (*domain.Book, error) {
-	return s.repo.GetByTitle(title)
+func (s *bookService) GetByTitle(ctx context.Context, title string) (*domain.Book, error) {
+	return s.repo.GetByTitle(ctx, title)
 }
 
-func (s *bookService) GetSimilarTitles(title string) ([]domain.Book, error) {
-	return s.repo.GetSimilarTitles(title)
+func (s *bookService) GetSimilarTitles(ctx context.Context, title string) ([]domain.Book, error) {
+	return s.repo.GetSimilarTitles(ctx, title)
 }
 
-func (s *bookService) FilterBooks(filter repository.BookFilter) ([]domain.Book, error) {
-	return s.repo.FilterBooks(filter)
+func (s *bookService) FilterBooks(ctx context.Context, filter repository.BookFilter) ([]domain.Book, error) {
+	return s.repo.FilterBooks(ctx, filter)
 }
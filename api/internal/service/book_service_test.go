@@ -1,10 +1,14 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+	"book_boy/api/internal/infra"
 	"book_boy/api/internal/repository"
 )
 
@@ -14,9 +18,10 @@ type mockBookRepo struct {
 	LastCreated *domain.Book
 	LastUpdated *domain.Book
 	LastDeleted int
+	Revisions   map[int][]domain.CatalogRevision
 }
 
-func (m *mockBookRepo) GetAll() ([]domain.Book, error) {
+func (m *mockBookRepo) GetAll(ctx context.Context) ([]domain.Book, error) {
 	books := make([]domain.Book, 0, len(m.Books))
 	for _, book := range m.Books {
 		books = append(books, book)
@@ -24,7 +29,7 @@ func (m *mockBookRepo) GetAll() ([]domain.Book, error) {
 	return books, m.Err
 }
 
-func (m *mockBookRepo) GetByID(id int) (*domain.Book, error) {
+func (m *mockBookRepo) GetByID(ctx context.Context, id int) (*domain.Book, error) {
 	book, ok := m.Books[id]
 	if !ok {
 		return nil, nil
@@ -32,7 +37,7 @@ func (m *mockBookRepo) GetByID(id int) (*domain.Book, error) {
 	return &book, nil
 }
 
-func (m *mockBookRepo) Create(book *domain.Book) (int, error) {
+func (m *mockBookRepo) Create(ctx context.Context, book *domain.Book) (int, error) {
 	if m.Err != nil {
 		return 0, m.Err
 	}
@@ -43,7 +48,7 @@ func (m *mockBookRepo) Create(book *domain.Book) (int, error) {
 	return id, nil
 }
 
-func (m *mockBookRepo) Update(book *domain.Book) error {
+func (m *mockBookRepo) Update(ctx context.Context, book *domain.Book, revision *domain.CatalogRevision) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -52,10 +57,28 @@ func (m *mockBookRepo) Update(book *domain.Book) error {
 	}
 	m.Books[book.ID] = *book
 	m.LastUpdated = book
+	if m.Revisions == nil {
+		m.Revisions = make(map[int][]domain.CatalogRevision)
+	}
+	revision.Version = len(m.Revisions[book.ID]) + 1
+	m.Revisions[book.ID] = append(m.Revisions[book.ID], *revision)
 	return nil
 }
 
-func (m *mockBookRepo) Delete(id int) error {
+func (m *mockBookRepo) GetRevisions(ctx context.Context, bookID int) ([]domain.CatalogRevision, error) {
+	return m.Revisions[bookID], m.Err
+}
+
+func (m *mockBookRepo) GetRevision(ctx context.Context, bookID int, version int) (*domain.CatalogRevision, error) {
+	for _, revision := range m.Revisions[bookID] {
+		if revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, m.Err
+}
+
+func (m *mockBookRepo) Delete(ctx context.Context, id int) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -64,7 +87,7 @@ func (m *mockBookRepo) Delete(id int) error {
 	return nil
 }
 
-func (m *mockBookRepo) GetByTitle(title string) (*domain.Book, error) {
+func (m *mockBookRepo) GetByTitle(ctx context.Context, title string) (*domain.Book, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -76,12 +99,12 @@ func (m *mockBookRepo) GetByTitle(title string) (*domain.Book, error) {
 	return nil, nil
 }
 
-func (m *mockBookRepo) GetSimilarTitles(title string) ([]domain.Book, error) {
+func (m *mockBookRepo) GetSimilarTitles(ctx context.Context, title string) ([]domain.Book, error) {
 	//TODO IMPLEMENT
 	return nil, nil
 }
 
-func (m *mockBookRepo) FilterBooks(filter repository.BookFilter) ([]domain.Book, error) {
+func (m *mockBookRepo) FilterBooks(ctx context.Context, filter repository.BookFilter) ([]domain.Book, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -100,6 +123,9 @@ func (m *mockBookRepo) FilterBooks(filter repository.BookFilter) ([]domain.Book,
 		if filter.TotalPages != nil && book.TotalPages != *filter.TotalPages {
 			match = false
 		}
+		if filter.MinRating != nil && (book.AverageRating == nil || *book.AverageRating < *filter.MinRating) {
+			match = false
+		}
 		if match {
 			results = append(results, book)
 		}
@@ -114,9 +140,9 @@ func TestBookService_GetAll(t *testing.T) {
 			2: {ID: 2, ISBN: "2222", Title: "Test Book B", TotalPages: 500},
 		},
 	}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
-	result, err := svc.GetAll()
+	result, err := svc.GetAll(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -131,7 +157,7 @@ func TestBookService_GetAll(t *testing.T) {
 			t.Errorf("unexpected book ID %d in result", book.ID)
 			continue
 		}
-		if book != expected {
+		if !reflect.DeepEqual(book, expected) {
 			t.Errorf("for book ID %d: expected %+v, got %+v", book.ID, expected, book)
 		}
 	}
@@ -146,10 +172,10 @@ func TestBookService_GetByID(t *testing.T) {
 		Err: nil,
 	}
 
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
 	t.Run("found", func(t *testing.T) {
-		book, err := svc.GetByID(1)
+		book, err := svc.GetByID(context.Background(), 1)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -159,7 +185,7 @@ func TestBookService_GetByID(t *testing.T) {
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		book, err := svc.GetByID(-1)
+		book, err := svc.GetByID(context.Background(), -1)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -171,10 +197,10 @@ func TestBookService_GetByID(t *testing.T) {
 
 func TestBookService_Create(t *testing.T) {
 	mockRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
 	book := &domain.Book{ISBN: "3333", Title: "New Book", TotalPages: 123}
-	id, err := svc.Create(book)
+	id, err := svc.Create(context.Background(), book)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -189,10 +215,10 @@ func TestBookService_Update(t *testing.T) {
 			1: {ID: 1, ISBN: "1111", Title: "Old Title", TotalPages: 322},
 		},
 	}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
 	book := &domain.Book{ID: 1, ISBN: "1111", Title: "Updated Title", TotalPages: 700}
-	err := svc.Update(book)
+	err := svc.Update(context.Background(), book, 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -201,15 +227,64 @@ func TestBookService_Update(t *testing.T) {
 	}
 }
 
+func TestBookService_GetByID_CachesUntilUpdated(t *testing.T) {
+	mockRepo := &mockBookRepo{
+		Books: map[int]domain.Book{
+			1: {ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 412},
+		},
+	}
+	svc := NewBookService(mockRepo, infra.NewMemoryCache(10), nil, nil, nil)
+	ctx := context.Background()
+
+	if _, err := svc.GetByID(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Changed behind the service's back, so only a cache miss would see it.
+	mockRepo.Books[1] = domain.Book{ID: 1, ISBN: "1111", Title: "Dune Messiah", TotalPages: 412}
+	book, err := svc.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if book.Title != "Dune" {
+		t.Fatalf("expected the cached title, got %q", book.Title)
+	}
+
+	if err := svc.Update(ctx, &domain.Book{ID: 1, ISBN: "1111", Title: "Children of Dune", TotalPages: 444}, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	book, err = svc.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if book.Title != "Children of Dune" {
+		t.Fatalf("expected the update to invalidate the cache, got %q", book.Title)
+	}
+}
+
+func TestBookService_GetByID_DoesNotCacheMissingBook(t *testing.T) {
+	mockRepo := &mockBookRepo{Books: map[int]domain.Book{}}
+	svc := NewBookService(mockRepo, infra.NewMemoryCache(10), nil, nil, nil)
+	ctx := context.Background()
+
+	if book, err := svc.GetByID(ctx, 1); err != nil || book != nil {
+		t.Fatalf("expected no book, got %+v, %v", book, err)
+	}
+	mockRepo.Books[1] = domain.Book{ID: 1, ISBN: "1111", Title: "Dune"}
+	book, err := svc.GetByID(ctx, 1)
+	if err != nil || book == nil {
+		t.Fatalf("expected the new book, got %+v, %v", book, err)
+	}
+}
+
 func TestBookService_Delete(t *testing.T) {
 	mockRepo := &mockBookRepo{
 		Books: map[int]domain.Book{
 			1: {ID: 1, ISBN: "1111", Title: "Delete Me", TotalPages: 100},
 		},
 	}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
-	err := svc.Delete(1)
+	err := svc.Delete(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -225,9 +300,9 @@ func TestBookService_GetByTitle(t *testing.T) {
 			2: {ID: 2, ISBN: "2222", Title: "Another Book", TotalPages: 300},
 		},
 	}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
-	book, err := svc.GetByTitle("Unique Title")
+	book, err := svc.GetByTitle(context.Background(), "Unique Title")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -235,7 +310,7 @@ func TestBookService_GetByTitle(t *testing.T) {
 		t.Fatalf("expected book with title 'Unique Title', got %+v", book)
 	}
 
-	notFound, err := svc.GetByTitle("Nonexistent")
+	notFound, err := svc.GetByTitle(context.Background(), "Nonexistent")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -251,9 +326,9 @@ func TestBookService_GetSimilarTitles(t *testing.T) {
 			2: {ID: 2, ISBN: "2222", Title: "Lord of the Rings", TotalPages: 400},
 		},
 	}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
-	books, err := svc.GetSimilarTitles("Potter")
+	books, err := svc.GetSimilarTitles(context.Background(), "Potter")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -264,10 +339,10 @@ func TestBookService_GetSimilarTitles(t *testing.T) {
 
 func TestBookService_Create_ValidationError(t *testing.T) {
 	mockRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
 	book := &domain.Book{ISBN: "1234", Title: "Valid", TotalPages: -5}
-	_, err := svc.Create(book)
+	_, err := svc.Create(context.Background(), book)
 	if err == nil {
 		t.Fatal("expected validation error for negative total_pages")
 	}
@@ -275,24 +350,24 @@ func TestBookService_Create_ValidationError(t *testing.T) {
 
 func TestBookService_Errors(t *testing.T) {
 	mockRepo := &mockBookRepo{Books: make(map[int]domain.Book), Err: errors.New("db error")}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
-	if _, err := svc.GetAll(); err == nil {
+	if _, err := svc.GetAll(context.Background()); err == nil {
 		t.Error("expected GetAll to return error")
 	}
-	if _, err := svc.Create(&domain.Book{ISBN: "1234", Title: "Test", TotalPages: 100}); err == nil {
+	if _, err := svc.Create(context.Background(), &domain.Book{ISBN: "1234", Title: "Test", TotalPages: 100}); err == nil {
 		t.Error("expected Create to return error")
 	}
-	if err := svc.Update(&domain.Book{ID: 1, ISBN: "1234", Title: "Test", TotalPages: 100}); err == nil {
+	if err := svc.Update(context.Background(), &domain.Book{ID: 1, ISBN: "1234", Title: "Test", TotalPages: 100}, 1); err == nil {
 		t.Error("expected Update to return error")
 	}
-	if err := svc.Delete(1); err == nil {
+	if err := svc.Delete(context.Background(), 1); err == nil {
 		t.Error("expected Delete to return error")
 	}
-	if _, err := svc.GetByTitle("test"); err == nil {
+	if _, err := svc.GetByTitle(context.Background(), "test"); err == nil {
 		t.Error("expected GetByTitle to return error")
 	}
-	if _, err := svc.FilterBooks(repository.BookFilter{}); err == nil {
+	if _, err := svc.FilterBooks(context.Background(), repository.BookFilter{}); err == nil {
 		t.Error("expected FilterBooks to return error")
 	}
 }
@@ -305,11 +380,11 @@ func TestBookService_FilterBooks(t *testing.T) {
 			3: {ID: 3, ISBN: "3333", Title: "Book C", TotalPages: 200},
 		},
 	}
-	svc := NewBookService(mockRepo, nil, nil)
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
 
 	pages := 200
 	filter := repository.BookFilter{TotalPages: &pages}
-	books, err := svc.FilterBooks(filter)
+	books, err := svc.FilterBooks(context.Background(), filter)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -322,3 +397,153 @@ func TestBookService_FilterBooks(t *testing.T) {
 		}
 	}
 }
+
+func TestBookService_FilterBooks_MinRating(t *testing.T) {
+	high, low := 4.5, 2.0
+	mockRepo := &mockBookRepo{
+		Books: map[int]domain.Book{
+			1: {ID: 1, ISBN: "1111", Title: "Loved", AverageRating: &high, RatingCount: 4},
+			2: {ID: 2, ISBN: "2222", Title: "Meh", AverageRating: &low, RatingCount: 2},
+			3: {ID: 3, ISBN: "3333", Title: "Unrated"},
+		},
+	}
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
+
+	minRating := 4.0
+	books, err := svc.FilterBooks(context.Background(), repository.BookFilter{MinRating: &minRating})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(books) != 1 || books[0].ID != 1 {
+		t.Fatalf("expected only book 1 rated >= 4, got %+v", books)
+	}
+}
+
+func TestBookService_Update_RecordsRevisionAndLocksFields(t *testing.T) {
+	mockRepo := &mockBookRepo{Books: map[int]domain.Book{
+		1: {ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 412},
+	}}
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
+
+	if err := svc.Update(context.Background(), &domain.Book{ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 896}, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	revisions := mockRepo.Revisions[1]
+	if len(revisions) != 1 {
+		t.Fatalf("expected 1 revision, got %d", len(revisions))
+	}
+	rev := revisions[0]
+	if rev.Source != domain.RevisionUser || rev.EditorID == nil || *rev.EditorID != 7 {
+		t.Fatalf("unexpected revision attribution: %+v", rev)
+	}
+	if len(rev.Changes) != 1 || rev.Changes["total_pages"].From != 412 || rev.Changes["total_pages"].To != 896 {
+		t.Fatalf("expected only total_pages to change, got %+v", rev.Changes)
+	}
+	if !reflect.DeepEqual(mockRepo.Books[1].LockedFields, []string{"total_pages"}) {
+		t.Fatalf("expected total_pages locked, got %v", mockRepo.Books[1].LockedFields)
+	}
+
+	if err := svc.Update(context.Background(), &domain.Book{ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 896}, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockRepo.Revisions[1]) != 1 {
+		t.Fatal("expected no revision for an edit that changes nothing")
+	}
+
+	if err := svc.Update(context.Background(), &domain.Book{ID: 99, ISBN: "9999", Title: "Missing"}, 7); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBookService_Update_KeepsOmittedFields(t *testing.T) {
+	mockRepo := &mockBookRepo{Books: map[int]domain.Book{
+		1: {ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 412, Author: "Frank Herbert"},
+	}}
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
+
+	if err := svc.Update(context.Background(), &domain.Book{ID: 1, ISBN: "1111", TotalPages: 896}, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	book := mockRepo.Books[1]
+	if book.Author != "Frank Herbert" || book.Title != "Dune" {
+		t.Fatalf("expected omitted author and title kept, got %+v", book)
+	}
+	if !reflect.DeepEqual(book.LockedFields, []string{"total_pages"}) {
+		t.Fatalf("expected only total_pages locked, got %v", book.LockedFields)
+	}
+}
+
+func TestBookService_ApplyMetadata_SkipsLockedFields(t *testing.T) {
+	mockRepo := &mockBookRepo{Books: map[int]domain.Book{
+		1: {ID: 1, ISBN: "1111", Title: "my dune copy", LockedFields: []string{"title"}},
+	}}
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
+
+	book, err := svc.ApplyMetadata(context.Background(), &domain.BookMetadataFetchedEvent{
+		BookID: 1, Title: "Dune", TotalPages: 412, Author: "Frank Herbert", Success: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if book.Title != "my dune copy" || book.TotalPages != 412 || book.Author != "Frank Herbert" {
+		t.Fatalf("expected only unlocked fields enriched, got %+v", book)
+	}
+	rev := mockRepo.Revisions[1][0]
+	if rev.Source != domain.RevisionMetadata || rev.EditorID != nil {
+		t.Fatalf("unexpected metadata revision: %+v", rev)
+	}
+	if _, ok := rev.Changes["title"]; ok {
+		t.Fatal("expected locked title to be absent from changes")
+	}
+	if !reflect.DeepEqual(mockRepo.Books[1].LockedFields, []string{"title"}) {
+		t.Fatalf("expected enrichment not to lock fields, got %v", mockRepo.Books[1].LockedFields)
+	}
+}
+
+func TestBookService_Create_LocksEnteredFields(t *testing.T) {
+	mockRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
+
+	if _, err := svc.Create(context.Background(), &domain.Book{ISBN: "1111", Title: "Dune"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(mockRepo.LastCreated.LockedFields, []string{"title"}) {
+		t.Fatalf("expected entered title locked, got %v", mockRepo.LastCreated.LockedFields)
+	}
+}
+
+func TestBookService_Revert(t *testing.T) {
+	mockRepo := &mockBookRepo{Books: map[int]domain.Book{
+		1: {ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 412},
+	}}
+	svc := NewBookService(mockRepo, nil, nil, nil, nil)
+
+	svc.Update(context.Background(), &domain.Book{ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 500}, 7)
+	svc.Update(context.Background(), &domain.Book{ID: 1, ISBN: "1111", Title: "Dune!!", TotalPages: 600}, 8)
+
+	// Snapshots read back from JSON carry numbers as float64.
+	mockRepo.Revisions[1][0].Snapshot["total_pages"] = float64(500)
+
+	book, err := svc.Revert(context.Background(), 1, 1, 9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if book.Title != "Dune" || book.TotalPages != 500 {
+		t.Fatalf("expected version 1 restored, got %+v", book)
+	}
+	latest := mockRepo.Revisions[1][2]
+	if latest.Source != domain.RevisionRevert || latest.RevertedTo == nil || *latest.RevertedTo != 1 {
+		t.Fatalf("unexpected revert revision: %+v", latest)
+	}
+
+	if _, err := svc.Revert(context.Background(), 1, 42, 9); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for unknown version, got %v", err)
+	}
+
+	history, err := svc.GetHistory(context.Background(), 1)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("expected 3 revisions, got %d (%v)", len(history), err)
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/repository"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	duplicateCandidateLimit = 20
+	// duplicateThreshold is the confidence a candidate needs to be reported.
+	// Title similarity alone rarely clears it; a matching page count, length
+	// or author usually has to agree too.
+	duplicateThreshold = 0.6
+	pagesMatchBonus    = 0.25
+	lengthMatchBonus   = 0.25
+	authorMatchBonus   = 0.15
+)
+
+type CatalogService interface {
+	FindBookDuplicates(ctx context.Context, bookID int) ([]domain.DuplicateCandidate, error)
+	FindAudiobookDuplicates(ctx context.Context, audiobookID int) ([]domain.DuplicateCandidate, error)
+	MergeBooks(ctx context.Context, canonicalID int, req *domain.MergeRequest) (*domain.MergeResult, error)
+	MergeAudiobooks(ctx context.Context, canonicalID int, req *domain.MergeRequest) (*domain.MergeResult, error)
+}
+
+type catalogService struct {
+	repo          repository.CatalogRepo
+	bookRepo      repository.BookRepo
+	audiobookRepo repository.AudiobookRepo
+	cache         infra.Cache
+	views         ProgressViews
+}
+
+func NewCatalogService(repo repository.CatalogRepo, bookRepo repository.BookRepo, audiobookRepo repository.AudiobookRepo, cache infra.Cache, views ProgressViews) CatalogService {
+	return &catalogService{repo: repo, bookRepo: bookRepo, audiobookRepo: audiobookRepo, cache: cache, views: views}
+}
+
+func (s *catalogService) FindBookDuplicates(ctx context.Context, bookID int) ([]domain.DuplicateCandidate, error) {
+	book, err := s.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	candidates, err := s.repo.GetBookDuplicateCandidates(ctx, bookID, duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []domain.DuplicateCandidate{}
+	for _, candidate := range candidates {
+		scoreBookDuplicate(book, &candidate)
+		if candidate.Confidence >= duplicateThreshold {
+			duplicates = append(duplicates, candidate)
+		}
+	}
+	sortByConfidence(duplicates)
+	return duplicates, nil
+}
+
+func (s *catalogService) FindAudiobookDuplicates(ctx context.Context, audiobookID int) ([]domain.DuplicateCandidate, error) {
+	audiobook, err := s.audiobookRepo.GetByID(ctx, audiobookID)
+	if err != nil {
+		return nil, err
+	}
+	if audiobook == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	candidates, err := s.repo.GetAudiobookDuplicateCandidates(ctx, audiobookID, duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []domain.DuplicateCandidate{}
+	for _, candidate := range candidates {
+		scoreAudiobookDuplicate(audiobook, &candidate)
+		if candidate.Confidence >= duplicateThreshold {
+			duplicates = append(duplicates, candidate)
+		}
+	}
+	sortByConfidence(duplicates)
+	return duplicates, nil
+}
+
+// MergeBooks only merges books that FindBookDuplicates reports for the
+// canonical one. A merge repoints every reader's entries and can't be undone,
+// so it mustn't be usable to fold unrelated books together.
+func (s *catalogService) MergeBooks(ctx context.Context, canonicalID int, req *domain.MergeRequest) (*domain.MergeResult, error) {
+	if err := req.Validate(canonicalID); err != nil {
+		return nil, err
+	}
+	duplicates, err := s.FindBookDuplicates(ctx, canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	detected := make(map[int]bool, len(duplicates))
+	for _, duplicate := range duplicates {
+		detected[duplicate.Book.ID] = true
+	}
+	if err := checkDetected(req.DuplicateIDs, detected); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.MergeBooks(ctx, canonicalID, req.DuplicateIDs)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, bookCacheKey, canonicalID, req.DuplicateIDs)
+	// The duplicates' entries now point at the canonical book.
+	bookChanged(ctx, s.views, canonicalID)
+	return result, nil
+}
+
+// MergeAudiobooks, like MergeBooks, only merges detected duplicates.
+func (s *catalogService) MergeAudiobooks(ctx context.Context, canonicalID int, req *domain.MergeRequest) (*domain.MergeResult, error) {
+	if err := req.Validate(canonicalID); err != nil {
+		return nil, err
+	}
+	duplicates, err := s.FindAudiobookDuplicates(ctx, canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	detected := make(map[int]bool, len(duplicates))
+	for _, duplicate := range duplicates {
+		detected[duplicate.Audiobook.ID] = true
+	}
+	if err := checkDetected(req.DuplicateIDs, detected); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.MergeAudiobooks(ctx, canonicalID, req.DuplicateIDs)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, audiobookCacheKey, canonicalID, req.DuplicateIDs)
+	// The duplicates' entries now point at the canonical audiobook.
+	audiobookChanged(ctx, s.views, canonicalID)
+	return result, nil
+}
+
+// checkDetected rejects the requested IDs that duplicate detection didn't
+// report, whether they score too low or don't exist.
+func checkDetected(duplicateIDs []int, detected map[int]bool) error {
+	var rejected []string
+	for _, id := range duplicateIDs {
+		if !detected[id] {
+			rejected = append(rejected, strconv.Itoa(id))
+		}
+	}
+	if len(rejected) > 0 {
+		return errors.ErrInvalidField("duplicate_ids", fmt.Sprintf("%s not detected as duplicates with confidence of at least %.1f", strings.Join(rejected, ", "), duplicateThreshold))
+	}
+	return nil
+}
+
+// invalidate drops the cached records; the canonical one picks up the merged
+// ratings, the duplicates no longer exist.
+func (s *catalogService) invalidate(ctx context.Context, cacheKey func(int) string, canonicalID int, duplicateIDs []int) {
+	if s.cache == nil {
+		return
+	}
+	for _, id := range append([]int{canonicalID}, duplicateIDs...) {
+		s.cache.Delete(context.WithoutCancel(ctx), cacheKey(id))
+	}
+}
+
+func scoreBookDuplicate(book *domain.Book, candidate *domain.DuplicateCandidate) {
+	other := candidate.Book
+	if core := isbnCore(book.ISBN); core != "" && core == isbnCore(other.ISBN) {
+		candidate.Confidence = 1
+		candidate.Reasons = []string{"isbn"}
+		return
+	}
+
+	confidence := candidate.TitleSimilarity
+	reasons := []string{"title"}
+	if pagesMatch(book.TotalPages, other.TotalPages) {
+		confidence += pagesMatchBonus
+		reasons = append(reasons, "pages")
+	}
+	if authorsMatch(book.Author, other.Author) {
+		confidence += authorMatchBonus
+		reasons = append(reasons, "author")
+	}
+	candidate.Confidence = roundTo2(math.Min(confidence, 1))
+	candidate.Reasons = reasons
+}
+
+func scoreAudiobookDuplicate(audiobook *domain.Audiobook, candidate *domain.DuplicateCandidate) {
+	other := candidate.Audiobook
+	confidence := candidate.TitleSimilarity
+	reasons := []string{"title"}
+	if audiobook.TotalLength != nil && other.TotalLength != nil &&
+		lengthsMatch(audiobook.TotalLength.Duration, other.TotalLength.Duration) {
+		confidence += lengthMatchBonus
+		reasons = append(reasons, "length")
+	}
+	if authorsMatch(audiobook.Author, other.Author) {
+		confidence += authorMatchBonus
+		reasons = append(reasons, "author")
+	}
+	candidate.Confidence = roundTo2(math.Min(confidence, 1))
+	candidate.Reasons = reasons
+}
+
+// isbnCore mirrors the repo's isbnCoreSQL: the 9 digits shared by the ISBN-10
+// and ISBN-13 forms of the same edition.
+func isbnCore(isbn string) string {
+	var b strings.Builder
+	for _, r := range isbn {
+		if (r >= '0' && r <= '9') || r == 'X' || r == 'x' {
+			b.WriteRune(r)
+		}
+	}
+	n := b.String()
+	switch {
+	case len(n) == 13 && (strings.HasPrefix(n, "978") || strings.HasPrefix(n, "979")):
+		return n[3:12]
+	case len(n) == 10:
+		return n[:9]
+	}
+	return n
+}
+
+// pagesMatch allows a couple of pages either way for front matter differences.
+func pagesMatch(a, b int) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	tolerance := math.Max(2, 0.02*math.Max(float64(a), float64(b)))
+	return math.Abs(float64(a-b)) <= tolerance
+}
+
+func lengthsMatch(a, b time.Duration) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	tolerance := time.Duration(0.01 * float64(max(a, b)))
+	if tolerance < time.Minute {
+		tolerance = time.Minute
+	}
+	return diff <= tolerance
+}
+
+func authorsMatch(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	return a != "" && strings.EqualFold(a, b)
+}
+
+func sortByConfidence(candidates []domain.DuplicateCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockCatalogRepo struct {
+	BookCandidates      []domain.DuplicateCandidate
+	AudiobookCandidates []domain.DuplicateCandidate
+	Err                 error
+	MergedInto          int
+	Merged              []int
+}
+
+func (m *mockCatalogRepo) GetBookDuplicateCandidates(ctx context.Context, bookID int, limit int) ([]domain.DuplicateCandidate, error) {
+	return m.BookCandidates, m.Err
+}
+
+func (m *mockCatalogRepo) GetAudiobookDuplicateCandidates(ctx context.Context, audiobookID int, limit int) ([]domain.DuplicateCandidate, error) {
+	return m.AudiobookCandidates, m.Err
+}
+
+func (m *mockCatalogRepo) MergeBooks(ctx context.Context, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.MergedInto = canonicalID
+	m.Merged = duplicateIDs
+	return &domain.MergeResult{CanonicalID: canonicalID, MergedIDs: duplicateIDs}, nil
+}
+
+func (m *mockCatalogRepo) MergeAudiobooks(ctx context.Context, canonicalID int, duplicateIDs []int) (*domain.MergeResult, error) {
+	return m.MergeBooks(context.Background(), canonicalID, duplicateIDs)
+}
+
+func TestCatalogService_FindBookDuplicates(t *testing.T) {
+	books := &mockBookRepo{Books: map[int]domain.Book{
+		1: {ID: 1, ISBN: "0-441-17271-7", Title: "Dune", TotalPages: 412, Author: "Frank Herbert"},
+	}}
+	repo := &mockCatalogRepo{BookCandidates: []domain.DuplicateCandidate{
+		{Book: &domain.Book{ID: 2, ISBN: "tracked-2", Title: "Dune ", TotalPages: 410}, TitleSimilarity: 0.9},
+		{Book: &domain.Book{ID: 3, ISBN: "9780441172719", Title: "Dune (40th Anniversary)"}, TitleSimilarity: 0.4},
+		{Book: &domain.Book{ID: 4, ISBN: "tracked-4", Title: "Dune Messiah", TotalPages: 256}, TitleSimilarity: 0.45},
+		{Book: &domain.Book{ID: 5, ISBN: "tracked-5", Title: "Dune Messiah", TotalPages: 256, Author: "frank herbert"}, TitleSimilarity: 0.45},
+	}}
+	svc := NewCatalogService(repo, books, &mockAudiobookRepo{}, nil, nil)
+
+	duplicates, err := svc.FindBookDuplicates(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(duplicates) != 3 {
+		t.Fatalf("expected 3 duplicates, got %+v", duplicates)
+	}
+	if duplicates[0].Book.ID != 2 || duplicates[0].Confidence != 1 || !reflect.DeepEqual(duplicates[0].Reasons, []string{"title", "pages"}) {
+		t.Fatalf("unexpected top duplicate: %+v", duplicates[0])
+	}
+	if duplicates[1].Book.ID != 3 || !reflect.DeepEqual(duplicates[1].Reasons, []string{"isbn"}) {
+		t.Fatalf("expected ISBN-13 form of the same edition, got %+v", duplicates[1])
+	}
+	if duplicates[2].Book.ID != 5 || duplicates[2].Confidence != 0.6 {
+		t.Fatalf("expected author match to lift book 5 over the threshold, got %+v", duplicates[2])
+	}
+
+	if _, err := svc.FindBookDuplicates(context.Background(), 99); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCatalogService_FindAudiobookDuplicates(t *testing.T) {
+	length := func(d time.Duration) *domain.CustomDuration { return &domain.CustomDuration{Duration: d} }
+	audiobooks := &mockAudiobookRepo{Audiobooks: []domain.Audiobook{
+		{ID: 1, Title: "Dune", TotalLength: length(21*time.Hour + 2*time.Minute)},
+	}}
+	repo := &mockCatalogRepo{AudiobookCandidates: []domain.DuplicateCandidate{
+		{Audiobook: &domain.Audiobook{ID: 2, Title: "Dune", TotalLength: length(21 * time.Hour)}, TitleSimilarity: 0.5},
+		{Audiobook: &domain.Audiobook{ID: 3, Title: "Dune", TotalLength: length(11 * time.Hour)}, TitleSimilarity: 0.5},
+	}}
+	svc := NewCatalogService(repo, &mockBookRepo{}, audiobooks, nil, nil)
+
+	duplicates, err := svc.FindAudiobookDuplicates(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(duplicates) != 1 || duplicates[0].Audiobook.ID != 2 || duplicates[0].Confidence != 0.75 {
+		t.Fatalf("expected only the matching length, got %+v", duplicates)
+	}
+}
+
+func TestCatalogService_MergeBooks(t *testing.T) {
+	books := &mockBookRepo{Books: map[int]domain.Book{
+		1: {ID: 1, Title: "Dune"},
+		2: {ID: 2, Title: "Dune"},
+		3: {ID: 3, Title: "dune"},
+	}}
+	repo := &mockCatalogRepo{BookCandidates: []domain.DuplicateCandidate{
+		{Book: &domain.Book{ID: 2, Title: "Dune"}, TitleSimilarity: 1},
+		{Book: &domain.Book{ID: 3, Title: "dune"}, TitleSimilarity: 1},
+		{Book: &domain.Book{ID: 4, Title: "Dune Messiah"}, TitleSimilarity: 0.45},
+	}}
+	svc := NewCatalogService(repo, books, &mockAudiobookRepo{}, nil, nil)
+
+	result, err := svc.MergeBooks(context.Background(), 1, &domain.MergeRequest{DuplicateIDs: []int{2, 3}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.CanonicalID != 1 || repo.MergedInto != 1 || !reflect.DeepEqual(repo.Merged, []int{2, 3}) {
+		t.Fatalf("unexpected merge: %+v", result)
+	}
+
+	if _, err := svc.MergeBooks(context.Background(), 1, &domain.MergeRequest{DuplicateIDs: []int{1}}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for self-merge, got %v", err)
+	}
+	if _, err := svc.MergeBooks(context.Background(), 1, &domain.MergeRequest{DuplicateIDs: []int{2, 2}}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for repeated ids, got %v", err)
+	}
+	if _, err := svc.MergeBooks(context.Background(), 99, &domain.MergeRequest{DuplicateIDs: []int{2}}); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a missing canonical book, got %v", err)
+	}
+
+	repo.Merged = nil
+	for _, ids := range [][]int{{4}, {2, 99}} {
+		if _, err := svc.MergeBooks(context.Background(), 1, &domain.MergeRequest{DuplicateIDs: ids}); !apperrors.IsValidationError(err) {
+			t.Fatalf("expected validation error merging undetected %v, got %v", ids, err)
+		}
+	}
+	if repo.Merged != nil {
+		t.Fatalf("expected nothing merged, got %v", repo.Merged)
+	}
+}
+
+func TestCatalogService_MergeAudiobooks_RequiresDetectedDuplicates(t *testing.T) {
+	length := func(d time.Duration) *domain.CustomDuration { return &domain.CustomDuration{Duration: d} }
+	audiobooks := &mockAudiobookRepo{Audiobooks: []domain.Audiobook{
+		{ID: 1, Title: "Dune", TotalLength: length(21 * time.Hour)},
+	}}
+	repo := &mockCatalogRepo{AudiobookCandidates: []domain.DuplicateCandidate{
+		{Audiobook: &domain.Audiobook{ID: 2, Title: "Dune", TotalLength: length(21 * time.Hour)}, TitleSimilarity: 0.5},
+		{Audiobook: &domain.Audiobook{ID: 3, Title: "Dune", TotalLength: length(11 * time.Hour)}, TitleSimilarity: 0.5},
+	}}
+	svc := NewCatalogService(repo, &mockBookRepo{}, audiobooks, nil, nil)
+
+	if _, err := svc.MergeAudiobooks(context.Background(), 1, &domain.MergeRequest{DuplicateIDs: []int{3}}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for a different-length audiobook, got %v", err)
+	}
+	if _, err := svc.MergeAudiobooks(context.Background(), 1, &domain.MergeRequest{DuplicateIDs: []int{2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(repo.Merged, []int{2}) {
+		t.Fatalf("expected audiobook 2 merged, got %v", repo.Merged)
+	}
+}
+
+func TestIsbnCore(t *testing.T) {
+	tests := map[string]string{
+		"0-441-17271-7":     "044117271",
+		"978-0-441-17271-9": "044117271",
+		"044117271X":        "044117271",
+		"abc":               "",
+	}
+	for isbn, want := range tests {
+		if got := isbnCore(isbn); got != want {
+			t.Errorf("isbnCore(%q) = %q, want %q", isbn, got, want)
+		}
+	}
+}
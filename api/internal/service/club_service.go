@@ -0,0 +1,501 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrCheckpointLocked is returned when a member asks for a checkpoint thread
+// they have not read far enough to see without spoilers.
+var ErrCheckpointLocked error = &errors.Error{
+	Kind:    errors.ErrForbidden,
+	Code:    "checkpoint_locked",
+	Message: "read up to this checkpoint to unlock its discussion",
+}
+
+type ClubService interface {
+	GetAllByUser(ctx context.Context, userID int) ([]domain.Club, error)
+	GetByID(ctx context.Context, userID int, id int) (*domain.Club, error)
+	Create(ctx context.Context, club *domain.Club) (int, error)
+	Update(ctx context.Context, userID int, club *domain.Club) error
+	Delete(ctx context.Context, userID int, id int) error
+	GetMembers(ctx context.Context, userID int, clubID int) ([]domain.ClubMember, error)
+	RemoveMember(ctx context.Context, userID int, clubID int, memberID int) error
+	LinkProgress(ctx context.Context, userID int, clubID int, progressID *int) error
+	Invite(ctx context.Context, userID int, clubID int, inviteeID int) (*domain.ClubInvite, error)
+	GetInvites(ctx context.Context, userID int) ([]domain.ClubInvite, error)
+	RespondToInvite(ctx context.Context, userID int, inviteID int, accept bool) error
+	GetSchedule(ctx context.Context, userID int, clubID int) (*domain.ClubSchedule, error)
+	AddCheckpoint(ctx context.Context, userID int, clubID int, checkpoint *domain.Checkpoint) (int, error)
+	DeleteCheckpoint(ctx context.Context, userID int, clubID int, checkpointID int) error
+	GetPosts(ctx context.Context, userID int, clubID int, checkpointID int) ([]domain.ClubPost, error)
+	CreatePost(ctx context.Context, userID int, clubID int, checkpointID int, post *domain.ClubPost) (int, error)
+	NotifyOpenedCheckpoints(ctx context.Context) (int, error)
+}
+
+type clubService struct {
+	repo         repository.ClubRepo
+	bookRepo     repository.BookRepo
+	userRepo     repository.UserRepo
+	sseManager   *infra.SSEManager
+	progressRepo repository.ProgressRepo
+	tx           repository.TxManager
+	now          func() time.Time
+}
+
+func NewClubService(repo repository.ClubRepo, bookRepo repository.BookRepo, userRepo repository.UserRepo, sseManager *infra.SSEManager, progressRepo repository.ProgressRepo, tx repository.TxManager) ClubService {
+	return &clubService{
+		repo:         repo,
+		bookRepo:     bookRepo,
+		userRepo:     userRepo,
+		sseManager:   sseManager,
+		progressRepo: progressRepo,
+		tx:           tx,
+		now:          time.Now,
+	}
+}
+
+func (s *clubService) GetAllByUser(ctx context.Context, userID int) ([]domain.Club, error) {
+	return s.repo.GetAllByUser(ctx, userID)
+}
+
+func (s *clubService) GetByID(ctx context.Context, userID int, id int) (*domain.Club, error) {
+	return s.getClubAsMember(ctx, userID, id)
+}
+
+func (s *clubService) Create(ctx context.Context, club *domain.Club) (int, error) {
+	if err := club.Validate(); err != nil {
+		return 0, err
+	}
+	book, err := s.bookRepo.GetByID(ctx, club.BookID)
+	if err != nil {
+		return 0, err
+	}
+	if book == nil {
+		return 0, errors.ErrInvalidInput("book not found")
+	}
+	return s.repo.Create(ctx, club)
+}
+
+func (s *clubService) Update(ctx context.Context, userID int, club *domain.Club) error {
+	existing, err := s.getClubAsOwner(ctx, userID, club.ID)
+	if err != nil {
+		return err
+	}
+	// The book is fixed once the schedule exists; only name/description change.
+	club.OwnerID = existing.OwnerID
+	club.BookID = existing.BookID
+	if err := club.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, club)
+}
+
+func (s *clubService) Delete(ctx context.Context, userID int, id int) error {
+	if _, err := s.getClubAsOwner(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *clubService) GetMembers(ctx context.Context, userID int, clubID int) ([]domain.ClubMember, error) {
+	if _, err := s.getClubAsMember(ctx, userID, clubID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(ctx, clubID)
+}
+
+// RemoveMember lets the owner remove anyone else, and any member leave.
+func (s *clubService) RemoveMember(ctx context.Context, userID int, clubID int, memberID int) error {
+	club, err := s.getClubAsMember(ctx, userID, clubID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && club.OwnerID != userID {
+		return errors.ErrForbidden
+	}
+	if memberID == club.OwnerID {
+		return errors.ErrInvalidInput("the owner cannot leave the club; delete it instead")
+	}
+	return s.repo.RemoveMember(ctx, clubID, memberID)
+}
+
+// LinkProgress names the progress entry the spoiler gate reads a member's
+// position from, for when it isn't matched to the club's book on its own.
+func (s *clubService) LinkProgress(ctx context.Context, userID int, clubID int, progressID *int) error {
+	if _, err := s.getClubAsMember(ctx, userID, clubID); err != nil {
+		return err
+	}
+	if progressID != nil {
+		progress, err := s.progressRepo.GetByID(ctx, *progressID)
+		if err != nil {
+			return err
+		}
+		if progress == nil || progress.UserID != userID {
+			return errors.ErrInvalidField("progress_id", "must be one of your progress entries")
+		}
+	}
+	return s.repo.SetMemberProgress(ctx, clubID, userID, progressID)
+}
+
+func (s *clubService) Invite(ctx context.Context, userID int, clubID int, inviteeID int) (*domain.ClubInvite, error) {
+	club, err := s.getClubAsMember(ctx, userID, clubID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitee, err := s.userRepo.GetByID(ctx, inviteeID)
+	if err != nil {
+		return nil, err
+	}
+	if invitee == nil {
+		return nil, errors.ErrInvalidInput("invited user not found")
+	}
+	member, err := s.repo.GetMember(ctx, clubID, inviteeID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		return nil, errors.ErrConflict
+	}
+
+	invite := &domain.ClubInvite{
+		ClubID:    clubID,
+		ClubName:  club.Name,
+		InviterID: userID,
+		InviteeID: inviteeID,
+		Status:    domain.InvitePending,
+	}
+	id, err := s.repo.CreateInvite(ctx, invite)
+	if err != nil {
+		return nil, err
+	}
+	invite.ID = id
+	invite.CreatedAt = s.now()
+
+	if s.sseManager != nil {
+		s.sseManager.SendToUser(inviteeID, "club.invite", invite)
+	}
+	return invite, nil
+}
+
+func (s *clubService) GetInvites(ctx context.Context, userID int) ([]domain.ClubInvite, error) {
+	return s.repo.GetPendingInvites(ctx, userID)
+}
+
+func (s *clubService) RespondToInvite(ctx context.Context, userID int, inviteID int, accept bool) error {
+	invite, err := s.repo.GetInvite(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite == nil {
+		return errors.ErrNotFound
+	}
+	if invite.InviteeID != userID {
+		return errors.ErrForbidden
+	}
+	if invite.Status != domain.InvitePending {
+		return errors.ErrConflict
+	}
+
+	if !accept {
+		return s.repo.UpdateInviteStatus(ctx, inviteID, domain.InviteDeclined)
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddMember(ctx, invite.ClubID, userID, domain.ClubRoleMember); err != nil {
+			return err
+		}
+		return s.repo.UpdateInviteStatus(ctx, inviteID, domain.InviteAccepted)
+	})
+}
+
+func (s *clubService) GetSchedule(ctx context.Context, userID int, clubID int) (*domain.ClubSchedule, error) {
+	club, err := s.getClubAsMember(ctx, userID, clubID)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := s.getCheckpoints(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetMembers(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := s.memberPages(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &domain.ClubSchedule{
+		Club:        *club,
+		Checkpoints: checkpoints,
+		Members:     make([]domain.MemberSchedule, 0, len(members)),
+	}
+	if book, err := s.bookRepo.GetByID(ctx, club.BookID); err == nil && book != nil {
+		schedule.TotalPages = book.TotalPages
+	}
+
+	// The latest checkpoint that has opened is the one everyone should be at.
+	dueCheckpointPage := 0
+	for _, cp := range checkpoints {
+		if cp.IsOpen && cp.Page > dueCheckpointPage {
+			dueCheckpointPage = cp.Page
+		}
+	}
+
+	for _, member := range members {
+		ms := domain.MemberSchedule{
+			UserID:   member.UserID,
+			Username: member.Username,
+			Role:     member.Role,
+		}
+		page, hasPage := pages[member.UserID]
+		if hasPage {
+			p := page
+			ms.CurrentPage = &p
+		}
+		for _, cp := range checkpoints {
+			if hasPage && page >= cp.Page {
+				id := cp.ID
+				ms.ReachedCheckpointID = &id
+			}
+		}
+		ms.OnSchedule = dueCheckpointPage == 0 || (hasPage && page >= dueCheckpointPage)
+		schedule.Members = append(schedule.Members, ms)
+	}
+	return schedule, nil
+}
+
+func (s *clubService) AddCheckpoint(ctx context.Context, userID int, clubID int, checkpoint *domain.Checkpoint) (int, error) {
+	club, err := s.getClubAsOwner(ctx, userID, clubID)
+	if err != nil {
+		return 0, err
+	}
+
+	totalPages := 0
+	book, err := s.bookRepo.GetByID(ctx, club.BookID)
+	if err != nil {
+		return 0, err
+	}
+	if book != nil {
+		totalPages = book.TotalPages
+	}
+	checkpoint.ApplyDefaults()
+	if err := checkpoint.Validate(totalPages); err != nil {
+		return 0, err
+	}
+	if checkpoint.Kind == domain.CheckpointChapter {
+		existing, err := s.repo.GetCheckpoints(ctx, clubID)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkChapterOrder(checkpoint, existing); err != nil {
+			return 0, err
+		}
+	}
+
+	checkpoint.ClubID = clubID
+	checkpoint.IsOpen = !checkpoint.OpensAt.After(s.now())
+	return s.repo.CreateCheckpoint(ctx, checkpoint)
+}
+
+func (s *clubService) DeleteCheckpoint(ctx context.Context, userID int, clubID int, checkpointID int) error {
+	if _, err := s.getClubAsOwner(ctx, userID, clubID); err != nil {
+		return err
+	}
+	if _, err := s.getClubCheckpoint(ctx, clubID, checkpointID); err != nil {
+		return err
+	}
+	return s.repo.DeleteCheckpoint(ctx, checkpointID)
+}
+
+func (s *clubService) GetPosts(ctx context.Context, userID int, clubID int, checkpointID int) ([]domain.ClubPost, error) {
+	if err := s.checkThreadAccess(ctx, userID, clubID, checkpointID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPosts(ctx, checkpointID)
+}
+
+func (s *clubService) CreatePost(ctx context.Context, userID int, clubID int, checkpointID int, post *domain.ClubPost) (int, error) {
+	if err := post.Validate(); err != nil {
+		return 0, err
+	}
+	if err := s.checkThreadAccess(ctx, userID, clubID, checkpointID); err != nil {
+		return 0, err
+	}
+	post.CheckpointID = checkpointID
+	post.UserID = userID
+	return s.repo.CreatePost(ctx, post)
+}
+
+// NotifyOpenedCheckpoints pushes a club.checkpoint_opened event to every member
+// of each club whose checkpoint has come due since the last run.
+func (s *clubService) NotifyOpenedCheckpoints(ctx context.Context) (int, error) {
+	due, err := s.repo.GetDueCheckpoints(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, cp := range due {
+		claimed, err := s.repo.MarkCheckpointNotified(ctx, cp.ID)
+		if err != nil {
+			return notified, err
+		}
+		if !claimed {
+			continue
+		}
+		notified++
+
+		if s.sseManager == nil {
+			continue
+		}
+		club, err := s.repo.GetByID(ctx, cp.ClubID)
+		if err != nil {
+			return notified, err
+		}
+		if club == nil {
+			continue
+		}
+		members, err := s.repo.GetMembers(ctx, cp.ClubID)
+		if err != nil {
+			return notified, err
+		}
+
+		cp.IsOpen = true
+		event := domain.CheckpointOpenedEvent{ClubID: club.ID, ClubName: club.Name, Checkpoint: cp}
+		for _, member := range members {
+			s.sseManager.SendToUser(member.UserID, "club.checkpoint_opened", event)
+		}
+	}
+	return notified, nil
+}
+
+// checkThreadAccess is the spoiler gate: members only see a checkpoint's
+// discussion once their progress in the club's book reaches its page.
+func (s *clubService) checkThreadAccess(ctx context.Context, userID int, clubID int, checkpointID int) error {
+	if _, err := s.getClubAsMember(ctx, userID, clubID); err != nil {
+		return err
+	}
+	checkpoint, err := s.getClubCheckpoint(ctx, clubID, checkpointID)
+	if err != nil {
+		return err
+	}
+	pages, err := s.memberPages(ctx, clubID)
+	if err != nil {
+		return err
+	}
+	if pages[userID] < checkpoint.Page {
+		return ErrCheckpointLocked
+	}
+	return nil
+}
+
+// checkChapterOrder rejects a chapter checkpoint that ends before an earlier
+// chapter or after a later one, which would gate the threads out of order.
+func checkChapterOrder(checkpoint *domain.Checkpoint, existing []domain.Checkpoint) error {
+	for _, cp := range existing {
+		if cp.Kind != domain.CheckpointChapter || cp.Chapter == nil {
+			continue
+		}
+		if (*cp.Chapter < *checkpoint.Chapter && cp.Page >= checkpoint.Page) ||
+			(*cp.Chapter > *checkpoint.Chapter && cp.Page <= checkpoint.Page) {
+			return errors.ErrInvalidField("page", fmt.Sprintf("conflicts with chapter %d, which ends on page %d", *cp.Chapter, cp.Page))
+		}
+	}
+	return nil
+}
+
+// memberPages returns each member's furthest page in the club's book, keyed
+// by user ID. A linked audiobook's time counts through timestampToPage, so
+// listeners aren't held back by a page that was never filled in, say because
+// the book's page count arrived after they started. Members with no progress
+// on the book are absent.
+func (s *clubService) memberPages(ctx context.Context, clubID int) (map[int]int, error) {
+	positions, err := s.repo.GetMemberPositions(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[int]int)
+	for _, pos := range positions {
+		page := 0
+		if pos.BookPage != nil {
+			page = *pos.BookPage
+		}
+		if pos.AudiobookTime != nil && pos.TotalLength != nil {
+			if listened, err := timestampToPage(pos.TotalPages, pos.AudiobookTime.Duration, pos.TotalLength.Duration); err == nil && listened > page {
+				page = listened
+			}
+		}
+		if page > pages[pos.UserID] {
+			pages[pos.UserID] = page
+		}
+	}
+	return pages, nil
+}
+
+func (s *clubService) getCheckpoints(ctx context.Context, clubID int) ([]domain.Checkpoint, error) {
+	checkpoints, err := s.repo.GetCheckpoints(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	for i := range checkpoints {
+		checkpoints[i].IsOpen = !checkpoints[i].OpensAt.After(now)
+	}
+	if checkpoints == nil {
+		checkpoints = []domain.Checkpoint{}
+	}
+	return checkpoints, nil
+}
+
+func (s *clubService) getClubCheckpoint(ctx context.Context, clubID int, checkpointID int) (*domain.Checkpoint, error) {
+	checkpoint, err := s.repo.GetCheckpoint(ctx, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil || checkpoint.ClubID != clubID {
+		return nil, errors.ErrNotFound
+	}
+	return checkpoint, nil
+}
+
+func (s *clubService) getClubAsMember(ctx context.Context, userID int, clubID int) (*domain.Club, error) {
+	club, err := s.repo.GetByID(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+	if club == nil {
+		return nil, errors.ErrNotFound
+	}
+	member, err := s.repo.GetMember(ctx, clubID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, errors.ErrForbidden
+	}
+	return club, nil
+}
+
+func (s *clubService) getClubAsOwner(ctx context.Context, userID int, clubID int) (*domain.Club, error) {
+	club, err := s.repo.GetByID(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+	if club == nil {
+		return nil, errors.ErrNotFound
+	}
+	if club.OwnerID != userID {
+		return nil, errors.ErrForbidden
+	}
+	return club, nil
+}
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+	"book_boy/api/internal/repository"
+)
+
+type mockClubRepo struct {
+	Clubs       map[int]domain.Club
+	Members     map[int]map[int]domain.ClubRole
+	Pages       map[int]int
+	Positions   []repository.MemberPosition
+	Links       map[[2]int]*int
+	Invites     map[int]domain.ClubInvite
+	Checkpoints map[int]domain.Checkpoint
+	Notified    map[int]bool
+	Posts       []domain.ClubPost
+	Err         error
+}
+
+func newMockClubRepo() *mockClubRepo {
+	return &mockClubRepo{
+		Clubs:       map[int]domain.Club{},
+		Members:     map[int]map[int]domain.ClubRole{},
+		Pages:       map[int]int{},
+		Links:       map[[2]int]*int{},
+		Invites:     map[int]domain.ClubInvite{},
+		Checkpoints: map[int]domain.Checkpoint{},
+		Notified:    map[int]bool{},
+	}
+}
+
+func (m *mockClubRepo) GetAllByUser(ctx context.Context, userID int) ([]domain.Club, error) {
+	var clubs []domain.Club
+	for id, club := range m.Clubs {
+		if _, ok := m.Members[id][userID]; ok {
+			clubs = append(clubs, club)
+		}
+	}
+	return clubs, m.Err
+}
+
+func (m *mockClubRepo) GetByID(ctx context.Context, id int) (*domain.Club, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	club, ok := m.Clubs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &club, nil
+}
+
+func (m *mockClubRepo) Create(ctx context.Context, club *domain.Club) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	id := len(m.Clubs) + 1
+	club.ID = id
+	m.Clubs[id] = *club
+	m.Members[id] = map[int]domain.ClubRole{club.OwnerID: domain.ClubRoleOwner}
+	return id, nil
+}
+
+func (m *mockClubRepo) Update(ctx context.Context, club *domain.Club) error {
+	m.Clubs[club.ID] = *club
+	return m.Err
+}
+
+func (m *mockClubRepo) Delete(ctx context.Context, id int) error {
+	delete(m.Clubs, id)
+	return m.Err
+}
+
+func (m *mockClubRepo) GetMembers(ctx context.Context, clubID int) ([]domain.ClubMember, error) {
+	var members []domain.ClubMember
+	for userID, role := range m.Members[clubID] {
+		members = append(members, domain.ClubMember{ClubID: clubID, UserID: userID, Role: role})
+	}
+	return members, m.Err
+}
+
+func (m *mockClubRepo) GetMember(ctx context.Context, clubID int, userID int) (*domain.ClubMember, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	role, ok := m.Members[clubID][userID]
+	if !ok {
+		return nil, nil
+	}
+	return &domain.ClubMember{ClubID: clubID, UserID: userID, Role: role}, nil
+}
+
+func (m *mockClubRepo) AddMember(ctx context.Context, clubID int, userID int, role domain.ClubRole) error {
+	if m.Members[clubID] == nil {
+		m.Members[clubID] = map[int]domain.ClubRole{}
+	}
+	m.Members[clubID][userID] = role
+	return m.Err
+}
+
+func (m *mockClubRepo) RemoveMember(ctx context.Context, clubID int, userID int) error {
+	delete(m.Members[clubID], userID)
+	return m.Err
+}
+
+func (m *mockClubRepo) SetMemberProgress(ctx context.Context, clubID int, userID int, progressID *int) error {
+	m.Links[[2]int{clubID, userID}] = progressID
+	return m.Err
+}
+
+// GetMemberPositions reports Pages as book pages, alongside any Positions.
+func (m *mockClubRepo) GetMemberPositions(ctx context.Context, clubID int) ([]repository.MemberPosition, error) {
+	var positions []repository.MemberPosition
+	for userID := range m.Members[clubID] {
+		if page, ok := m.Pages[userID]; ok {
+			positions = append(positions, repository.MemberPosition{UserID: userID, BookPage: &page, TotalPages: 300})
+		}
+	}
+	for _, pos := range m.Positions {
+		if _, ok := m.Members[clubID][pos.UserID]; ok {
+			positions = append(positions, pos)
+		}
+	}
+	return positions, m.Err
+}
+
+func (m *mockClubRepo) CreateInvite(ctx context.Context, invite *domain.ClubInvite) (int, error) {
+	id := len(m.Invites) + 1
+	invite.ID = id
+	m.Invites[id] = *invite
+	return id, m.Err
+}
+
+func (m *mockClubRepo) GetInvite(ctx context.Context, id int) (*domain.ClubInvite, error) {
+	invite, ok := m.Invites[id]
+	if !ok {
+		return nil, m.Err
+	}
+	return &invite, m.Err
+}
+
+func (m *mockClubRepo) GetPendingInvites(ctx context.Context, userID int) ([]domain.ClubInvite, error) {
+	var invites []domain.ClubInvite
+	for _, invite := range m.Invites {
+		if invite.InviteeID == userID && invite.Status == domain.InvitePending {
+			invites = append(invites, invite)
+		}
+	}
+	return invites, m.Err
+}
+
+func (m *mockClubRepo) UpdateInviteStatus(ctx context.Context, id int, status domain.InviteStatus) error {
+	invite := m.Invites[id]
+	invite.Status = status
+	m.Invites[id] = invite
+	return m.Err
+}
+
+func (m *mockClubRepo) GetCheckpoints(ctx context.Context, clubID int) ([]domain.Checkpoint, error) {
+	var checkpoints []domain.Checkpoint
+	for _, cp := range m.Checkpoints {
+		if cp.ClubID == clubID {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	return checkpoints, m.Err
+}
+
+func (m *mockClubRepo) GetCheckpoint(ctx context.Context, id int) (*domain.Checkpoint, error) {
+	cp, ok := m.Checkpoints[id]
+	if !ok {
+		return nil, m.Err
+	}
+	return &cp, m.Err
+}
+
+func (m *mockClubRepo) CreateCheckpoint(ctx context.Context, checkpoint *domain.Checkpoint) (int, error) {
+	id := len(m.Checkpoints) + 1
+	checkpoint.ID = id
+	m.Checkpoints[id] = *checkpoint
+	return id, m.Err
+}
+
+func (m *mockClubRepo) DeleteCheckpoint(ctx context.Context, id int) error {
+	delete(m.Checkpoints, id)
+	return m.Err
+}
+
+func (m *mockClubRepo) GetDueCheckpoints(ctx context.Context, now time.Time) ([]domain.Checkpoint, error) {
+	var due []domain.Checkpoint
+	for id, cp := range m.Checkpoints {
+		if !m.Notified[id] && !cp.OpensAt.After(now) {
+			due = append(due, cp)
+		}
+	}
+	return due, m.Err
+}
+
+func (m *mockClubRepo) MarkCheckpointNotified(ctx context.Context, id int) (bool, error) {
+	if m.Notified[id] {
+		return false, m.Err
+	}
+	m.Notified[id] = true
+	return true, m.Err
+}
+
+func (m *mockClubRepo) GetPosts(ctx context.Context, checkpointID int) ([]domain.ClubPost, error) {
+	var posts []domain.ClubPost
+	for _, post := range m.Posts {
+		if post.CheckpointID == checkpointID {
+			posts = append(posts, post)
+		}
+	}
+	return posts, m.Err
+}
+
+func (m *mockClubRepo) CreatePost(ctx context.Context, post *domain.ClubPost) (int, error) {
+	post.ID = len(m.Posts) + 1
+	m.Posts = append(m.Posts, *post)
+	return post.ID, m.Err
+}
+
+var clubTestNow = time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+// newClubTestService sets up club 1 on a 300 page book, owned by user 1 with
+// user 2 as a member, and checkpoints at page 100 (open) and 200 (upcoming).
+func newClubTestService() (*clubService, *mockClubRepo) {
+	repo := newMockClubRepo()
+	repo.Clubs[1] = domain.Club{ID: 1, OwnerID: 1, BookID: 1, Name: "Sci-fi club"}
+	repo.Members[1] = map[int]domain.ClubRole{1: domain.ClubRoleOwner, 2: domain.ClubRoleMember}
+	repo.Checkpoints[1] = domain.Checkpoint{ID: 1, ClubID: 1, Label: "Part one", Page: 100, OpensAt: clubTestNow.Add(-24 * time.Hour)}
+	repo.Checkpoints[2] = domain.Checkpoint{ID: 2, ClubID: 1, Label: "Part two", Page: 200, OpensAt: clubTestNow.Add(7 * 24 * time.Hour)}
+
+	bookRepo := &mockBookRepo{Books: map[int]domain.Book{1: {ID: 1, Title: "Dune", TotalPages: 300}}}
+	userRepo := &mockUserRepo{Users: map[int]domain.User{
+		1: {ID: 1, Username: "owner"},
+		2: {ID: 2, Username: "member"},
+		3: {ID: 3, Username: "outsider"},
+	}}
+
+	progressRepo := &mockProgressRepo{Data: map[int]domain.Progress{
+		1: {ID: 1, UserID: 2},
+		2: {ID: 2, UserID: 3},
+	}}
+
+	svc := NewClubService(repo, bookRepo, userRepo, nil, progressRepo, &mockTxManager{}).(*clubService)
+	svc.now = func() time.Time { return clubTestNow }
+	return svc, repo
+}
+
+func TestClubService_Create(t *testing.T) {
+	svc, repo := newClubTestService()
+
+	id, err := svc.Create(context.Background(), &domain.Club{OwnerID: 3, BookID: 1, Name: "Book night"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Members[id][3] != domain.ClubRoleOwner {
+		t.Fatal("expected creator to be the owner member")
+	}
+
+	if _, err := svc.Create(context.Background(), &domain.Club{OwnerID: 3, BookID: 99, Name: "Missing book"}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for missing book, got %v", err)
+	}
+}
+
+func TestClubService_MembershipRequired(t *testing.T) {
+	svc, _ := newClubTestService()
+
+	if _, err := svc.GetByID(context.Background(), 3, 1); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.GetSchedule(context.Background(), 3, 1); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.AddCheckpoint(context.Background(), 2, 1, &domain.Checkpoint{Label: "x", Page: 50, OpensAt: clubTestNow}); err != apperrors.ErrForbidden {
+		t.Fatalf("expected members to be unable to add checkpoints, got %v", err)
+	}
+}
+
+func TestClubService_InviteFlow(t *testing.T) {
+	svc, repo := newClubTestService()
+
+	invite, err := svc.Invite(context.Background(), 2, 1, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Invite(context.Background(), 1, 1, 2); err != apperrors.ErrConflict {
+		t.Fatalf("expected ErrConflict inviting an existing member, got %v", err)
+	}
+	if err := svc.RespondToInvite(context.Background(), 2, invite.ID, true); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for someone else's invite, got %v", err)
+	}
+
+	if err := svc.RespondToInvite(context.Background(), 3, invite.ID, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := repo.Members[1][3]; !ok {
+		t.Fatal("expected invitee to become a member")
+	}
+	if err := svc.RespondToInvite(context.Background(), 3, invite.ID, true); err != apperrors.ErrConflict {
+		t.Fatalf("expected ErrConflict answering twice, got %v", err)
+	}
+	if tx := svc.tx.(*mockTxManager); tx.Committed != 1 {
+		t.Fatalf("expected accepting to join and mark the invite in one unit of work, got %+v", tx)
+	}
+}
+
+func TestClubService_LinkProgress(t *testing.T) {
+	svc, repo := newClubTestService()
+	ctx := context.Background()
+	progressID := 1
+
+	if err := svc.LinkProgress(ctx, 2, 1, &progressID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if link := repo.Links[[2]int{1, 2}]; link == nil || *link != 1 {
+		t.Fatalf("expected member 2 linked to progress 1, got %v", link)
+	}
+
+	otherID := 2
+	if err := svc.LinkProgress(ctx, 2, 1, &otherID); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for someone else's progress, got %v", err)
+	}
+	if err := svc.LinkProgress(ctx, 3, 1, &otherID); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for a non-member, got %v", err)
+	}
+
+	if err := svc.LinkProgress(ctx, 2, 1, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Links[[2]int{1, 2}] != nil {
+		t.Fatal("expected the link to be cleared")
+	}
+}
+
+func TestClubService_RemoveMember(t *testing.T) {
+	svc, repo := newClubTestService()
+	repo.Members[1][3] = domain.ClubRoleMember
+
+	if err := svc.RemoveMember(context.Background(), 2, 1, 3); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for a member removing another, got %v", err)
+	}
+	if err := svc.RemoveMember(context.Background(), 1, 1, 1); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for owner leaving, got %v", err)
+	}
+	if err := svc.RemoveMember(context.Background(), 3, 1, 3); err != nil {
+		t.Fatalf("unexpected error leaving: %v", err)
+	}
+	if _, ok := repo.Members[1][3]; ok {
+		t.Fatal("expected member to have left")
+	}
+}
+
+func TestClubService_GetSchedule(t *testing.T) {
+	svc, repo := newClubTestService()
+	repo.Pages[1] = 150
+
+	schedule, err := svc.GetSchedule(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schedule.TotalPages != 300 || len(schedule.Checkpoints) != 2 {
+		t.Fatalf("unexpected schedule: %+v", schedule)
+	}
+
+	for _, ms := range schedule.Members {
+		switch ms.UserID {
+		case 1:
+			if !ms.OnSchedule || ms.ReachedCheckpointID == nil || *ms.ReachedCheckpointID != 1 {
+				t.Errorf("expected owner on schedule at checkpoint 1, got %+v", ms)
+			}
+		case 2:
+			if ms.OnSchedule || ms.CurrentPage != nil {
+				t.Errorf("expected member without progress to be behind, got %+v", ms)
+			}
+		}
+	}
+}
+
+func TestClubService_SpoilerGate(t *testing.T) {
+	svc, repo := newClubTestService()
+	repo.Pages[1] = 150
+	repo.Pages[2] = 40
+
+	if _, err := svc.CreatePost(context.Background(), 1, 1, 1, &domain.ClubPost{Body: "That ending!"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.GetPosts(context.Background(), 2, 1, 1); err != ErrCheckpointLocked {
+		t.Fatalf("expected ErrCheckpointLocked, got %v", err)
+	}
+	if _, err := svc.GetPosts(context.Background(), 1, 1, 2); err != ErrCheckpointLocked {
+		t.Fatalf("expected ErrCheckpointLocked for checkpoint beyond position, got %v", err)
+	}
+
+	repo.Pages[2] = 100
+	posts, err := svc.GetPosts(context.Background(), 2, 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+}
+
+func TestClubService_SpoilerGate_CountsAudiobookTime(t *testing.T) {
+	svc, repo := newClubTestService()
+	// Member 2 listens along; their progress never got a page because the
+	// book's page count wasn't known when they started.
+	repo.Positions = []repository.MemberPosition{{
+		UserID:        2,
+		AudiobookTime: &domain.CustomDuration{Duration: 5 * time.Hour},
+		TotalPages:    300,
+		TotalLength:   &domain.CustomDuration{Duration: 10 * time.Hour},
+	}}
+
+	if _, err := svc.GetPosts(context.Background(), 2, 1, 1); err != nil {
+		t.Fatalf("expected halfway through the audiobook to unlock page 100, got %v", err)
+	}
+	if _, err := svc.GetPosts(context.Background(), 2, 1, 2); err != ErrCheckpointLocked {
+		t.Fatalf("expected ErrCheckpointLocked for page 200, got %v", err)
+	}
+
+	schedule, err := svc.GetSchedule(context.Background(), 2, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ms := range schedule.Members {
+		if ms.UserID == 2 && (ms.CurrentPage == nil || *ms.CurrentPage != 151) {
+			t.Errorf("expected the listener at page 151, got %+v", ms)
+		}
+	}
+}
+
+func TestClubService_AddCheckpoint_Kinds(t *testing.T) {
+	svc, repo := newClubTestService()
+	ctx := context.Background()
+	chapter := func(n int) *int { return &n }
+
+	id, err := svc.AddCheckpoint(ctx, 1, 1, &domain.Checkpoint{Kind: domain.CheckpointChapter, Chapter: chapter(5), Page: 120, OpensAt: clubTestNow})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp := repo.Checkpoints[id]; cp.Label != "Chapter 5" {
+		t.Errorf("expected the label to default to the chapter, got %q", cp.Label)
+	}
+
+	id, err = svc.AddCheckpoint(ctx, 1, 1, &domain.Checkpoint{Page: 150, OpensAt: clubTestNow})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp := repo.Checkpoints[id]; cp.Kind != domain.CheckpointPage || cp.Label != "Page 150" {
+		t.Errorf("expected a page checkpoint by default, got %+v", cp)
+	}
+
+	if _, err := svc.AddCheckpoint(ctx, 1, 1, &domain.Checkpoint{Kind: domain.CheckpointChapter, Page: 160, OpensAt: clubTestNow}); !apperrors.IsValidationError(err) {
+		t.Errorf("expected a chapter checkpoint without a chapter to be rejected, got %v", err)
+	}
+	if _, err := svc.AddCheckpoint(ctx, 1, 1, &domain.Checkpoint{Kind: domain.CheckpointPage, Chapter: chapter(2), Page: 160, OpensAt: clubTestNow}); !apperrors.IsValidationError(err) {
+		t.Errorf("expected a page checkpoint with a chapter to be rejected, got %v", err)
+	}
+	if _, err := svc.AddCheckpoint(ctx, 1, 1, &domain.Checkpoint{Kind: domain.CheckpointChapter, Chapter: chapter(6), Page: 110, OpensAt: clubTestNow}); !apperrors.IsValidationError(err) {
+		t.Errorf("expected chapter 6 ending before chapter 5 to be rejected, got %v", err)
+	}
+}
+
+func TestClubService_NotifyOpenedCheckpoints(t *testing.T) {
+	svc, _ := newClubTestService()
+
+	count, err := svc.NotifyOpenedCheckpoints(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 opened checkpoint, got %d", count)
+	}
+
+	count, _ = svc.NotifyOpenedCheckpoints(context.Background())
+	if count != 0 {
+		t.Fatalf("expected checkpoint to be notified only once, got %d", count)
+	}
+
+	svc.now = func() time.Time { return clubTestNow.Add(8 * 24 * time.Hour) }
+	count, _ = svc.NotifyOpenedCheckpoints(context.Background())
+	if count != 1 {
+		t.Fatalf("expected the second checkpoint to open later, got %d", count)
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/repository"
+	"context"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// goalMilestones are the completion percentages that trigger a notification.
+var goalMilestones = []int{25, 50, 75, 100}
+
+// goalPaceTolerance is the share of the target a user can be off the expected
+// pace and still count as on track.
+const goalPaceTolerance = 0.02
+
+type GoalService interface {
+	GetAllByUser(ctx context.Context, userID int) ([]domain.Goal, error)
+	GetByID(ctx context.Context, userID int, id int) (*domain.Goal, error)
+	Create(ctx context.Context, goal *domain.Goal) (int, error)
+	Update(ctx context.Context, userID int, goal *domain.Goal) error
+	Delete(ctx context.Context, userID int, id int) error
+	RecordProgressDelta(ctx context.Context, activity *domain.ProgressActivity) error
+}
+
+type goalService struct {
+	repo       repository.GoalRepo
+	sseManager *infra.SSEManager
+	now        func() time.Time
+}
+
+func NewGoalService(repo repository.GoalRepo, sseManager *infra.SSEManager) GoalService {
+	return &goalService{
+		repo:       repo,
+		sseManager: sseManager,
+		now:        time.Now,
+	}
+}
+
+func (s *goalService) GetAllByUser(ctx context.Context, userID int) ([]domain.Goal, error) {
+	goals, err := s.repo.GetAllByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range goals {
+		progress, err := s.progressFor(ctx, &goals[i])
+		if err != nil {
+			return nil, err
+		}
+		goals[i].Progress = progress
+	}
+	return goals, nil
+}
+
+func (s *goalService) GetByID(ctx context.Context, userID int, id int) (*domain.Goal, error) {
+	goal, err := s.getOwnedGoal(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.progressFor(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+	goal.Progress = progress
+	return goal, nil
+}
+
+func (s *goalService) Create(ctx context.Context, goal *domain.Goal) (int, error) {
+	if err := goal.Validate(); err != nil {
+		return 0, err
+	}
+	if err := s.checkDuplicate(ctx, goal); err != nil {
+		return 0, err
+	}
+	return s.repo.Create(ctx, goal)
+}
+
+func (s *goalService) Update(ctx context.Context, userID int, goal *domain.Goal) error {
+	if err := goal.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.getOwnedGoal(ctx, userID, goal.ID); err != nil {
+		return err
+	}
+	goal.UserID = userID
+	if err := s.checkDuplicate(ctx, goal); err != nil {
+		return err
+	}
+
+	// Changing the target or window moves the percentage, so re-baseline the
+	// milestone to wherever the goal now stands instead of replaying old ones.
+	progress, err := s.progressFor(ctx, goal)
+	if err != nil {
+		return err
+	}
+	goal.LastMilestone = reachedMilestone(progress.Percent)
+	goal.Progress = progress
+	return s.repo.Update(ctx, goal)
+}
+
+func (s *goalService) Delete(ctx context.Context, userID int, id int) error {
+	if _, err := s.getOwnedGoal(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// RecordProgressDelta stores a dated position change and notifies the user of
+// any goal milestones it pushed them past.
+func (s *goalService) RecordProgressDelta(ctx context.Context, activity *domain.ProgressActivity) error {
+	if activity.PagesDelta == 0 && activity.SecondsDelta == 0 && !activity.Finished {
+		return nil
+	}
+	if err := s.repo.RecordActivity(ctx, activity); err != nil {
+		return err
+	}
+	return s.checkMilestones(ctx, activity.UserID)
+}
+
+// recordGoalActivity feeds a position change into reading goals once ctx's unit
+// of work commits, so a write that rolls back neither counts nor announces a
+// milestone, and a failed goal write can't abort the transaction. Goal
+// tracking is best effort and never fails the write itself.
+func recordGoalActivity(ctx context.Context, goals GoalService, logger *slog.Logger, activity *domain.ProgressActivity) {
+	if goals == nil {
+		return
+	}
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		if err := goals.RecordProgressDelta(context.WithoutCancel(ctx), activity); err != nil {
+			logger.WarnContext(ctx, "failed to record reading activity", "progress_id", activity.ProgressID, "error", err)
+		}
+	})
+}
+
+func (s *goalService) checkMilestones(ctx context.Context, userID int) error {
+	goals, err := s.repo.GetAllByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	for i := range goals {
+		goal := &goals[i]
+		start, end := goal.Bounds(time.UTC)
+		if now.Before(start) || !now.Before(end) || goal.LastMilestone >= 100 {
+			continue
+		}
+
+		progress, err := s.progressFor(ctx, goal)
+		if err != nil {
+			return err
+		}
+		milestone := reachedMilestone(progress.Percent)
+		if milestone <= goal.LastMilestone {
+			continue
+		}
+
+		if err := s.repo.UpdateLastMilestone(ctx, goal.ID, milestone); err != nil {
+			return err
+		}
+		goal.LastMilestone = milestone
+		goal.Progress = progress
+
+		if s.sseManager != nil {
+			s.sseManager.SendToUser(userID, "goal.milestone", domain.GoalMilestoneEvent{
+				GoalID:    goal.ID,
+				Milestone: milestone,
+				Goal:      *goal,
+				Progress:  *progress,
+			})
+		}
+	}
+	return nil
+}
+
+func (s *goalService) progressFor(ctx context.Context, goal *domain.Goal) (*domain.GoalProgress, error) {
+	start, end := goal.Bounds(time.UTC)
+	totals, err := s.repo.SumActivity(ctx, goal.UserID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return computeGoalProgress(goal, totals, s.now()), nil
+}
+
+func (s *goalService) getOwnedGoal(ctx context.Context, userID int, id int) (*domain.Goal, error) {
+	goal, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if goal == nil {
+		return nil, errors.ErrNotFound
+	}
+	if goal.UserID != userID {
+		return nil, errors.ErrForbidden
+	}
+	return goal, nil
+}
+
+// checkDuplicate rejects a second goal for the same metric and period window.
+func (s *goalService) checkDuplicate(ctx context.Context, goal *domain.Goal) error {
+	existing, err := s.repo.GetAllByUser(ctx, goal.UserID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ID == goal.ID || other.Period != goal.Period || other.Metric != goal.Metric || other.Year != goal.Year {
+			continue
+		}
+		if goal.Month == nil || (other.Month != nil && *other.Month == *goal.Month) {
+			return errors.ErrConflict
+		}
+	}
+	return nil
+}
+
+// computeGoalProgress compares what the user has done so far against a
+// straight-line pace through the goal's period.
+func computeGoalProgress(goal *domain.Goal, totals *domain.ActivityTotals, now time.Time) *domain.GoalProgress {
+	var current float64
+	switch goal.Metric {
+	case domain.GoalMetricBooks:
+		current = float64(totals.BooksFinished)
+	case domain.GoalMetricPages:
+		current = float64(totals.Pages)
+	case domain.GoalMetricListeningHours:
+		current = roundTo2(float64(totals.Seconds) / 3600)
+	}
+	if current < 0 {
+		current = 0
+	}
+
+	target := float64(goal.Target)
+	percent := int(current / target * 100)
+	if percent > 100 {
+		percent = 100
+	}
+
+	start, end := goal.Bounds(time.UTC)
+	now = now.UTC()
+	var elapsed float64
+	switch {
+	case now.Before(start):
+		elapsed = 0
+	case !now.Before(end):
+		elapsed = 1
+	default:
+		elapsed = float64(now.Sub(start)) / float64(end.Sub(start))
+	}
+
+	expected := roundTo2(target * elapsed)
+	difference := roundTo2(current - expected)
+
+	pace := domain.GoalPaceOnTrack
+	switch {
+	case current >= target:
+		pace = domain.GoalPaceCompleted
+	case difference > target*goalPaceTolerance:
+		pace = domain.GoalPaceAhead
+	case difference < -target*goalPaceTolerance:
+		pace = domain.GoalPaceBehind
+	}
+
+	return &domain.GoalProgress{
+		Current:       current,
+		Target:        goal.Target,
+		Percent:       percent,
+		ExpectedByNow: expected,
+		Difference:    difference,
+		Pace:          pace,
+	}
+}
+
+func reachedMilestone(percent int) int {
+	reached := 0
+	for _, m := range goalMilestones {
+		if percent >= m {
+			reached = m
+		}
+	}
+	return reached
+}
+
+func roundTo2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockGoalRepo struct {
+	Goals      map[int]domain.Goal
+	Totals     domain.ActivityTotals
+	Activities []domain.ProgressActivity
+	Milestones map[int]int
+	Err        error
+}
+
+func (m *mockGoalRepo) GetAllByUser(ctx context.Context, userID int) ([]domain.Goal, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var goals []domain.Goal
+	for _, goal := range m.Goals {
+		if goal.UserID == userID {
+			goals = append(goals, goal)
+		}
+	}
+	return goals, nil
+}
+
+func (m *mockGoalRepo) GetByID(ctx context.Context, id int) (*domain.Goal, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	goal, ok := m.Goals[id]
+	if !ok {
+		return nil, nil
+	}
+	return &goal, nil
+}
+
+func (m *mockGoalRepo) Create(ctx context.Context, goal *domain.Goal) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	id := len(m.Goals) + 1
+	goal.ID = id
+	m.Goals[id] = *goal
+	return id, nil
+}
+
+func (m *mockGoalRepo) Update(ctx context.Context, goal *domain.Goal) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Goals[goal.ID] = *goal
+	return nil
+}
+
+func (m *mockGoalRepo) Delete(ctx context.Context, id int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	delete(m.Goals, id)
+	return nil
+}
+
+func (m *mockGoalRepo) UpdateLastMilestone(ctx context.Context, id int, milestone int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	if m.Milestones == nil {
+		m.Milestones = map[int]int{}
+	}
+	m.Milestones[id] = milestone
+	goal := m.Goals[id]
+	goal.LastMilestone = milestone
+	m.Goals[id] = goal
+	return nil
+}
+
+func (m *mockGoalRepo) RecordActivity(ctx context.Context, activity *domain.ProgressActivity) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Activities = append(m.Activities, *activity)
+	m.Totals.Pages += activity.PagesDelta
+	m.Totals.Seconds += activity.SecondsDelta
+	if activity.Finished {
+		m.Totals.BooksFinished++
+	}
+	return nil
+}
+
+func (m *mockGoalRepo) SumActivity(ctx context.Context, userID int, from time.Time, to time.Time) (*domain.ActivityTotals, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	totals := m.Totals
+	return &totals, nil
+}
+
+func fixedNow(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func TestGoal_Validate(t *testing.T) {
+	month := 3
+	tests := []struct {
+		name    string
+		goal    domain.Goal
+		wantErr bool
+	}{
+		{"yearly books", domain.Goal{Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Target: 12, Year: 2026}, false},
+		{"monthly pages", domain.Goal{Period: domain.GoalPeriodMonthly, Metric: domain.GoalMetricPages, Target: 500, Year: 2026, Month: &month}, false},
+		{"monthly without month", domain.Goal{Period: domain.GoalPeriodMonthly, Metric: domain.GoalMetricPages, Target: 500, Year: 2026}, true},
+		{"yearly with month", domain.Goal{Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Target: 12, Year: 2026, Month: &month}, true},
+		{"zero target", domain.Goal{Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Year: 2026}, true},
+		{"unknown metric", domain.Goal{Period: domain.GoalPeriodYearly, Metric: "words", Target: 1, Year: 2026}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.goal.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputeGoalProgress(t *testing.T) {
+	// Halfway through a non-leap year, by the clock.
+	mid := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC).Add(365 * 24 * time.Hour / 2)
+	goal := &domain.Goal{Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Target: 20, Year: 2026}
+
+	tests := []struct {
+		name        string
+		finished    int
+		wantPercent int
+		wantPace    domain.GoalPace
+	}{
+		{"ahead", 15, 75, domain.GoalPaceAhead},
+		{"on track", 10, 50, domain.GoalPaceOnTrack},
+		{"behind", 4, 20, domain.GoalPaceBehind},
+		{"completed", 22, 100, domain.GoalPaceCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := computeGoalProgress(goal, &domain.ActivityTotals{BooksFinished: tt.finished}, mid)
+			if p.Percent != tt.wantPercent {
+				t.Errorf("Percent = %d, want %d", p.Percent, tt.wantPercent)
+			}
+			if p.Pace != tt.wantPace {
+				t.Errorf("Pace = %s, want %s", p.Pace, tt.wantPace)
+			}
+			if p.ExpectedByNow != 10 {
+				t.Errorf("ExpectedByNow = %v, want 10", p.ExpectedByNow)
+			}
+		})
+	}
+
+	t.Run("listening hours", func(t *testing.T) {
+		hours := &domain.Goal{Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricListeningHours, Target: 100, Year: 2026}
+		p := computeGoalProgress(hours, &domain.ActivityTotals{Seconds: 90 * 60}, mid)
+		if p.Current != 1.5 {
+			t.Errorf("Current = %v, want 1.5", p.Current)
+		}
+	})
+}
+
+func TestGoalService_CreateRejectsDuplicate(t *testing.T) {
+	repo := &mockGoalRepo{Goals: map[int]domain.Goal{
+		1: {ID: 1, UserID: 1, Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Target: 12, Year: 2026},
+	}}
+	svc := NewGoalService(repo, nil)
+
+	_, err := svc.Create(context.Background(), &domain.Goal{UserID: 1, Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Target: 20, Year: 2026})
+	if err != apperrors.ErrConflict {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	if _, err := svc.Create(context.Background(), &domain.Goal{UserID: 1, Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricPages, Target: 5000, Year: 2026}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGoalService_Ownership(t *testing.T) {
+	repo := &mockGoalRepo{Goals: map[int]domain.Goal{
+		1: {ID: 1, UserID: 2, Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricBooks, Target: 12, Year: 2026},
+	}}
+	svc := NewGoalService(repo, nil)
+
+	if _, err := svc.GetByID(context.Background(), 1, 1); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := svc.Delete(context.Background(), 1, 1); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.GetByID(context.Background(), 1, 99); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGoalService_RecordProgressDeltaMilestones(t *testing.T) {
+	repo := &mockGoalRepo{Goals: map[int]domain.Goal{
+		1: {ID: 1, UserID: 1, Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricPages, Target: 1000, Year: 2026},
+		2: {ID: 2, UserID: 1, Period: domain.GoalPeriodYearly, Metric: domain.GoalMetricPages, Target: 1000, Year: 2025},
+	}}
+	svc := NewGoalService(repo, nil).(*goalService)
+	svc.now = fixedNow(time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC))
+
+	if err := svc.RecordProgressDelta(context.Background(), &domain.ProgressActivity{UserID: 1, ProgressID: 1, PagesDelta: 300}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Milestones[1] != 25 {
+		t.Fatalf("expected milestone 25, got %d", repo.Milestones[1])
+	}
+	if _, ok := repo.Milestones[2]; ok {
+		t.Fatal("expected goal outside its period to be skipped")
+	}
+
+	if err := svc.RecordProgressDelta(context.Background(), &domain.ProgressActivity{UserID: 1, ProgressID: 1, PagesDelta: 500}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Milestones[1] != 75 {
+		t.Fatalf("expected milestone 75, got %d", repo.Milestones[1])
+	}
+
+	if err := svc.RecordProgressDelta(context.Background(), &domain.ProgressActivity{UserID: 1, ProgressID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.Activities) != 2 {
+		t.Fatalf("expected empty delta to be skipped, got %d activities", len(repo.Activities))
+	}
+}
+
+func TestProgressService_RecordsGoalActivity(t *testing.T) {
+	bookID := 1
+	bookPage := 450
+	progressRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 7, BookID: &bookID, BookPage: &bookPage},
+		},
+	}
+	goalRepo := &mockGoalRepo{Goals: map[int]domain.Goal{}}
+	svc := NewProgressService(progressRepo, NewGoalService(goalRepo, nil), nil, nil, nil)
+
+	if _, err := svc.UpdateProgressPage(context.Background(), 1, 500, domain.UpdateCondition{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(goalRepo.Activities) != 1 {
+		t.Fatalf("expected 1 activity, got %d", len(goalRepo.Activities))
+	}
+	activity := goalRepo.Activities[0]
+	if activity.UserID != 7 || activity.PagesDelta != 50 || !activity.Finished {
+		t.Fatalf("unexpected activity: %+v", activity)
+	}
+}
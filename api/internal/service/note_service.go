@@ -0,0 +1,147 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/repository"
+	"context"
+	"strings"
+)
+
+const (
+	defaultNoteSearchLimit = 20
+	maxNoteSearchLimit     = 100
+)
+
+type NoteService interface {
+	GetByProgress(ctx context.Context, userID int, progressID int) ([]domain.Note, error)
+	GetByID(ctx context.Context, userID int, progressID int, noteID int) (*domain.Note, error)
+	Create(ctx context.Context, userID int, progressID int, note *domain.Note) (int, error)
+	Update(ctx context.Context, userID int, progressID int, note *domain.Note) error
+	Delete(ctx context.Context, userID int, progressID int, noteID int) error
+	Search(ctx context.Context, userID int, query string, limit int) ([]domain.NoteSearchResult, error)
+	GetTags(ctx context.Context, userID int, progressID int) ([]string, error)
+	SetTags(ctx context.Context, userID int, progressID int, tags []string) ([]string, error)
+}
+
+type noteService struct {
+	repo         repository.NoteRepo
+	progressRepo repository.ProgressRepo
+}
+
+func NewNoteService(repo repository.NoteRepo, progressRepo repository.ProgressRepo) NoteService {
+	return &noteService{repo: repo, progressRepo: progressRepo}
+}
+
+func (s *noteService) GetByProgress(ctx context.Context, userID int, progressID int) ([]domain.Note, error) {
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByProgress(ctx, progressID)
+}
+
+func (s *noteService) GetByID(ctx context.Context, userID int, progressID int, noteID int) (*domain.Note, error) {
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return nil, err
+	}
+	return s.getProgressNote(ctx, progressID, noteID)
+}
+
+func (s *noteService) Create(ctx context.Context, userID int, progressID int, note *domain.Note) (int, error) {
+	if err := note.Validate(); err != nil {
+		return 0, err
+	}
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return 0, err
+	}
+	note.ProgressID = progressID
+	note.UserID = userID
+	return s.repo.Create(ctx, note)
+}
+
+func (s *noteService) Update(ctx context.Context, userID int, progressID int, note *domain.Note) error {
+	if err := note.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return err
+	}
+	existing, err := s.getProgressNote(ctx, progressID, note.ID)
+	if err != nil {
+		return err
+	}
+	note.ProgressID = existing.ProgressID
+	note.UserID = existing.UserID
+	note.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, note)
+}
+
+func (s *noteService) Delete(ctx context.Context, userID int, progressID int, noteID int) error {
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return err
+	}
+	if _, err := s.getProgressNote(ctx, progressID, noteID); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, noteID)
+}
+
+func (s *noteService) Search(ctx context.Context, userID int, query string, limit int) ([]domain.NoteSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.ErrInvalidInput("search query cannot be empty")
+	}
+	if limit <= 0 {
+		limit = defaultNoteSearchLimit
+	}
+	if limit > maxNoteSearchLimit {
+		limit = maxNoteSearchLimit
+	}
+	return s.repo.Search(ctx, userID, query, limit)
+}
+
+func (s *noteService) GetTags(ctx context.Context, userID int, progressID int) ([]string, error) {
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTags(ctx, progressID)
+}
+
+func (s *noteService) SetTags(ctx context.Context, userID int, progressID int, tags []string) ([]string, error) {
+	normalized, err := domain.NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProgressOwner(ctx, userID, progressID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTags(ctx, progressID, normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func (s *noteService) checkProgressOwner(ctx context.Context, userID int, progressID int) error {
+	progress, err := s.progressRepo.GetByID(ctx, progressID)
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		return errors.ErrNotFound
+	}
+	if progress.UserID != userID {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
+func (s *noteService) getProgressNote(ctx context.Context, progressID int, noteID int) (*domain.Note, error) {
+	note, err := s.repo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil || note.ProgressID != progressID {
+		return nil, errors.ErrNotFound
+	}
+	return note, nil
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockNoteRepo struct {
+	Notes       map[int]domain.Note
+	Tags        map[int][]string
+	Err         error
+	LastSearch  string
+	LastLimit   int
+	LastDeleted int
+}
+
+func (m *mockNoteRepo) GetByProgress(ctx context.Context, progressID int) ([]domain.Note, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var notes []domain.Note
+	for _, note := range m.Notes {
+		if note.ProgressID == progressID {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+func (m *mockNoteRepo) GetByID(ctx context.Context, id int) (*domain.Note, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	note, ok := m.Notes[id]
+	if !ok {
+		return nil, nil
+	}
+	return &note, nil
+}
+
+func (m *mockNoteRepo) Create(ctx context.Context, note *domain.Note) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	id := len(m.Notes) + 100
+	note.ID = id
+	m.Notes[id] = *note
+	return id, nil
+}
+
+func (m *mockNoteRepo) Update(ctx context.Context, note *domain.Note) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Notes[note.ID] = *note
+	return nil
+}
+
+func (m *mockNoteRepo) Delete(ctx context.Context, id int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	delete(m.Notes, id)
+	m.LastDeleted = id
+	return nil
+}
+
+func (m *mockNoteRepo) Search(ctx context.Context, userID int, query string, limit int) ([]domain.NoteSearchResult, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.LastSearch = query
+	m.LastLimit = limit
+	return []domain.NoteSearchResult{}, nil
+}
+
+func (m *mockNoteRepo) GetTags(ctx context.Context, progressID int) ([]string, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Tags[progressID], nil
+}
+
+func (m *mockNoteRepo) SetTags(ctx context.Context, progressID int, tags []string) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Tags[progressID] = tags
+	return nil
+}
+
+func newNoteTestService() (NoteService, *mockNoteRepo) {
+	bookID := 1
+	progressRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID},
+			2: {ID: 2, UserID: 2, BookID: &bookID},
+		},
+	}
+	noteRepo := &mockNoteRepo{
+		Notes: map[int]domain.Note{
+			10: {ID: 10, ProgressID: 1, UserID: 1, Kind: domain.NoteKindNote, Body: "loved chapter 3"},
+			20: {ID: 20, ProgressID: 2, UserID: 2, Kind: domain.NoteKindNote, Body: "not yours"},
+		},
+		Tags: map[int][]string{},
+	}
+	return NewNoteService(noteRepo, progressRepo), noteRepo
+}
+
+func TestNoteService_GetByProgress(t *testing.T) {
+	svc, _ := newNoteTestService()
+
+	notes, err := svc.GetByProgress(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notes) != 1 || notes[0].ID != 10 {
+		t.Fatalf("expected note 10, got %+v", notes)
+	}
+
+	if _, err := svc.GetByProgress(context.Background(), 1, 2); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.GetByProgress(context.Background(), 1, 999); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNoteService_GetByID_WrongProgress(t *testing.T) {
+	svc, _ := newNoteTestService()
+
+	if _, err := svc.GetByID(context.Background(), 1, 1, 20); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for note on another progress, got %v", err)
+	}
+}
+
+func TestNoteService_Create(t *testing.T) {
+	svc, repo := newNoteTestService()
+
+	t.Run("note defaults kind", func(t *testing.T) {
+		note := &domain.Note{Body: "a thought"}
+		id, err := svc.Create(context.Background(), 1, 1, note)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stored := repo.Notes[id]
+		if stored.Kind != domain.NoteKindNote || stored.ProgressID != 1 || stored.UserID != 1 {
+			t.Fatalf("unexpected stored note: %+v", stored)
+		}
+	})
+
+	t.Run("highlight anchored to page", func(t *testing.T) {
+		page := 143
+		note := &domain.Note{Kind: domain.NoteKindHighlight, Body: "quote...", Page: &page}
+		if _, err := svc.Create(context.Background(), 1, 1, note); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("highlight anchored to time", func(t *testing.T) {
+		note := &domain.Note{
+			Kind:          domain.NoteKindHighlight,
+			Body:          "quote...",
+			AudiobookTime: &domain.CustomDuration{Duration: 90 * time.Minute},
+		}
+		if _, err := svc.Create(context.Background(), 1, 1, note); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("highlight without anchor", func(t *testing.T) {
+		note := &domain.Note{Kind: domain.NoteKindHighlight, Body: "quote..."}
+		if _, err := svc.Create(context.Background(), 1, 1, note); !apperrors.IsValidationError(err) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		if _, err := svc.Create(context.Background(), 1, 1, &domain.Note{Body: "   "}); !apperrors.IsValidationError(err) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("other user's progress", func(t *testing.T) {
+		if _, err := svc.Create(context.Background(), 1, 2, &domain.Note{Body: "sneaky"}); err != apperrors.ErrForbidden {
+			t.Fatalf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
+
+func TestNoteService_UpdateAndDelete(t *testing.T) {
+	svc, repo := newNoteTestService()
+
+	if err := svc.Update(context.Background(), 1, 1, &domain.Note{ID: 10, Body: "edited"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Notes[10].Body != "edited" || repo.Notes[10].ProgressID != 1 {
+		t.Fatalf("expected note edited in place, got %+v", repo.Notes[10])
+	}
+
+	if err := svc.Delete(context.Background(), 1, 1, 20); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound deleting note from another progress, got %v", err)
+	}
+	if err := svc.Delete(context.Background(), 1, 1, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastDeleted != 10 {
+		t.Errorf("expected note 10 deleted, got %d", repo.LastDeleted)
+	}
+}
+
+func TestNoteService_Search(t *testing.T) {
+	svc, repo := newNoteTestService()
+
+	if _, err := svc.Search(context.Background(), 1, "  ", 0); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for empty query, got %v", err)
+	}
+
+	if _, err := svc.Search(context.Background(), 1, " dune spice ", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastSearch != "dune spice" || repo.LastLimit != defaultNoteSearchLimit {
+		t.Fatalf("expected trimmed query and default limit, got %q/%d", repo.LastSearch, repo.LastLimit)
+	}
+
+	if _, err := svc.Search(context.Background(), 1, "dune", 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastLimit != maxNoteSearchLimit {
+		t.Fatalf("expected limit capped at %d, got %d", maxNoteSearchLimit, repo.LastLimit)
+	}
+}
+
+func TestNoteService_SetTags(t *testing.T) {
+	svc, repo := newNoteTestService()
+
+	tags, err := svc.SetTags(context.Background(), 1, 1, []string{" Sci-Fi ", "classic", "sci-fi", ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "sci-fi" || tags[1] != "classic" {
+		t.Fatalf("expected normalized tags [sci-fi classic], got %v", tags)
+	}
+	if len(repo.Tags[1]) != 2 {
+		t.Fatalf("expected tags stored, got %v", repo.Tags[1])
+	}
+
+	if _, err := svc.SetTags(context.Background(), 1, 2, []string{"x"}); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
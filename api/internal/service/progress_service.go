@@ -2,180 +2,504 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/logging"
 	"book_boy/api/internal/repository"
+	"context"
 	"fmt"
+	"log/slog"
+	"time"
 )
 
 type ProgressService interface {
-	GetAll() ([]domain.Progress, error)
-	GetByID(id int) (*domain.Progress, error)
-	GetByIDWithCompletion(id int) (*domain.Progress, error)
-	Create(progress *domain.Progress) (int, error)
-	Update(progress *domain.Progress) error
-	Delete(id int) error
-	UpdateProgressPage(id int, bookPage int) error
-	UpdateProgressTime(id int, audiobookTime *domain.CustomDuration) error
-	SetBook(id int, bookID int) error
-	SetAudiobook(id int, audiobookID int) error
-	FilterProgress(filter repository.ProgressFilter) ([]domain.Progress, error)
-	GetAllEnrichedByUser(userID int) ([]domain.EnrichedProgress, error)
+	GetAll(ctx context.Context) ([]domain.Progress, error)
+	GetByID(ctx context.Context, id int) (*domain.Progress, error)
+	GetByIDWithCompletion(ctx context.Context, id int) (*domain.Progress, error)
+	Create(ctx context.Context, progress *domain.Progress) (int, error)
+	Update(ctx context.Context, progress *domain.Progress) error
+	Delete(ctx context.Context, id int) error
+	UpdateProgressPage(ctx context.Context, id int, bookPage int, cond domain.UpdateCondition) (*domain.Progress, error)
+	UpdateProgressTime(ctx context.Context, id int, audiobookTime *domain.CustomDuration, cond domain.UpdateCondition) (*domain.Progress, error)
+	SetBook(ctx context.Context, id int, bookID int, cond domain.UpdateCondition) (*domain.Progress, error)
+	SetAudiobook(ctx context.Context, id int, audiobookID int, cond domain.UpdateCondition) (*domain.Progress, error)
+	FilterProgress(ctx context.Context, filter repository.ProgressFilter) ([]domain.Progress, error)
+	GetAllEnrichedByUser(ctx context.Context, userID int) ([]domain.EnrichedProgress, error)
+	GetAllEnrichedByUserShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error)
+	UpdateBatch(ctx context.Context, userID int, req *domain.ProgressBatchRequest) (*domain.ProgressBatchResponse, error)
 }
 
 type progressService struct {
-	repo repository.ProgressRepo
+	repo   repository.ProgressRepo
+	goals  GoalService
+	social SocialService
+	views  ProgressViews
+	logger *slog.Logger
 }
 
-func NewProgressService(repo repository.ProgressRepo) ProgressService {
-	return &progressService{repo: repo}
+func NewProgressService(repo repository.ProgressRepo, goals GoalService, social SocialService, views ProgressViews, logger *slog.Logger) ProgressService {
+	return &progressService{repo: repo, goals: goals, social: social, views: views, logger: logging.OrDiscard(logger)}
 }
 
-func (s *progressService) GetAll() ([]domain.Progress, error) {
-	return s.repo.GetAll()
+func (s *progressService) GetAll(ctx context.Context) ([]domain.Progress, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *progressService) GetByID(id int) (*domain.Progress, error) {
-	return s.repo.GetByID(id)
+func (s *progressService) GetByID(ctx context.Context, id int) (*domain.Progress, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *progressService) GetByIDWithCompletion(id int) (*domain.Progress, error) {
-	progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(id)
-	if err != nil {
+func (s *progressService) GetByIDWithCompletion(ctx context.Context, id int) (*domain.Progress, error) {
+	progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(ctx, id)
+	if err != nil || progress == nil {
 		return nil, err
 	}
 	progress.CompletionPercent = calculateCompletionPercent(progress, totalPages, totalLength)
 	return progress, nil
 }
 
-func (s *progressService) Create(progress *domain.Progress) (int, error) {
+func (s *progressService) Create(ctx context.Context, progress *domain.Progress) (int, error) {
 	if err := progress.Validate(); err != nil {
 		return 0, err
 	}
-	return s.repo.Create(progress)
+	id, err := s.repo.Create(ctx, progress)
+	if err != nil {
+		return 0, err
+	}
+
+	progress.ID = id
+	progressChanged(ctx, s.views, progress.UserID)
+	s.publishActivity(ctx, domain.ActivityStarted, progress)
+	return id, nil
 }
 
-func (s *progressService) Update(progress *domain.Progress) error {
+func (s *progressService) Update(ctx context.Context, progress *domain.Progress) error {
 	if err := progress.Validate(); err != nil {
 		return err
 	}
-	return s.repo.Update(progress)
+	if err := s.repo.Update(ctx, progress); err != nil {
+		return err
+	}
+	progressChanged(ctx, s.views, progress.UserID)
+	return nil
 }
 
-func (s *progressService) Delete(id int) error {
-	return s.repo.Delete(id)
+// Delete drops the owner's cached views once the row is gone; dropping them
+// first would let a read in between cache the deleted entry again.
+func (s *progressService) Delete(ctx context.Context, id int) error {
+	var progress *domain.Progress
+	if s.views != nil {
+		var err error
+		if progress, err = s.repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if progress != nil {
+		progressChanged(ctx, s.views, progress.UserID)
+	}
+	return nil
 }
 
-func (s *progressService) UpdateProgressPage(id, bookPage int) error {
-	progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(id)
+// maxPositionAttempts bounds how often a position write re-reads after losing
+// a race with another writer.
+const maxPositionAttempts = 3
+
+func (s *progressService) UpdateProgressPage(ctx context.Context, id, bookPage int, cond domain.UpdateCondition) (*domain.Progress, error) {
+	var before domain.Progress
+	var totalPages int
+	progress, written, err := s.writePosition(ctx, id, cond, func(p *domain.Progress, pages int, length *domain.CustomDuration) (bool, error) {
+		before, totalPages = *p, pages
+		if err := movePage(p, bookPage, pages, length); err != nil {
+			return false, err
+		}
+		if cond.Mode == domain.MergeModeFurthest && *p.BookPage <= pageOf(&before) {
+			*p = before
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil || !written {
+		return progress, err
+	}
+	s.recordPageMove(ctx, &before, progress, totalPages)
+	return progress, nil
+}
+
+func (s *progressService) UpdateProgressTime(ctx context.Context, progressID int, audiobookTime *domain.CustomDuration, cond domain.UpdateCondition) (*domain.Progress, error) {
+	var before domain.Progress
+	var totalLength *domain.CustomDuration
+	progress, written, err := s.writePosition(ctx, progressID, cond, func(p *domain.Progress, pages int, length *domain.CustomDuration) (bool, error) {
+		before, totalLength = *p, length
+		moveTime(p, audiobookTime, pages, length)
+		if cond.Mode == domain.MergeModeFurthest && audiobookTime.Duration <= timeOf(&before) {
+			*p = before
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil || !written {
+		return progress, err
+	}
+	s.recordTimeMove(ctx, &before, progress, totalLength)
+	return progress, nil
+}
+
+// UpdateBatch applies page, time and "completed" updates to the user's entries.
+// Other users' entries count as not found.
+func (s *progressService) UpdateBatch(ctx context.Context, userID int, req *domain.ProgressBatchRequest) (*domain.ProgressBatchResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Atomic {
+		return s.updateBatchAtomic(ctx, userID, req.Updates)
+	}
+
+	resp := &domain.ProgressBatchResponse{Results: make([]domain.ProgressBatchResult, len(req.Updates))}
+	for i, item := range req.Updates {
+		result := domain.ProgressBatchResult{Index: i, ProgressID: item.ProgressID}
+		progress, err := s.applyBatchItem(ctx, userID, item)
+		if err != nil {
+			result.Error = err.Error()
+			resp.Failed++
+		} else {
+			result.Progress = progress
+			resp.Applied++
+		}
+		resp.Results[i] = result
+	}
+	return resp, nil
+}
+
+func (s *progressService) applyBatchItem(ctx context.Context, userID int, item domain.ProgressBatchItem) (*domain.Progress, error) {
+	current, totalPages, totalLength, err := s.repo.GetByIDWithTotals(ctx, item.ProgressID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if current == nil || current.UserID != userID {
+		return nil, errors.ErrNotFound
 	}
-	if progress == nil || progress.BookID == nil || totalPages <= 0 {
-		return fmt.Errorf("book info missing for conversion")
+
+	switch {
+	case item.Page != nil:
+		return s.UpdateProgressPage(ctx, item.ProgressID, *item.Page, domain.UpdateCondition{})
+	case item.AudiobookTime != nil:
+		return s.UpdateProgressTime(ctx, item.ProgressID, item.AudiobookTime, domain.UpdateCondition{})
+	case current.BookID != nil && totalPages > 0:
+		return s.UpdateProgressPage(ctx, item.ProgressID, totalPages, domain.UpdateCondition{})
+	case current.AudiobookID != nil && totalLength != nil && totalLength.Duration > 0:
+		return s.UpdateProgressTime(ctx, item.ProgressID, totalLength, domain.UpdateCondition{})
 	}
+	return nil, errors.ErrInvalidInput("book info missing for conversion")
+}
 
-	if bookPage < 1 {
-		bookPage = 1
+// updateBatchAtomic moves every entry in memory with the same conversions as
+// the single updates, then writes them in one transaction. Any item that
+// can't apply fails the whole batch before anything is written.
+func (s *progressService) updateBatchAtomic(ctx context.Context, userID int, items []domain.ProgressBatchItem) (*domain.ProgressBatchResponse, error) {
+	type loaded struct {
+		progress    *domain.Progress
+		totalPages  int
+		totalLength *domain.CustomDuration
 	}
-	if bookPage > totalPages {
-		bookPage = totalPages
+	type move struct {
+		before, after domain.Progress
+		entry         *loaded
+		byPage        bool
 	}
-	progress.BookPage = &bookPage
 
-	if totalLength != nil && totalLength.Duration > 0 {
-		ts, _ := pageToTimestamp(totalPages, bookPage, totalLength.Duration)
-		cd := domain.CustomDuration{Duration: ts}
-		progress.AudiobookTime = &cd
+	entries := make(map[int]*loaded)
+	var order []*domain.Progress
+	moves := make([]move, len(items))
+	for i, item := range items {
+		entry, ok := entries[item.ProgressID]
+		if !ok {
+			progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(ctx, item.ProgressID)
+			if err != nil {
+				return nil, err
+			}
+			if progress == nil || progress.UserID != userID {
+				return nil, errors.ErrInvalidInput(fmt.Sprintf("updates[%d]: progress not found", i))
+			}
+			entry = &loaded{progress: progress, totalPages: totalPages, totalLength: totalLength}
+			entries[item.ProgressID] = entry
+			order = append(order, progress)
+		}
+
+		p := entry.progress
+		moves[i] = move{before: *p, entry: entry}
+		var err error
+		switch {
+		case item.Page != nil:
+			err = movePage(p, *item.Page, entry.totalPages, entry.totalLength)
+			moves[i].byPage = true
+		case item.AudiobookTime != nil:
+			moveTime(p, item.AudiobookTime, entry.totalPages, entry.totalLength)
+		case p.BookID != nil && entry.totalPages > 0:
+			err = movePage(p, entry.totalPages, entry.totalPages, entry.totalLength)
+			moves[i].byPage = true
+		case p.AudiobookID != nil && entry.totalLength != nil && entry.totalLength.Duration > 0:
+			moveTime(p, entry.totalLength, entry.totalPages, entry.totalLength)
+		default:
+			err = errors.ErrInvalidInput("book info missing for conversion")
+		}
+		if err != nil {
+			return nil, errors.ErrInvalidInput(fmt.Sprintf("updates[%d]: %v", i, err))
+		}
+		moves[i].after = *p
 	}
 
-	return s.repo.Update(progress)
+	if err := s.repo.UpdatePositions(ctx, order); err != nil {
+		return nil, err
+	}
+	progressChanged(ctx, s.views, userID)
+
+	resp := &domain.ProgressBatchResponse{Applied: len(items), Results: make([]domain.ProgressBatchResult, len(items))}
+	for i, m := range moves {
+		if m.byPage {
+			s.recordPageMove(ctx, &m.before, &m.after, m.entry.totalPages)
+		} else {
+			s.recordTimeMove(ctx, &m.before, &m.after, m.entry.totalLength)
+		}
+		resp.Results[i] = domain.ProgressBatchResult{Index: i, ProgressID: items[i].ProgressID, Progress: m.entry.progress}
+	}
+	return resp, nil
 }
 
-func (s *progressService) UpdateProgressTime(progressID int, audiobookTime *domain.CustomDuration) error {
-	pr, totalPages, totalLength, err := s.repo.GetByIDWithTotals(progressID)
-	if err != nil {
-		return err
+// movePage sets the page, clamped to the book, and moves the audiobook time to
+// match when the audiobook's length is known.
+func movePage(p *domain.Progress, bookPage int, totalPages int, totalLength *domain.CustomDuration) error {
+	if p.BookID == nil || totalPages <= 0 {
+		return errors.ErrInvalidInput("book info missing for conversion")
+	}
+	page := bookPage
+	if page < 1 {
+		page = 1
 	}
-	if pr == nil {
-		return fmt.Errorf("book info missing for conversion")
+	if page > totalPages {
+		page = totalPages
 	}
+	p.BookPage = &page
 
-	pr.AudiobookTime = audiobookTime
+	if totalLength != nil && totalLength.Duration > 0 {
+		ts, _ := pageToTimestamp(totalPages, page, totalLength.Duration)
+		p.AudiobookTime = &domain.CustomDuration{Duration: ts}
+	}
+	return nil
+}
 
-	if pr.BookID != nil && totalPages > 0 && totalLength != nil && totalLength.Duration > 0 {
+// moveTime sets the audiobook time and moves the page to match when a book is
+// linked and both totals are known.
+func moveTime(p *domain.Progress, audiobookTime *domain.CustomDuration, totalPages int, totalLength *domain.CustomDuration) {
+	p.AudiobookTime = audiobookTime
+	if p.BookID != nil && totalPages > 0 && totalLength != nil && totalLength.Duration > 0 {
 		page, _ := timestampToPage(totalPages, audiobookTime.Duration, totalLength.Duration)
-		pr.BookPage = &page
+		p.BookPage = &page
 	}
+}
 
-	return s.repo.Update(pr)
+func pageOf(p *domain.Progress) int {
+	if p.BookPage == nil {
+		return 0
+	}
+	return *p.BookPage
 }
 
-func (s *progressService) SetBook(id int, bookID int) error {
-	progress, err := s.repo.GetByID(id)
-	if err != nil {
-		return err
+func timeOf(p *domain.Progress) time.Duration {
+	if p.AudiobookTime == nil {
+		return 0
 	}
-	if progress == nil {
-		return fmt.Errorf("progress not found")
+	return p.AudiobookTime.Duration
+}
+
+func (s *progressService) recordPageMove(ctx context.Context, before, after *domain.Progress, totalPages int) {
+	oldPage, newPage := pageOf(before), pageOf(after)
+	finished := oldPage < totalPages && newPage == totalPages
+	s.recordActivity(ctx, &domain.ProgressActivity{
+		UserID:     after.UserID,
+		ProgressID: after.ID,
+		PagesDelta: newPage - oldPage,
+		Finished:   finished,
+	})
+	if finished {
+		s.publishActivity(ctx, domain.ActivityFinished, after)
+	}
+}
+
+func (s *progressService) recordTimeMove(ctx context.Context, before, after *domain.Progress, totalLength *domain.CustomDuration) {
+	oldTime, newTime := timeOf(before), timeOf(after)
+	finished := false
+	if totalLength != nil && totalLength.Duration > 0 {
+		finished = oldTime < totalLength.Duration && newTime >= totalLength.Duration
+	}
+	s.recordActivity(ctx, &domain.ProgressActivity{
+		UserID:       after.UserID,
+		ProgressID:   after.ID,
+		SecondsDelta: int((newTime - oldTime) / time.Second),
+		Finished:     finished,
+	})
+	if finished {
+		s.publishActivity(ctx, domain.ActivityFinished, after)
+	}
+}
+
+// writePosition reads the progress with its totals, lets apply move the
+// position, and writes it back guarded by the version it read. apply returns
+// false to leave the row untouched. A write that loses a race is retried from a
+// fresh read, unless the caller pinned a version with IfMatch in strict mode,
+// in which case the conflict is reported as ErrPreconditionFailed.
+func (s *progressService) writePosition(ctx context.Context, id int, cond domain.UpdateCondition, apply func(p *domain.Progress, totalPages int, totalLength *domain.CustomDuration) (bool, error)) (*domain.Progress, bool, error) {
+	pinned := cond.IfMatch > 0 && cond.Mode != domain.MergeModeFurthest
+	for attempt := 1; ; attempt++ {
+		progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(ctx, id)
+		if err != nil {
+			return nil, false, err
+		}
+		if progress == nil {
+			return nil, false, errors.ErrNotFound
+		}
+		if pinned && progress.Version != cond.IfMatch {
+			return nil, false, errors.ErrPreconditionFailed
+		}
+
+		changed, err := apply(progress, totalPages, totalLength)
+		if err != nil {
+			return nil, false, err
+		}
+		if !changed {
+			return progress, false, nil
+		}
+
+		err = s.repo.Update(ctx, progress)
+		if err == errors.ErrPreconditionFailed && !pinned && attempt < maxPositionAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		progressChanged(ctx, s.views, progress.UserID)
+		return progress, true, nil
+	}
+}
+
+func (s *progressService) recordActivity(ctx context.Context, activity *domain.ProgressActivity) {
+	recordGoalActivity(ctx, s.goals, s.logger, activity)
+}
+
+func (s *progressService) publishActivity(ctx context.Context, activityType domain.ActivityType, progress *domain.Progress) {
+	progressID := progress.ID
+	publishActivity(ctx, s.social, s.logger, &domain.ActivityEvent{
+		UserID:      progress.UserID,
+		Type:        activityType,
+		ProgressID:  &progressID,
+		BookID:      progress.BookID,
+		AudiobookID: progress.AudiobookID,
+	})
+}
+
+// SetBook links a book and derives the page from the audiobook time when both
+// totals are known. Both writes are guarded by the version they read, and by
+// cond.IfMatch when the caller pinned one; it returns the entry as written.
+func (s *progressService) SetBook(ctx context.Context, id int, bookID int, cond domain.UpdateCondition) (*domain.Progress, error) {
+	progress, err := s.getPinned(ctx, id, cond)
+	if err != nil {
+		return nil, err
 	}
 
 	progress.BookID = &bookID
 
-	if err := s.repo.Update(progress); err != nil {
-		return err
+	if err := s.repo.Update(ctx, progress); err != nil {
+		return nil, err
 	}
+	progressChanged(ctx, s.views, progress.UserID)
 
-	progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(id)
+	linked, totalPages, totalLength, err := s.repo.GetByIDWithTotals(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	// The totals come from the new link; the version must stay the one just
+	// written, so a write that slipped in since fails instead of being lost.
+	linked.Version = progress.Version
 
-	if progress.AudiobookTime != nil && totalPages > 0 && totalLength != nil && totalLength.Duration > 0 {
-		page, err := timestampToPage(totalPages, progress.AudiobookTime.Duration, totalLength.Duration)
+	if linked.AudiobookTime != nil && totalPages > 0 && totalLength != nil && totalLength.Duration > 0 {
+		page, err := timestampToPage(totalPages, linked.AudiobookTime.Duration, totalLength.Duration)
 		if err == nil {
-			progress.BookPage = &page
-			return s.repo.Update(progress)
+			linked.BookPage = &page
+			if err := s.repo.Update(ctx, linked); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return nil
+	return linked, nil
 }
 
-func (s *progressService) SetAudiobook(id int, audiobookID int) error {
-	progress, err := s.repo.GetByID(id)
+// SetAudiobook links an audiobook and derives its time from the page, guarded
+// like SetBook.
+func (s *progressService) SetAudiobook(ctx context.Context, id int, audiobookID int, cond domain.UpdateCondition) (*domain.Progress, error) {
+	progress, err := s.getPinned(ctx, id, cond)
 	if err != nil {
-		return err
-	}
-	if progress == nil {
-		return fmt.Errorf("progress not found")
+		return nil, err
 	}
 
 	progress.AudiobookID = &audiobookID
 
-	if err := s.repo.Update(progress); err != nil {
-		return err
+	if err := s.repo.Update(ctx, progress); err != nil {
+		return nil, err
 	}
+	progressChanged(ctx, s.views, progress.UserID)
 
-	progress, totalPages, totalLength, err := s.repo.GetByIDWithTotals(id)
+	linked, totalPages, totalLength, err := s.repo.GetByIDWithTotals(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	linked.Version = progress.Version
 
-	if progress.BookPage != nil && totalPages > 0 && totalLength != nil && totalLength.Duration > 0 {
-		ts, err := pageToTimestamp(totalPages, *progress.BookPage, totalLength.Duration)
+	if linked.BookPage != nil && totalPages > 0 && totalLength != nil && totalLength.Duration > 0 {
+		ts, err := pageToTimestamp(totalPages, *linked.BookPage, totalLength.Duration)
 		if err == nil {
 			cd := domain.CustomDuration{Duration: ts}
-			progress.AudiobookTime = &cd
-			return s.repo.Update(progress)
+			linked.AudiobookTime = &cd
+			if err := s.repo.Update(ctx, linked); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return nil
+	return linked, nil
+}
+
+// getPinned reads the entry and, when cond pins a version in strict mode,
+// fails with ErrPreconditionFailed if it has moved on.
+func (s *progressService) getPinned(ctx context.Context, id int, cond domain.UpdateCondition) (*domain.Progress, error) {
+	progress, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return nil, errors.NotFound("progress not found")
+	}
+	if cond.IfMatch > 0 && cond.Mode != domain.MergeModeFurthest && progress.Version != cond.IfMatch {
+		return nil, errors.ErrPreconditionFailed
+	}
+	return progress, nil
 }
 
-func (s *progressService) FilterProgress(filter repository.ProgressFilter) ([]domain.Progress, error) {
-	return s.repo.FilterProgress(filter)
+func (s *progressService) FilterProgress(ctx context.Context, filter repository.ProgressFilter) ([]domain.Progress, error) {
+	return s.repo.FilterProgress(ctx, filter)
 }
 
-func (s *progressService) GetAllEnrichedByUser(userID int) ([]domain.EnrichedProgress, error) {
-	return s.repo.GetAllEnrichedByUser(userID)
+func (s *progressService) GetAllEnrichedByUser(ctx context.Context, userID int) ([]domain.EnrichedProgress, error) {
+	if s.views != nil {
+		return s.views.Enriched(ctx, userID)
+	}
+	return s.repo.GetAllEnrichedByUser(ctx, userID)
+}
+
+func (s *progressService) GetAllEnrichedByUserShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error) {
+	if s.views != nil {
+		return s.views.EnrichedByShelf(ctx, userID, shelfID)
+	}
+	return s.repo.GetAllEnrichedByUserShelf(ctx, userID, shelfID)
 }
@@ -1,20 +1,23 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
 )
 
 type mockProgressRepo struct {
-	Data map[int]domain.Progress
-	Err  error
+	Data    map[int]domain.Progress
+	Shelves map[int][]int
+	Err     error
 }
 
-func (m *mockProgressRepo) GetAll() ([]domain.Progress, error) {
+func (m *mockProgressRepo) GetAll(ctx context.Context) ([]domain.Progress, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -25,14 +28,14 @@ func (m *mockProgressRepo) GetAll() ([]domain.Progress, error) {
 	return all, nil
 }
 
-func (m *mockProgressRepo) GetByID(id int) (*domain.Progress, error) {
+func (m *mockProgressRepo) GetByID(ctx context.Context, id int) (*domain.Progress, error) {
 	if val, ok := m.Data[id]; ok {
 		return &val, nil
 	}
 	return nil, nil
 }
 
-func (m *mockProgressRepo) Create(progress *domain.Progress) (int, error) {
+func (m *mockProgressRepo) Create(ctx context.Context, progress *domain.Progress) (int, error) {
 	if m.Err != nil {
 		return 0, m.Err
 	}
@@ -42,18 +45,39 @@ func (m *mockProgressRepo) Create(progress *domain.Progress) (int, error) {
 	return id, nil
 }
 
-func (m *mockProgressRepo) Update(progress *domain.Progress) error {
+func (m *mockProgressRepo) Update(ctx context.Context, progress *domain.Progress) error {
 	if m.Err != nil {
 		return m.Err
 	}
-	if _, ok := m.Data[progress.ID]; !ok {
+	current, ok := m.Data[progress.ID]
+	if !ok {
 		return errors.New("not found")
 	}
+	if progress.Version != 0 && progress.Version != current.Version {
+		return apperrors.ErrPreconditionFailed
+	}
+	progress.Version = current.Version + 1
 	m.Data[progress.ID] = *progress
 	return nil
 }
 
-func (m *mockProgressRepo) Delete(id int) error {
+func (m *mockProgressRepo) UpdatePositions(ctx context.Context, progresses []*domain.Progress) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, p := range progresses {
+		if current, ok := m.Data[p.ID]; !ok || current.Version != p.Version {
+			return apperrors.ErrPreconditionFailed
+		}
+	}
+	for _, p := range progresses {
+		p.Version++
+		m.Data[p.ID] = *p
+	}
+	return nil
+}
+
+func (m *mockProgressRepo) Delete(ctx context.Context, id int) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -64,7 +88,7 @@ func (m *mockProgressRepo) Delete(id int) error {
 	return nil
 }
 
-func (m *mockProgressRepo) GetByIDWithTotals(id int) (progress *domain.Progress, totalPages int, totalLength *domain.CustomDuration, err error) {
+func (m *mockProgressRepo) GetByIDWithTotals(ctx context.Context, id int) (progress *domain.Progress, totalPages int, totalLength *domain.CustomDuration, err error) {
 	if m.Err != nil {
 		return nil, 0, nil, m.Err
 	}
@@ -74,7 +98,7 @@ func (m *mockProgressRepo) GetByIDWithTotals(id int) (progress *domain.Progress,
 	return nil, 0, nil, nil
 }
 
-func (m *mockProgressRepo) SetBook(id int, bookId int) error {
+func (m *mockProgressRepo) SetBook(ctx context.Context, id int, bookId int) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -86,7 +110,7 @@ func (m *mockProgressRepo) SetBook(id int, bookId int) error {
 	return errors.New("not found")
 }
 
-func (m *mockProgressRepo) SetAudiobook(id int, audiobookId int) error {
+func (m *mockProgressRepo) SetAudiobook(ctx context.Context, id int, audiobookId int) error {
 	if m.Err != nil {
 		return m.Err
 	}
@@ -98,7 +122,7 @@ func (m *mockProgressRepo) SetAudiobook(id int, audiobookId int) error {
 	return errors.New("not found")
 }
 
-func (m *mockProgressRepo) FilterProgress(filter repository.ProgressFilter) ([]domain.Progress, error) {
+func (m *mockProgressRepo) FilterProgress(ctx context.Context, filter repository.ProgressFilter) ([]domain.Progress, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -128,7 +152,7 @@ func (m *mockProgressRepo) FilterProgress(filter repository.ProgressFilter) ([]d
 	return results, nil
 }
 
-func (m *mockProgressRepo) GetAllEnrichedByUser(userID int) ([]domain.EnrichedProgress, error) {
+func (m *mockProgressRepo) GetAllEnrichedByUser(ctx context.Context, userID int) ([]domain.EnrichedProgress, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
@@ -144,6 +168,19 @@ func (m *mockProgressRepo) GetAllEnrichedByUser(userID int) ([]domain.EnrichedPr
 	return results, nil
 }
 
+func (m *mockProgressRepo) GetAllEnrichedByUserShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var results []domain.EnrichedProgress
+	for _, progressID := range m.Shelves[shelfID] {
+		if prog, ok := m.Data[progressID]; ok && prog.UserID == userID {
+			results = append(results, domain.EnrichedProgress{Progress: prog})
+		}
+	}
+	return results, nil
+}
+
 func TestProgressService(t *testing.T) {
 	mockData := map[int]domain.Progress{
 		1: {
@@ -161,10 +198,10 @@ func TestProgressService(t *testing.T) {
 		Err:  nil,
 	}
 
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
 	t.Run("GetAll", func(t *testing.T) {
-		res, err := svc.GetAll()
+		res, err := svc.GetAll(context.Background())
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -174,7 +211,7 @@ func TestProgressService(t *testing.T) {
 	})
 
 	t.Run("GetByID found", func(t *testing.T) {
-		res, err := svc.GetByID(1)
+		res, err := svc.GetByID(context.Background(), 1)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -184,7 +221,7 @@ func TestProgressService(t *testing.T) {
 	})
 
 	t.Run("GetByID not found", func(t *testing.T) {
-		res, err := svc.GetByID(999)
+		res, err := svc.GetByID(context.Background(), 999)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -201,7 +238,7 @@ func TestProgressService(t *testing.T) {
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
-		_, err := svc.Create(&progress)
+		_, err := svc.Create(context.Background(), &progress)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -218,7 +255,7 @@ func TestProgressService(t *testing.T) {
 			BookID:   &bookID,
 			BookPage: ptrInt(15),
 		}
-		err := svc.Update(&progress)
+		err := svc.Update(context.Background(), &progress)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -226,21 +263,21 @@ func TestProgressService(t *testing.T) {
 
 	t.Run("Update not found", func(t *testing.T) {
 		progress := domain.Progress{ID: 999}
-		err := svc.Update(&progress)
+		err := svc.Update(context.Background(), &progress)
 		if err == nil {
 			t.Fatal("expected error for non-existent record")
 		}
 	})
 
 	t.Run("Delete found", func(t *testing.T) {
-		err := svc.Delete(1)
+		err := svc.Delete(context.Background(), 1)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 
 	t.Run("Delete not found", func(t *testing.T) {
-		err := svc.Delete(999)
+		err := svc.Delete(context.Background(), 999)
 		if err == nil {
 			t.Fatal("expected error for non-existent record")
 		}
@@ -257,12 +294,13 @@ func TestProgressService_UpdateProgressPage(t *testing.T) {
 				UserID:   1,
 				BookID:   &bookID,
 				BookPage: &bookPage,
+				Version:  1,
 			},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	err := svc.UpdateProgressPage(1, 100)
+	progress, err := svc.UpdateProgressPage(context.Background(), 1, 100, domain.UpdateCondition{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -270,6 +308,68 @@ func TestProgressService_UpdateProgressPage(t *testing.T) {
 	if updated.BookPage == nil || *updated.BookPage != 100 {
 		t.Fatalf("expected BookPage to be 100, got %v", updated.BookPage)
 	}
+	if progress.Version != 2 || updated.Version != 2 {
+		t.Fatalf("expected version to be bumped to 2, got %d", progress.Version)
+	}
+}
+
+func TestProgressService_UpdateProgressPage_IfMatch(t *testing.T) {
+	bookID := 1
+	bookPage := 50
+	mockRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: &bookPage, Version: 3},
+		},
+	}
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
+
+	t.Run("stale version", func(t *testing.T) {
+		_, err := svc.UpdateProgressPage(context.Background(), 1, 100, domain.UpdateCondition{IfMatch: 2})
+		if err != apperrors.ErrPreconditionFailed {
+			t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+		}
+		if *mockRepo.Data[1].BookPage != 50 {
+			t.Fatalf("expected page to stay at 50, got %d", *mockRepo.Data[1].BookPage)
+		}
+	})
+
+	t.Run("current version", func(t *testing.T) {
+		progress, err := svc.UpdateProgressPage(context.Background(), 1, 100, domain.UpdateCondition{IfMatch: 3})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if progress.Version != 4 || *progress.BookPage != 100 {
+			t.Fatalf("expected page 100 at version 4, got page %d at version %d", *progress.BookPage, progress.Version)
+		}
+	})
+}
+
+func TestProgressService_UpdateProgressPage_Furthest(t *testing.T) {
+	bookID := 1
+	bookPage := 200
+	mockRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: &bookPage, Version: 5},
+		},
+	}
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
+	furthest := domain.UpdateCondition{IfMatch: 1, Mode: domain.MergeModeFurthest}
+
+	progress, err := svc.UpdateProgressPage(context.Background(), 1, 150, furthest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *progress.BookPage != 200 || progress.Version != 5 {
+		t.Fatalf("expected page 200 at version 5 to be kept, got page %d at version %d", *progress.BookPage, progress.Version)
+	}
+
+	progress, err = svc.UpdateProgressPage(context.Background(), 1, 250, furthest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *progress.BookPage != 250 || progress.Version != 6 {
+		t.Fatalf("expected page 250 at version 6, got page %d at version %d", *progress.BookPage, progress.Version)
+	}
 }
 
 func TestProgressService_UpdateProgressTime(t *testing.T) {
@@ -283,10 +383,10 @@ func TestProgressService_UpdateProgressTime(t *testing.T) {
 			},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
 	newTime := &domain.CustomDuration{Duration: 30 * time.Minute}
-	err := svc.UpdateProgressTime(1, newTime)
+	_, err := svc.UpdateProgressTime(context.Background(), 1, newTime, domain.UpdateCondition{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -302,23 +402,56 @@ func TestProgressService_SetBook(t *testing.T) {
 			1: {ID: 1, UserID: 1},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	err := svc.SetBook(1, 5)
+	_, err := svc.SetBook(context.Background(), 1, 5, domain.UpdateCondition{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
+func TestProgressService_SetBook_IfMatch(t *testing.T) {
+	audiobookTime := &domain.CustomDuration{Duration: time.Hour}
+	mockRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, AudiobookTime: audiobookTime, Version: 3},
+		},
+	}
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
+
+	if _, err := svc.SetBook(context.Background(), 1, 5, domain.UpdateCondition{IfMatch: 2}); err != apperrors.ErrPreconditionFailed {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+	if mockRepo.Data[1].BookID != nil {
+		t.Fatal("expected a stale If-Match to leave the book unlinked")
+	}
+
+	progress, err := svc.SetBook(context.Background(), 1, 5, domain.UpdateCondition{IfMatch: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Linking and deriving the page are two writes; the returned version is
+	// the last one, so the next write in a chain can pin it.
+	if progress.Version != 5 || progress.Version != mockRepo.Data[1].Version {
+		t.Fatalf("expected version 5 returned, got %d (stored %d)", progress.Version, mockRepo.Data[1].Version)
+	}
+	if progress.BookPage == nil {
+		t.Fatal("expected the page derived from the audiobook time")
+	}
+	if _, err := svc.UpdateProgressPage(context.Background(), 1, 10, domain.UpdateCondition{IfMatch: progress.Version}); err != nil {
+		t.Fatalf("expected the chained version to match, got %v", err)
+	}
+}
+
 func TestProgressService_SetAudiobook(t *testing.T) {
 	mockRepo := &mockProgressRepo{
 		Data: map[int]domain.Progress{
 			1: {ID: 1, UserID: 1},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	err := svc.SetAudiobook(1, 10)
+	_, err := svc.SetAudiobook(context.Background(), 1, 10, domain.UpdateCondition{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -334,11 +467,11 @@ func TestProgressService_FilterProgress(t *testing.T) {
 			3: {ID: 3, UserID: 2, BookID: &bookID1},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
 	userID := 1
 	filter := repository.ProgressFilter{UserID: &userID}
-	results, err := svc.FilterProgress(filter)
+	results, err := svc.FilterProgress(context.Background(), filter)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -354,12 +487,12 @@ func TestProgressService_FilterProgress(t *testing.T) {
 
 func TestProgressService_Create_ValidationError(t *testing.T) {
 	mockRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
 	progress := domain.Progress{
 		UserID: 1,
 	}
-	_, err := svc.Create(&progress)
+	_, err := svc.Create(context.Background(), &progress)
 	if err == nil {
 		t.Fatal("expected validation error for progress with no book_id or audiobook_id")
 	}
@@ -367,7 +500,7 @@ func TestProgressService_Create_ValidationError(t *testing.T) {
 
 func TestProgressService_Create_NegativeBookPage(t *testing.T) {
 	mockRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
 	bookID := 1
 	negativePage := -1
@@ -376,7 +509,7 @@ func TestProgressService_Create_NegativeBookPage(t *testing.T) {
 		BookID:   &bookID,
 		BookPage: &negativePage,
 	}
-	_, err := svc.Create(&progress)
+	_, err := svc.Create(context.Background(), &progress)
 	if err == nil {
 		t.Fatal("expected validation error for negative book_page")
 	}
@@ -390,9 +523,9 @@ func TestProgressService_GetByIDWithCompletion(t *testing.T) {
 			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: &page},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	result, err := svc.GetByIDWithCompletion(1)
+	result, err := svc.GetByIDWithCompletion(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -406,9 +539,9 @@ func TestProgressService_GetByIDWithCompletion(t *testing.T) {
 
 func TestProgressService_SetBook_NotFound(t *testing.T) {
 	mockRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	err := svc.SetBook(999, 1)
+	_, err := svc.SetBook(context.Background(), 999, 1, domain.UpdateCondition{})
 	if err == nil {
 		t.Fatal("expected error for non-existent progress")
 	}
@@ -416,9 +549,9 @@ func TestProgressService_SetBook_NotFound(t *testing.T) {
 
 func TestProgressService_SetAudiobook_NotFound(t *testing.T) {
 	mockRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	err := svc.SetAudiobook(999, 1)
+	_, err := svc.SetAudiobook(context.Background(), 999, 1, domain.UpdateCondition{})
 	if err == nil {
 		t.Fatal("expected error for non-existent progress")
 	}
@@ -433,9 +566,9 @@ func TestProgressService_GetAllEnrichedByUser(t *testing.T) {
 			2: {ID: 2, UserID: 2, BookID: &bookID, BookPage: &page},
 		},
 	}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	results, err := svc.GetAllEnrichedByUser(1)
+	results, err := svc.GetAllEnrichedByUser(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -446,12 +579,103 @@ func TestProgressService_GetAllEnrichedByUser(t *testing.T) {
 
 func TestProgressService_GetAllEnrichedByUser_Error(t *testing.T) {
 	mockRepo := &mockProgressRepo{Data: make(map[int]domain.Progress), Err: errors.New("db error")}
-	svc := NewProgressService(mockRepo)
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
 
-	_, err := svc.GetAllEnrichedByUser(1)
+	_, err := svc.GetAllEnrichedByUser(context.Background(), 1)
 	if err == nil {
 		t.Fatal("expected error")
 	}
 }
 
+func TestProgressService_GetAllEnrichedByUserShelf(t *testing.T) {
+	bookID := 1
+	page := 50
+	mockRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: &page},
+			2: {ID: 2, UserID: 1, BookID: &bookID, BookPage: &page},
+			3: {ID: 3, UserID: 2, BookID: &bookID, BookPage: &page},
+		},
+		Shelves: map[int][]int{7: {1, 3}},
+	}
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
+
+	results, err := svc.GetAllEnrichedByUserShelf(context.Background(), 1, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Progress.ID != 1 {
+		t.Fatalf("expected only progress 1 on shelf 7 for user 1, got %+v", results)
+	}
+}
+
 func ptrInt(i int) *int { return &i }
+
+func TestProgressService_UpdateBatch_PerItem(t *testing.T) {
+	bookID := 1
+	page := 50
+	mockRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: &page, Version: 1},
+			2: {ID: 2, UserID: 2, BookID: &bookID, BookPage: &page, Version: 1},
+		},
+	}
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
+
+	hundred, ten := 100, 10
+	resp, err := svc.UpdateBatch(context.Background(), 1, &domain.ProgressBatchRequest{Updates: []domain.ProgressBatchItem{
+		{ProgressID: 1, Page: &hundred},
+		{ProgressID: 2, Page: &ten},
+		{ProgressID: 1, Status: domain.ProgressStatusCompleted},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Applied != 2 || resp.Failed != 1 || resp.Results[1].Error == "" {
+		t.Fatalf("expected another user's entry to fail alone, got %+v", resp)
+	}
+	if got := mockRepo.Data[1]; *got.BookPage != 500 || got.Version != 3 {
+		t.Fatalf("expected entry 1 finished at page 500 after two writes, got page %d at version %d", *got.BookPage, got.Version)
+	}
+	if *mockRepo.Data[2].BookPage != 50 {
+		t.Fatal("expected another user's entry to be left alone")
+	}
+}
+
+func TestProgressService_UpdateBatch_Atomic(t *testing.T) {
+	bookID := 1
+	page := 50
+	mockRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: &page, Version: 1},
+			3: {ID: 3, UserID: 1, BookID: &bookID, BookPage: &page, Version: 4},
+		},
+	}
+	svc := NewProgressService(mockRepo, nil, nil, nil, nil)
+	hundred := 100
+
+	_, err := svc.UpdateBatch(context.Background(), 1, &domain.ProgressBatchRequest{Atomic: true, Updates: []domain.ProgressBatchItem{
+		{ProgressID: 1, Page: &hundred},
+		{ProgressID: 99, Page: &hundred},
+	}})
+	if !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for the missing entry, got %v", err)
+	}
+	if *mockRepo.Data[1].BookPage != 50 {
+		t.Fatal("expected nothing to be written when one item fails")
+	}
+
+	resp, err := svc.UpdateBatch(context.Background(), 1, &domain.ProgressBatchRequest{Atomic: true, Updates: []domain.ProgressBatchItem{
+		{ProgressID: 1, Page: &hundred},
+		{ProgressID: 3, Status: domain.ProgressStatusCompleted},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Applied != 2 || *mockRepo.Data[1].BookPage != 100 || *mockRepo.Data[3].BookPage != 500 {
+		t.Fatalf("expected both entries written, got %+v", mockRepo.Data)
+	}
+	if mockRepo.Data[3].Version != 5 {
+		t.Fatalf("expected version 5, got %d", mockRepo.Data[3].Version)
+	}
+}
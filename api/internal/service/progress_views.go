@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/logging"
+	"book_boy/api/internal/repository"
+)
+
+// ProgressViewTTL bounds how long a view can outlive a change nobody reported.
+const ProgressViewTTL = 10 * time.Minute
+
+// ProgressViews serves each user's enriched progress, the join behind
+// /progress/enriched and /tracking/current, from the cache. Services report
+// the changes that affect a view and the user's cached views are dropped once
+// the change commits.
+type ProgressViews interface {
+	Enriched(ctx context.Context, userID int) ([]domain.EnrichedProgress, error)
+	EnrichedByShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error)
+	// ProgressChanged reports a change to the user's progress entries or
+	// shelves.
+	ProgressChanged(ctx context.Context, userID int)
+	// BookChanged and AudiobookChanged report a catalog change that shows in
+	// the views of everyone tracking the title. Call them before deleting
+	// the title, while its progress entries still point at it.
+	BookChanged(ctx context.Context, bookID int)
+	AudiobookChanged(ctx context.Context, audiobookID int)
+}
+
+type progressViews struct {
+	repo   repository.ProgressRepo
+	cache  infra.Cache
+	logger *slog.Logger
+}
+
+func NewProgressViews(repo repository.ProgressRepo, cache infra.Cache, logger *slog.Logger) ProgressViews {
+	return &progressViews{repo: repo, cache: cache, logger: logging.OrDiscard(logger)}
+}
+
+func (v *progressViews) Enriched(ctx context.Context, userID int) ([]domain.EnrichedProgress, error) {
+	return v.view(ctx, userID, "all", func(ctx context.Context) ([]domain.EnrichedProgress, error) {
+		return v.repo.GetAllEnrichedByUser(ctx, userID)
+	})
+}
+
+func (v *progressViews) EnrichedByShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error) {
+	return v.view(ctx, userID, fmt.Sprintf("shelf:%d", shelfID), func(ctx context.Context) ([]domain.EnrichedProgress, error) {
+		return v.repo.GetAllEnrichedByUserShelf(ctx, userID, shelfID)
+	})
+}
+
+// view keys each view by the user's current generation, so invalidating a
+// user means starting a new generation rather than finding every shelf's key.
+// A load that races an invalidation lands under the old generation, where
+// nobody reads it again.
+func (v *progressViews) view(ctx context.Context, userID int, name string, load func(ctx context.Context) ([]domain.EnrichedProgress, error)) ([]domain.EnrichedProgress, error) {
+	if v.cache == nil {
+		return load(ctx)
+	}
+
+	var generation string
+	if err := v.cache.Get(ctx, progressViewGenerationKey(userID), &generation); err != nil {
+		generation = strconv.FormatInt(time.Now().UnixNano(), 36)
+		v.cache.Set(ctx, progressViewGenerationKey(userID), generation, 2*ProgressViewTTL)
+	}
+	key := fmt.Sprintf("progress_views:%d:%s:%s", userID, generation, name)
+
+	return infra.GetOrLoad(ctx, v.cache, key, ProgressViewTTL, func(ctx context.Context) ([]domain.EnrichedProgress, error) {
+		enriched, err := load(ctx)
+		// Users with nothing tracked are cached too; GetOrLoad skips nil.
+		if err == nil && enriched == nil {
+			enriched = []domain.EnrichedProgress{}
+		}
+		return enriched, err
+	})
+}
+
+func (v *progressViews) ProgressChanged(ctx context.Context, userID int) {
+	v.invalidate(ctx, []int{userID})
+}
+
+func (v *progressViews) BookChanged(ctx context.Context, bookID int) {
+	v.titleChanged(ctx, repository.ProgressFilter{BookID: &bookID})
+}
+
+func (v *progressViews) AudiobookChanged(ctx context.Context, audiobookID int) {
+	v.titleChanged(ctx, repository.ProgressFilter{AudiobookID: &audiobookID})
+}
+
+// titleChanged looks up who tracks the title now, inside the caller's unit of
+// work, and invalidates them once it commits.
+func (v *progressViews) titleChanged(ctx context.Context, filter repository.ProgressFilter) {
+	if v.cache == nil {
+		return
+	}
+	entries, err := v.repo.FilterProgress(ctx, filter)
+	if err != nil {
+		v.logger.WarnContext(ctx, "failed to find progress views to invalidate", "error", err)
+		return
+	}
+	userIDs := make([]int, 0, len(entries))
+	seen := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		if !seen[entry.UserID] {
+			seen[entry.UserID] = true
+			userIDs = append(userIDs, entry.UserID)
+		}
+	}
+	v.invalidate(ctx, userIDs)
+}
+
+func (v *progressViews) invalidate(ctx context.Context, userIDs []int) {
+	if v.cache == nil || len(userIDs) == 0 {
+		return
+	}
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		ctx = context.WithoutCancel(ctx)
+		keys := make([]string, len(userIDs))
+		for i, userID := range userIDs {
+			keys[i] = progressViewGenerationKey(userID)
+		}
+		if err := v.cache.Delete(ctx, keys...); err != nil {
+			v.logger.WarnContext(ctx, "failed to invalidate progress views", "users", userIDs, "error", err)
+		}
+	})
+}
+
+func progressViewGenerationKey(userID int) string {
+	return fmt.Sprintf("progress_views_generation:%d", userID)
+}
+
+// progressChanged and the helpers below let services run without views, as
+// most tests do.
+func progressChanged(ctx context.Context, views ProgressViews, userID int) {
+	if views != nil {
+		views.ProgressChanged(ctx, userID)
+	}
+}
+
+func bookChanged(ctx context.Context, views ProgressViews, bookID int) {
+	if views != nil {
+		views.BookChanged(ctx, bookID)
+	}
+}
+
+func audiobookChanged(ctx context.Context, views ProgressViews, audiobookID int) {
+	if views != nil {
+		views.AudiobookChanged(ctx, audiobookID)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/infra"
+)
+
+func TestProgressViews_CachedUntilProgressChanges(t *testing.T) {
+	bookID := 1
+	repo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID, BookPage: ptrInt(10)},
+		},
+	}
+	views := NewProgressViews(repo, infra.NewMemoryCache(10), nil)
+	svc := NewProgressService(repo, nil, nil, views, nil)
+	ctx := context.Background()
+
+	if _, err := svc.GetAllEnrichedByUser(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Changed behind the service's back, so only a cache miss would see it.
+	repo.Data[2] = domain.Progress{ID: 2, UserID: 1, BookID: &bookID, BookPage: ptrInt(20)}
+	results, err := svc.GetAllEnrichedByUser(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the cached view, got %d entries", len(results))
+	}
+
+	if err := svc.Update(ctx, &domain.Progress{ID: 1, UserID: 1, BookID: &bookID, BookPage: ptrInt(30)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err = svc.GetAllEnrichedByUser(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected the update to invalidate the view, got %d entries", len(results))
+	}
+}
+
+// readDuringDeleteRepo loads the user's view just before the row goes, as a
+// concurrent request would.
+type readDuringDeleteRepo struct {
+	*mockProgressRepo
+	read func()
+}
+
+func (r readDuringDeleteRepo) Delete(ctx context.Context, id int) error {
+	r.read()
+	return r.mockProgressRepo.Delete(ctx, id)
+}
+
+func TestProgressViews_InvalidatedAfterDelete(t *testing.T) {
+	bookID := 1
+	data := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID},
+			2: {ID: 2, UserID: 1, BookID: &bookID},
+		},
+	}
+	views := NewProgressViews(data, infra.NewMemoryCache(10), nil)
+	ctx := context.Background()
+	repo := readDuringDeleteRepo{mockProgressRepo: data, read: func() {
+		if _, err := views.Enriched(ctx, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}}
+	svc := NewProgressService(repo, nil, nil, views, nil)
+
+	if err := svc.Delete(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err := svc.GetAllEnrichedByUser(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the deleted entry gone from the view, got %d entries", len(results))
+	}
+}
+
+func TestProgressViews_ShelfViewsInvalidatedWithUser(t *testing.T) {
+	bookID := 1
+	repo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID},
+		},
+		Shelves: map[int][]int{7: {1}},
+	}
+	views := NewProgressViews(repo, infra.NewMemoryCache(10), nil)
+	ctx := context.Background()
+
+	if _, err := views.EnrichedByShelf(ctx, 1, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Shelves[7] = nil
+	results, err := views.EnrichedByShelf(ctx, 1, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the cached shelf view, got %d entries", len(results))
+	}
+
+	views.ProgressChanged(ctx, 1)
+	results, err = views.EnrichedByShelf(ctx, 1, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected the shelf view to be invalidated, got %d entries", len(results))
+	}
+}
+
+func TestProgressViews_BookChangeInvalidatesEveryTracker(t *testing.T) {
+	bookID, otherBookID := 1, 2
+	repo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID},
+			2: {ID: 2, UserID: 2, BookID: &bookID},
+			3: {ID: 3, UserID: 3, BookID: &otherBookID},
+		},
+	}
+	views := NewProgressViews(repo, infra.NewMemoryCache(10), nil)
+	books := NewBookService(&mockBookRepo{
+		Books: map[int]domain.Book{
+			1: {ID: 1, ISBN: "1111", Title: "Dune", TotalPages: 412},
+		},
+	}, nil, nil, views, nil)
+	ctx := context.Background()
+
+	for userID := 1; userID <= 3; userID++ {
+		if _, err := views.Enriched(ctx, userID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// A fresh load shows the extra entry, a cached view doesn't.
+	for userID := 1; userID <= 3; userID++ {
+		repo.Data[10+userID] = domain.Progress{ID: 10 + userID, UserID: userID, BookID: &otherBookID}
+	}
+
+	if err := books.Update(ctx, &domain.Book{ID: 1, ISBN: "1111", Title: "Dune Messiah", TotalPages: 412}, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for userID, want := range map[int]int{1: 2, 2: 2, 3: 1} {
+		results, err := views.Enriched(ctx, userID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != want {
+			t.Errorf("user %d: expected %d entries, got %d", userID, want, len(results))
+		}
+	}
+}
+
+func TestProgressViews_CachesEmptyViews(t *testing.T) {
+	repo := &mockProgressRepo{Data: map[int]domain.Progress{}}
+	views := NewProgressViews(repo, infra.NewMemoryCache(10), nil)
+	ctx := context.Background()
+
+	if _, err := views.Enriched(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.Data[1] = domain.Progress{ID: 1, UserID: 1}
+	results, err := views.Enriched(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected the cached empty view, got %d entries", len(results))
+	}
+}
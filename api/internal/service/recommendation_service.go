@@ -0,0 +1,189 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/logging"
+	"book_boy/api/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendations         = 50
+
+	// Co-reading is the stronger signal; title similarity mostly helps users
+	// whose books nobody else is reading yet.
+	coReadWeight     = 0.7
+	similarityWeight = 0.3
+
+	RecommendationCacheTTL = time.Hour
+)
+
+type RecommendationService interface {
+	GetForUser(ctx context.Context, userID int, limit int) ([]domain.Recommendation, error)
+	Refresh(ctx context.Context, userID int) ([]domain.Recommendation, error)
+	RefreshAll(ctx context.Context) (int, error)
+}
+
+type recommendationService struct {
+	repo     repository.RecommendationRepo
+	bookRepo repository.BookRepo
+	cache    infra.Cache
+	logger   *slog.Logger
+}
+
+func NewRecommendationService(repo repository.RecommendationRepo, bookRepo repository.BookRepo, cache infra.Cache, logger *slog.Logger) RecommendationService {
+	return &recommendationService{repo: repo, bookRepo: bookRepo, cache: cache, logger: logging.OrDiscard(logger)}
+}
+
+// GetForUser serves the list the background worker cached, computing it on the
+// spot for users the worker hasn't reached yet.
+func (s *recommendationService) GetForUser(ctx context.Context, userID int, limit int) ([]domain.Recommendation, error) {
+	if limit <= 0 {
+		limit = defaultRecommendationLimit
+	}
+	if limit > maxRecommendations {
+		limit = maxRecommendations
+	}
+
+	var recommendations []domain.Recommendation
+	cached := false
+	if s.cache != nil {
+		if err := s.cache.Get(ctx, recommendationCacheKey(userID), &recommendations); err == nil {
+			cached = true
+		}
+	}
+	if !cached {
+		var err error
+		recommendations, err = s.Refresh(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+func (s *recommendationService) Refresh(ctx context.Context, userID int) ([]domain.Recommendation, error) {
+	recommendations, err := s.compute(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		s.cache.Set(ctx, recommendationCacheKey(userID), recommendations, RecommendationCacheTTL)
+	}
+	return recommendations, nil
+}
+
+// RefreshAll recomputes recommendations for every user with books in progress.
+// A failure for one user is logged and skipped so the rest still refresh.
+func (s *recommendationService) RefreshAll(ctx context.Context) (int, error) {
+	userIDs, err := s.repo.GetActiveUserIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, userID := range userIDs {
+		if _, err := s.Refresh(ctx, userID); err != nil {
+			s.logger.WarnContext(ctx, "failed to refresh recommendations", "user_id", userID, "error", err)
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+func (s *recommendationService) compute(ctx context.Context, userID int) ([]domain.Recommendation, error) {
+	coRead, err := s.repo.GetCoReadCandidates(ctx, userID, maxRecommendations)
+	if err != nil {
+		return nil, err
+	}
+	similar, err := s.repo.GetSimilarTitleCandidates(ctx, userID, maxRecommendations)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeRecommendationCandidates(coRead, similar)
+	if len(merged) > maxRecommendations {
+		merged = merged[:maxRecommendations]
+	}
+
+	recommendations := make([]domain.Recommendation, 0, len(merged))
+	for _, rec := range merged {
+		book, err := s.bookRepo.GetByID(ctx, rec.Book.ID)
+		if err != nil {
+			return nil, err
+		}
+		if book == nil {
+			continue
+		}
+		rec.Book = *book
+		recommendations = append(recommendations, rec)
+	}
+	return recommendations, nil
+}
+
+// mergeRecommendationCandidates blends both signals into one ranked list.
+// Co-read scores are scaled against the best candidate so both signals sit
+// in [0, 1] before weighting. Only Book.ID is set on the results.
+func mergeRecommendationCandidates(coRead []domain.RecommendationCandidate, similar []domain.RecommendationCandidate) []domain.Recommendation {
+	maxCoScore := 0.0
+	for _, c := range coRead {
+		if c.CoScore > maxCoScore {
+			maxCoScore = c.CoScore
+		}
+	}
+
+	byBook := make(map[int]*domain.Recommendation)
+	var order []int
+	get := func(bookID int) *domain.Recommendation {
+		rec, ok := byBook[bookID]
+		if !ok {
+			rec = &domain.Recommendation{Book: domain.Book{ID: bookID}}
+			byBook[bookID] = rec
+			order = append(order, bookID)
+		}
+		return rec
+	}
+
+	for _, c := range coRead {
+		rec := get(c.BookID)
+		rec.CoReaders = c.CoReaders
+		if maxCoScore > 0 {
+			rec.Score += coReadWeight * c.CoScore / maxCoScore
+		}
+	}
+	for _, c := range similar {
+		rec := get(c.BookID)
+		rec.TitleSimilarity = roundTo2(c.Similarity)
+		rec.SimilarTo = c.SimilarTo
+		rec.Score += similarityWeight * c.Similarity
+	}
+
+	merged := make([]domain.Recommendation, 0, len(order))
+	for _, bookID := range order {
+		rec := byBook[bookID]
+		rec.Score = roundTo2(rec.Score)
+		merged = append(merged, *rec)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Score != merged[j].Score {
+			return merged[i].Score > merged[j].Score
+		}
+		return merged[i].Book.ID < merged[j].Book.ID
+	})
+	return merged
+}
+
+func recommendationCacheKey(userID int) string {
+	return fmt.Sprintf("recommendations:%d", userID)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"book_boy/api/internal/domain"
+)
+
+type mockRecommendationRepo struct {
+	CoRead  map[int][]domain.RecommendationCandidate
+	Similar map[int][]domain.RecommendationCandidate
+	Active  []int
+	Err     error
+	FailFor int
+}
+
+func (m *mockRecommendationRepo) GetCoReadCandidates(ctx context.Context, userID int, limit int) ([]domain.RecommendationCandidate, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if userID == m.FailFor {
+		return nil, errors.New("query failed")
+	}
+	return m.CoRead[userID], nil
+}
+
+func (m *mockRecommendationRepo) GetSimilarTitleCandidates(ctx context.Context, userID int, limit int) ([]domain.RecommendationCandidate, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Similar[userID], nil
+}
+
+func (m *mockRecommendationRepo) GetActiveUserIDs(ctx context.Context) ([]int, error) {
+	return m.Active, m.Err
+}
+
+func newRecommendationTestService() (RecommendationService, *mockRecommendationRepo) {
+	books := &mockBookRepo{Books: map[int]domain.Book{
+		10: {ID: 10, Title: "Dune Messiah"},
+		11: {ID: 11, Title: "Hyperion"},
+		12: {ID: 12, Title: "Children of Dune"},
+		13: {ID: 13, Title: "Foundation"},
+	}}
+	repo := &mockRecommendationRepo{
+		CoRead: map[int][]domain.RecommendationCandidate{
+			1: {
+				{BookID: 11, CoReaders: 4, CoScore: 8},
+				{BookID: 10, CoReaders: 2, CoScore: 4},
+				{BookID: 99, CoReaders: 1, CoScore: 1},
+			},
+		},
+		Similar: map[int][]domain.RecommendationCandidate{
+			1: {
+				{BookID: 10, Similarity: 0.8, SimilarTo: "Dune"},
+				{BookID: 12, Similarity: 0.5, SimilarTo: "Dune"},
+			},
+		},
+	}
+	return NewRecommendationService(repo, books, nil, nil), repo
+}
+
+func TestRecommendationService_GetForUser_BlendsSignals(t *testing.T) {
+	svc, _ := newRecommendationTestService()
+
+	recs, err := svc.GetForUser(context.Background(), 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 recommendations (missing book skipped), got %+v", recs)
+	}
+
+	// 11: 0.7*8/8 = 0.70; 10: 0.7*4/8 + 0.3*0.8 = 0.59; 12: 0.3*0.5 = 0.15
+	expected := []struct {
+		id    int
+		score float64
+	}{{11, 0.7}, {10, 0.59}, {12, 0.15}}
+	for i, want := range expected {
+		if recs[i].Book.ID != want.id || recs[i].Score != want.score {
+			t.Fatalf("recommendation %d: expected book %d score %.2f, got %+v", i, want.id, want.score, recs[i])
+		}
+	}
+	if recs[1].Book.Title != "Dune Messiah" || recs[1].CoReaders != 2 || recs[1].SimilarTo != "Dune" {
+		t.Fatalf("expected blended details on book 10, got %+v", recs[1])
+	}
+}
+
+func TestRecommendationService_GetForUser_Limit(t *testing.T) {
+	svc, _ := newRecommendationTestService()
+
+	recs, err := svc.GetForUser(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Book.ID != 11 {
+		t.Fatalf("expected only top recommendation, got %+v", recs)
+	}
+
+	recs, err = svc.GetForUser(context.Background(), 2, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 0 {
+		t.Fatalf("expected no recommendations for user without candidates, got %+v", recs)
+	}
+}
+
+func TestRecommendationService_RefreshAll(t *testing.T) {
+	svc, repo := newRecommendationTestService()
+	repo.Active = []int{1, 2, 3}
+	repo.FailFor = 2
+
+	count, err := svc.RefreshAll(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 users refreshed, got %d", count)
+	}
+
+	repo.Err = errors.New("db down")
+	if _, err := svc.RefreshAll(context.Background()); err == nil {
+		t.Fatal("expected error when active users cannot be loaded")
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/logging"
+	"book_boy/api/internal/repository"
+	"context"
+	"log/slog"
+)
+
+type ReviewService interface {
+	GetBookReviews(ctx context.Context, viewerID int, bookID int) ([]domain.Review, error)
+	GetAudiobookReviews(ctx context.Context, viewerID int, audiobookID int) ([]domain.Review, error)
+	ReviewBook(ctx context.Context, userID int, bookID int, req *domain.ReviewRequest) (*domain.Review, error)
+	ReviewAudiobook(ctx context.Context, userID int, audiobookID int, req *domain.ReviewRequest) (*domain.Review, error)
+	DeleteBookReview(ctx context.Context, userID int, bookID int) error
+	DeleteAudiobookReview(ctx context.Context, userID int, audiobookID int) error
+}
+
+type reviewService struct {
+	repo          repository.ReviewRepo
+	bookRepo      repository.BookRepo
+	audiobookRepo repository.AudiobookRepo
+	cache         infra.Cache
+	social        SocialService
+	logger        *slog.Logger
+}
+
+func NewReviewService(repo repository.ReviewRepo, bookRepo repository.BookRepo, audiobookRepo repository.AudiobookRepo, cache infra.Cache, social SocialService, logger *slog.Logger) ReviewService {
+	return &reviewService{
+		repo:          repo,
+		bookRepo:      bookRepo,
+		audiobookRepo: audiobookRepo,
+		cache:         cache,
+		social:        social,
+		logger:        logging.OrDiscard(logger),
+	}
+}
+
+func (s *reviewService) GetBookReviews(ctx context.Context, viewerID int, bookID int) ([]domain.Review, error) {
+	if err := s.checkBookExists(ctx, bookID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetVisibleByBook(ctx, bookID, viewerID)
+}
+
+func (s *reviewService) GetAudiobookReviews(ctx context.Context, viewerID int, audiobookID int) ([]domain.Review, error) {
+	if err := s.checkAudiobookExists(ctx, audiobookID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetVisibleByAudiobook(ctx, audiobookID, viewerID)
+}
+
+func (s *reviewService) ReviewBook(ctx context.Context, userID int, bookID int, req *domain.ReviewRequest) (*domain.Review, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkBookExists(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	review := &domain.Review{
+		UserID:  userID,
+		BookID:  &bookID,
+		Rating:  req.Rating,
+		Body:    req.Body,
+		Spoiler: req.Spoiler,
+	}
+	if err := s.repo.UpsertBookReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, bookCacheKey(bookID))
+	s.publishRated(ctx, review)
+	return review, nil
+}
+
+func (s *reviewService) ReviewAudiobook(ctx context.Context, userID int, audiobookID int, req *domain.ReviewRequest) (*domain.Review, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkAudiobookExists(ctx, audiobookID); err != nil {
+		return nil, err
+	}
+
+	review := &domain.Review{
+		UserID:      userID,
+		AudiobookID: &audiobookID,
+		Rating:      req.Rating,
+		Body:        req.Body,
+		Spoiler:     req.Spoiler,
+	}
+	if err := s.repo.UpsertAudiobookReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, audiobookCacheKey(audiobookID))
+	s.publishRated(ctx, review)
+	return review, nil
+}
+
+func (s *reviewService) DeleteBookReview(ctx context.Context, userID int, bookID int) error {
+	if err := s.repo.DeleteBookReview(ctx, userID, bookID); err != nil {
+		return err
+	}
+	s.invalidate(ctx, bookCacheKey(bookID))
+	return nil
+}
+
+func (s *reviewService) DeleteAudiobookReview(ctx context.Context, userID int, audiobookID int) error {
+	if err := s.repo.DeleteAudiobookReview(ctx, userID, audiobookID); err != nil {
+		return err
+	}
+	s.invalidate(ctx, audiobookCacheKey(audiobookID))
+	return nil
+}
+
+func (s *reviewService) checkBookExists(ctx context.Context, bookID int) error {
+	book, err := s.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if book == nil {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+func (s *reviewService) checkAudiobookExists(ctx context.Context, audiobookID int) error {
+	audiobook, err := s.audiobookRepo.GetByID(ctx, audiobookID)
+	if err != nil {
+		return err
+	}
+	if audiobook == nil {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+func (s *reviewService) publishRated(ctx context.Context, review *domain.Review) {
+	rating := review.Rating
+	publishActivity(ctx, s.social, s.logger, &domain.ActivityEvent{
+		UserID:      review.UserID,
+		Type:        domain.ActivityRated,
+		BookID:      review.BookID,
+		AudiobookID: review.AudiobookID,
+		Rating:      &rating,
+	})
+}
+
+// invalidate drops the cached book/audiobook so the next GET picks up the new
+// aggregate rating.
+func (s *reviewService) invalidate(ctx context.Context, key string) {
+	if s.cache != nil {
+		s.cache.Delete(context.WithoutCancel(ctx), key)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockReviewRepo struct {
+	Reviews      []domain.Review
+	Private      map[int]bool
+	Err          error
+	LastUpserted *domain.Review
+	LastDeleted  [2]int
+}
+
+func (m *mockReviewRepo) visible(viewerID int, match func(domain.Review) bool) []domain.Review {
+	var reviews []domain.Review
+	for _, review := range m.Reviews {
+		if !match(review) {
+			continue
+		}
+		if review.UserID == viewerID || !m.Private[review.UserID] {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews
+}
+
+func (m *mockReviewRepo) GetVisibleByBook(ctx context.Context, bookID int, viewerID int) ([]domain.Review, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.visible(viewerID, func(r domain.Review) bool { return r.BookID != nil && *r.BookID == bookID }), nil
+}
+
+func (m *mockReviewRepo) GetVisibleByAudiobook(ctx context.Context, audiobookID int, viewerID int) ([]domain.Review, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.visible(viewerID, func(r domain.Review) bool { return r.AudiobookID != nil && *r.AudiobookID == audiobookID }), nil
+}
+
+func (m *mockReviewRepo) UpsertBookReview(ctx context.Context, review *domain.Review) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	review.ID = len(m.Reviews) + 1
+	m.LastUpserted = review
+	return nil
+}
+
+func (m *mockReviewRepo) UpsertAudiobookReview(ctx context.Context, review *domain.Review) error {
+	return m.UpsertBookReview(context.Background(), review)
+}
+
+func (m *mockReviewRepo) DeleteBookReview(ctx context.Context, userID int, bookID int) error {
+	m.LastDeleted = [2]int{userID, bookID}
+	return m.Err
+}
+
+func (m *mockReviewRepo) DeleteAudiobookReview(ctx context.Context, userID int, audiobookID int) error {
+	m.LastDeleted = [2]int{userID, audiobookID}
+	return m.Err
+}
+
+func newReviewTestService(repo *mockReviewRepo) ReviewService {
+	bookRepo := &mockBookRepo{Books: map[int]domain.Book{1: {ID: 1, ISBN: "1111", Title: "Dune"}}}
+	audiobookRepo := &mockAudiobookRepo{Audiobooks: []domain.Audiobook{
+		{ID: 2, Title: "Dune", TotalLength: &domain.CustomDuration{Duration: 21 * time.Hour}},
+	}}
+	return NewReviewService(repo, bookRepo, audiobookRepo, nil, nil, nil)
+}
+
+func TestReviewService_ReviewBook(t *testing.T) {
+	repo := &mockReviewRepo{}
+	svc := newReviewTestService(repo)
+
+	review, err := svc.ReviewBook(context.Background(), 1, 1, &domain.ReviewRequest{Rating: 4.5, Body: "Spice!", Spoiler: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if review.BookID == nil || *review.BookID != 1 || review.Rating != 4.5 || !review.Spoiler {
+		t.Fatalf("unexpected review: %+v", review)
+	}
+	if repo.LastUpserted != review {
+		t.Error("expected review to be upserted")
+	}
+}
+
+func TestReviewService_ReviewAudiobook(t *testing.T) {
+	repo := &mockReviewRepo{}
+	svc := newReviewTestService(repo)
+
+	review, err := svc.ReviewAudiobook(context.Background(), 1, 2, &domain.ReviewRequest{Rating: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if review.AudiobookID == nil || *review.AudiobookID != 2 || review.BookID != nil {
+		t.Fatalf("unexpected review: %+v", review)
+	}
+}
+
+func TestReviewService_RatingValidation(t *testing.T) {
+	svc := newReviewTestService(&mockReviewRepo{})
+
+	for _, rating := range []float64{0, 0.25, 3.3, 5.5, -1} {
+		if _, err := svc.ReviewBook(context.Background(), 1, 1, &domain.ReviewRequest{Rating: rating}); !apperrors.IsValidationError(err) {
+			t.Errorf("expected validation error for rating %v, got %v", rating, err)
+		}
+	}
+	for _, rating := range []float64{0.5, 1, 2.5, 5} {
+		if _, err := svc.ReviewBook(context.Background(), 1, 1, &domain.ReviewRequest{Rating: rating}); err != nil {
+			t.Errorf("unexpected error for rating %v: %v", rating, err)
+		}
+	}
+}
+
+func TestReviewService_UnknownTitle(t *testing.T) {
+	svc := newReviewTestService(&mockReviewRepo{})
+
+	if _, err := svc.ReviewBook(context.Background(), 1, 999, &domain.ReviewRequest{Rating: 3}); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := svc.GetAudiobookReviews(context.Background(), 1, 999); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReviewService_GetBookReviews_Privacy(t *testing.T) {
+	bookID := 1
+	repo := &mockReviewRepo{
+		Reviews: []domain.Review{
+			{ID: 1, UserID: 1, BookID: &bookID, Rating: 5},
+			{ID: 2, UserID: 2, BookID: &bookID, Rating: 2},
+		},
+		Private: map[int]bool{2: true},
+	}
+	svc := newReviewTestService(repo)
+
+	reviews, err := svc.GetBookReviews(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].UserID != 1 {
+		t.Fatalf("expected private review hidden from other users, got %+v", reviews)
+	}
+
+	reviews, err = svc.GetBookReviews(context.Background(), 2, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("expected author to see their own private review, got %+v", reviews)
+	}
+}
+
+func TestReviewService_DeleteBookReview(t *testing.T) {
+	repo := &mockReviewRepo{}
+	svc := newReviewTestService(repo)
+
+	if err := svc.DeleteBookReview(context.Background(), 3, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastDeleted != [2]int{3, 1} {
+		t.Fatalf("expected delete for user 3 book 1, got %v", repo.LastDeleted)
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/repository"
+	"context"
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+type SearchService interface {
+	Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error)
+}
+
+type searchService struct {
+	repo repository.SearchRepo
+}
+
+func NewSearchService(repo repository.SearchRepo) SearchService {
+	return &searchService{repo: repo}
+}
+
+func (s *searchService) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Format == "" {
+		query.Format = domain.SearchFormatAll
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+
+	terms := searchTerms(query.Query)
+	if query.Prefix && len(terms) > 0 {
+		query.TSQuery = prefixTSQuery(terms)
+	}
+
+	results, err := s.repo.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Score = roundTo2(results[i].Score)
+		results[i].Highlight = domain.SearchHighlight{
+			Title:  highlightTerms(results[i].Title, terms, query.Prefix),
+			Author: highlightTerms(results[i].Author, terms, query.Prefix),
+		}
+	}
+	return results, nil
+}
+
+// searchTerms splits a query into lowercase words, dropping punctuation so the
+// words are safe to splice into a tsquery.
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixTSQuery turns "dune mes" into "dune:* & mes:*" for autocomplete.
+func prefixTSQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// highlightTerms HTML-escapes text and wraps every case-insensitive occurrence
+// of a term in <mark>. Outside prefix mode a match must cover a whole word, so
+// "dune" highlights "Dune" but not "Dunes".
+func highlightTerms(text string, terms []string, prefix bool) string {
+	if text == "" {
+		return ""
+	}
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for start := 0; start+len(termRunes) <= len(lower); start++ {
+			if start > 0 && isWordRune(lower[start-1]) {
+				continue
+			}
+			end := start + len(termRunes)
+			if string(lower[start:end]) != term {
+				continue
+			}
+			if !prefix && end < len(lower) && isWordRune(lower[end]) {
+				continue
+			}
+			for i := start; i < end; i++ {
+				marked[i] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + segment + "</mark>")
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockSearchRepo struct {
+	Results   []domain.SearchResult
+	Err       error
+	LastQuery domain.SearchQuery
+}
+
+func (m *mockSearchRepo) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	m.LastQuery = query
+	return m.Results, m.Err
+}
+
+func TestSearchService_Search_Defaults(t *testing.T) {
+	repo := &mockSearchRepo{}
+	svc := NewSearchService(repo)
+
+	if _, err := svc.Search(context.Background(), domain.SearchQuery{Query: "  dune  ", Limit: 500}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastQuery.Query != "dune" || repo.LastQuery.Format != domain.SearchFormatAll {
+		t.Fatalf("unexpected query: %+v", repo.LastQuery)
+	}
+	if repo.LastQuery.Limit != maxSearchLimit {
+		t.Fatalf("expected limit capped at %d, got %d", maxSearchLimit, repo.LastQuery.Limit)
+	}
+	if repo.LastQuery.TSQuery != "" {
+		t.Fatalf("expected no tsquery outside prefix mode, got %q", repo.LastQuery.TSQuery)
+	}
+}
+
+func TestSearchService_Search_Validation(t *testing.T) {
+	svc := NewSearchService(&mockSearchRepo{})
+
+	if _, err := svc.Search(context.Background(), domain.SearchQuery{Query: "   "}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for empty query, got %v", err)
+	}
+	if _, err := svc.Search(context.Background(), domain.SearchQuery{Query: "dune", Format: "ebook"}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for bad format, got %v", err)
+	}
+}
+
+func TestSearchService_Search_PrefixMode(t *testing.T) {
+	repo := &mockSearchRepo{Results: []domain.SearchResult{
+		{Type: domain.SearchFormatBook, ID: 1, Title: "Dune Messiah", Author: "Frank Herbert", Score: 0.8712},
+	}}
+	svc := NewSearchService(repo)
+
+	results, err := svc.Search(context.Background(), domain.SearchQuery{Query: "dune me'ss", Prefix: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastQuery.TSQuery != "dune:* & me:* & ss:*" {
+		t.Fatalf("unexpected tsquery: %q", repo.LastQuery.TSQuery)
+	}
+	if results[0].Score != 0.87 {
+		t.Fatalf("expected rounded score, got %v", results[0].Score)
+	}
+	if results[0].Highlight.Title != "<mark>Dune</mark> <mark>Me</mark>ssiah" {
+		t.Fatalf("unexpected title highlight: %q", results[0].Highlight.Title)
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	tests := []struct {
+		text   string
+		terms  []string
+		prefix bool
+		want   string
+	}{
+		{"Dune", []string{"dune"}, false, "<mark>Dune</mark>"},
+		{"Dunes of Arrakis", []string{"dune"}, false, "Dunes of Arrakis"},
+		{"Dunes of Arrakis", []string{"dune"}, true, "<mark>Dune</mark>s of Arrakis"},
+		{"Sandune", []string{"dune"}, true, "Sandune"},
+		{"Tom & Jerry", []string{"tom"}, false, "<mark>Tom</mark> &amp; Jerry"},
+		{"", []string{"dune"}, false, ""},
+	}
+	for _, tt := range tests {
+		if got := highlightTerms(tt.text, tt.terms, tt.prefix); got != tt.want {
+			t.Errorf("highlightTerms(%q, %v, %v) = %q, want %q", tt.text, tt.terms, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/repository"
+)
+
+type ShelfService interface {
+	GetAllByUser(ctx context.Context, userID int) ([]domain.Shelf, error)
+	GetByID(ctx context.Context, userID int, id int) (*domain.Shelf, error)
+	CheckOwned(ctx context.Context, userID int, id int) error
+	Create(ctx context.Context, shelf *domain.Shelf) (int, error)
+	Update(ctx context.Context, userID int, shelf *domain.Shelf) error
+	Delete(ctx context.Context, userID int, id int) error
+	AddItem(ctx context.Context, userID int, shelfID int, req *domain.AddShelfItemRequest) (*domain.ShelfItem, error)
+	RemoveItem(ctx context.Context, userID int, shelfID int, itemID int) error
+	ReorderItems(ctx context.Context, userID int, shelfID int, itemIDs []int) error
+}
+
+type shelfService struct {
+	repo  repository.ShelfRepo
+	views ProgressViews
+}
+
+func NewShelfService(repo repository.ShelfRepo, views ProgressViews) ShelfService {
+	return &shelfService{repo: repo, views: views}
+}
+
+func (s *shelfService) GetAllByUser(ctx context.Context, userID int) ([]domain.Shelf, error) {
+	return s.repo.GetAllByUser(ctx, userID)
+}
+
+func (s *shelfService) GetByID(ctx context.Context, userID int, id int) (*domain.Shelf, error) {
+	shelf, err := s.getOwnedShelf(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	shelf.Items = items
+	return shelf, nil
+}
+
+// CheckOwned returns ErrNotFound unless userID owns the shelf, so filtering by
+// someone else's shelf can't reveal that it exists or what's on it.
+func (s *shelfService) CheckOwned(ctx context.Context, userID int, id int) error {
+	_, err := s.getOwnedShelf(ctx, userID, id)
+	if err == errors.ErrForbidden {
+		return errors.ErrNotFound
+	}
+	return err
+}
+
+func (s *shelfService) Create(ctx context.Context, shelf *domain.Shelf) (int, error) {
+	if err := shelf.Validate(); err != nil {
+		return 0, err
+	}
+	return s.repo.Create(ctx, shelf)
+}
+
+func (s *shelfService) Update(ctx context.Context, userID int, shelf *domain.Shelf) error {
+	if err := shelf.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.getOwnedShelf(ctx, userID, shelf.ID); err != nil {
+		return err
+	}
+	shelf.UserID = userID
+	return s.repo.Update(ctx, shelf)
+}
+
+func (s *shelfService) Delete(ctx context.Context, userID int, id int) error {
+	if _, err := s.getOwnedShelf(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	progressChanged(ctx, s.views, userID)
+	return nil
+}
+
+func (s *shelfService) AddItem(ctx context.Context, userID int, shelfID int, req *domain.AddShelfItemRequest) (*domain.ShelfItem, error) {
+	item := &domain.ShelfItem{
+		ShelfID:     shelfID,
+		BookID:      req.BookID,
+		AudiobookID: req.AudiobookID,
+	}
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getOwnedShelf(ctx, userID, shelfID); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.AddItem(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	item.ID = id
+	progressChanged(ctx, s.views, userID)
+	return item, nil
+}
+
+func (s *shelfService) RemoveItem(ctx context.Context, userID int, shelfID int, itemID int) error {
+	if _, err := s.getOwnedShelf(ctx, userID, shelfID); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveItem(ctx, shelfID, itemID); err != nil {
+		return err
+	}
+	progressChanged(ctx, s.views, userID)
+	return nil
+}
+
+// ReorderItems sets item positions to match the order of itemIDs. The list
+// must contain every item on the shelf exactly once.
+func (s *shelfService) ReorderItems(ctx context.Context, userID int, shelfID int, itemIDs []int) error {
+	if _, err := s.getOwnedShelf(ctx, userID, shelfID); err != nil {
+		return err
+	}
+
+	items, err := s.repo.GetItems(ctx, shelfID)
+	if err != nil {
+		return err
+	}
+	if len(items) != len(itemIDs) {
+		return errors.ErrInvalidField("item_ids", "must list every item on the shelf exactly once")
+	}
+
+	current := make(map[int]bool, len(items))
+	for _, item := range items {
+		current[item.ID] = true
+	}
+	for _, id := range itemIDs {
+		if !current[id] {
+			return errors.ErrInvalidField("item_ids", "must list every item on the shelf exactly once")
+		}
+		delete(current, id)
+	}
+
+	return s.repo.ReorderItems(ctx, shelfID, itemIDs)
+}
+
+func (s *shelfService) getOwnedShelf(ctx context.Context, userID int, id int) (*domain.Shelf, error) {
+	shelf, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if shelf == nil {
+		return nil, errors.ErrNotFound
+	}
+	if shelf.UserID != userID {
+		return nil, errors.ErrForbidden
+	}
+	return shelf, nil
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockShelfRepo struct {
+	Shelves     map[int]domain.Shelf
+	Items       map[int][]domain.ShelfItem
+	Err         error
+	LastReorder []int
+	LastRemoved int
+	LastDeleted int
+	nextItemID  int
+}
+
+func (m *mockShelfRepo) GetAllByUser(ctx context.Context, userID int) ([]domain.Shelf, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var shelves []domain.Shelf
+	for _, shelf := range m.Shelves {
+		if shelf.UserID == userID {
+			shelves = append(shelves, shelf)
+		}
+	}
+	return shelves, nil
+}
+
+func (m *mockShelfRepo) GetByID(ctx context.Context, id int) (*domain.Shelf, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	shelf, ok := m.Shelves[id]
+	if !ok {
+		return nil, nil
+	}
+	return &shelf, nil
+}
+
+func (m *mockShelfRepo) Create(ctx context.Context, shelf *domain.Shelf) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	id := len(m.Shelves) + 1
+	shelf.ID = id
+	m.Shelves[id] = *shelf
+	return id, nil
+}
+
+func (m *mockShelfRepo) Update(ctx context.Context, shelf *domain.Shelf) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Shelves[shelf.ID] = *shelf
+	return nil
+}
+
+func (m *mockShelfRepo) Delete(ctx context.Context, id int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	delete(m.Shelves, id)
+	m.LastDeleted = id
+	return nil
+}
+
+func (m *mockShelfRepo) GetItems(ctx context.Context, shelfID int) ([]domain.ShelfItem, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Items[shelfID], nil
+}
+
+func (m *mockShelfRepo) AddItem(ctx context.Context, item *domain.ShelfItem) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	m.nextItemID++
+	item.ID = m.nextItemID
+	item.Position = len(m.Items[item.ShelfID])
+	m.Items[item.ShelfID] = append(m.Items[item.ShelfID], *item)
+	return item.ID, nil
+}
+
+func (m *mockShelfRepo) RemoveItem(ctx context.Context, shelfID int, itemID int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.LastRemoved = itemID
+	return nil
+}
+
+func (m *mockShelfRepo) ReorderItems(ctx context.Context, shelfID int, itemIDs []int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.LastReorder = itemIDs
+	return nil
+}
+
+func newMockShelfRepo() *mockShelfRepo {
+	return &mockShelfRepo{
+		Shelves: map[int]domain.Shelf{
+			1: {ID: 1, UserID: 1, Name: "Favorites"},
+			2: {ID: 2, UserID: 2, Name: "Someone else's"},
+		},
+		Items: map[int][]domain.ShelfItem{
+			1: {
+				{ID: 10, ShelfID: 1, BookID: ptrInt(1), Position: 0},
+				{ID: 11, ShelfID: 1, AudiobookID: ptrInt(2), Position: 1},
+			},
+		},
+		nextItemID: 11,
+	}
+}
+
+func TestShelfService_GetAllByUser(t *testing.T) {
+	svc := NewShelfService(newMockShelfRepo(), nil)
+
+	shelves, err := svc.GetAllByUser(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(shelves) != 1 || shelves[0].Name != "Favorites" {
+		t.Fatalf("expected only user 1's shelf, got %+v", shelves)
+	}
+}
+
+func TestShelfService_GetByID(t *testing.T) {
+	svc := NewShelfService(newMockShelfRepo(), nil)
+
+	t.Run("includes items", func(t *testing.T) {
+		shelf, err := svc.GetByID(context.Background(), 1, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(shelf.Items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(shelf.Items))
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := svc.GetByID(context.Background(), 1, 999); err != apperrors.ErrNotFound {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("other user's shelf", func(t *testing.T) {
+		if _, err := svc.GetByID(context.Background(), 1, 2); err != apperrors.ErrForbidden {
+			t.Fatalf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
+
+func TestShelfService_CheckOwned(t *testing.T) {
+	svc := NewShelfService(newMockShelfRepo(), nil)
+
+	if err := svc.CheckOwned(context.Background(), 1, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.CheckOwned(context.Background(), 1, 999); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := svc.CheckOwned(context.Background(), 1, 2); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for another user's shelf, got %v", err)
+	}
+}
+
+func TestShelfService_Create(t *testing.T) {
+	repo := newMockShelfRepo()
+	svc := NewShelfService(repo, nil)
+
+	shelf := &domain.Shelf{UserID: 1, Name: "To Read"}
+	id, err := svc.Create(context.Background(), shelf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := repo.Shelves[id]; !ok {
+		t.Fatal("expected shelf to be stored")
+	}
+
+	if _, err := svc.Create(context.Background(), &domain.Shelf{UserID: 1}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for empty name, got %v", err)
+	}
+}
+
+func TestShelfService_UpdateAndDelete(t *testing.T) {
+	repo := newMockShelfRepo()
+	svc := NewShelfService(repo, nil)
+
+	if err := svc.Update(context.Background(), 1, &domain.Shelf{ID: 1, Name: "Renamed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Shelves[1].Name != "Renamed" || repo.Shelves[1].UserID != 1 {
+		t.Fatalf("expected shelf renamed and owner kept, got %+v", repo.Shelves[1])
+	}
+
+	if err := svc.Update(context.Background(), 1, &domain.Shelf{ID: 2, Name: "Mine now"}); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+
+	if err := svc.Delete(context.Background(), 1, 2); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := svc.Delete(context.Background(), 1, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastDeleted != 1 {
+		t.Errorf("expected shelf 1 deleted, got %d", repo.LastDeleted)
+	}
+}
+
+func TestShelfService_AddItem(t *testing.T) {
+	repo := newMockShelfRepo()
+	svc := NewShelfService(repo, nil)
+
+	item, err := svc.AddItem(context.Background(), 1, 1, &domain.AddShelfItemRequest{BookID: ptrInt(5)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item.ID == 0 || item.Position != 2 {
+		t.Fatalf("expected new item appended at position 2, got %+v", item)
+	}
+
+	if _, err := svc.AddItem(context.Background(), 1, 1, &domain.AddShelfItemRequest{}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for empty item, got %v", err)
+	}
+	if _, err := svc.AddItem(context.Background(), 1, 1, &domain.AddShelfItemRequest{BookID: ptrInt(1), AudiobookID: ptrInt(1)}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for item with both ids, got %v", err)
+	}
+	if _, err := svc.AddItem(context.Background(), 1, 2, &domain.AddShelfItemRequest{BookID: ptrInt(5)}); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestShelfService_RemoveItem(t *testing.T) {
+	repo := newMockShelfRepo()
+	svc := NewShelfService(repo, nil)
+
+	if err := svc.RemoveItem(context.Background(), 1, 1, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.LastRemoved != 10 {
+		t.Errorf("expected item 10 removed, got %d", repo.LastRemoved)
+	}
+}
+
+func TestShelfService_ReorderItems(t *testing.T) {
+	repo := newMockShelfRepo()
+	svc := NewShelfService(repo, nil)
+
+	t.Run("valid order", func(t *testing.T) {
+		if err := svc.ReorderItems(context.Background(), 1, 1, []int{11, 10}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.LastReorder) != 2 || repo.LastReorder[0] != 11 {
+			t.Fatalf("expected reorder [11 10], got %v", repo.LastReorder)
+		}
+	})
+
+	t.Run("missing item", func(t *testing.T) {
+		if err := svc.ReorderItems(context.Background(), 1, 1, []int{11}); !apperrors.IsValidationError(err) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		if err := svc.ReorderItems(context.Background(), 1, 1, []int{11, 99}); !apperrors.IsValidationError(err) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("duplicate item", func(t *testing.T) {
+		if err := svc.ReorderItems(context.Background(), 1, 1, []int{11, 11}); !apperrors.IsValidationError(err) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+}
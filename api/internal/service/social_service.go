@@ -0,0 +1,310 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/infra"
+	"book_boy/api/internal/repository"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+type SocialService interface {
+	Follow(ctx context.Context, followerID int, followeeID int) (*domain.FollowResult, error)
+	Unfollow(ctx context.Context, followerID int, followeeID int) error
+	GetFollowRequests(ctx context.Context, userID int) ([]domain.UserSummary, error)
+	ApproveFollowRequest(ctx context.Context, userID int, requesterID int) error
+	DeclineFollowRequest(ctx context.Context, userID int, requesterID int) error
+	GetFollowers(ctx context.Context, viewerID int, userID int) ([]domain.UserSummary, error)
+	GetFollowing(ctx context.Context, viewerID int, userID int) ([]domain.UserSummary, error)
+	GetProfile(ctx context.Context, viewerID int, userID int) (*domain.Profile, error)
+	CheckProgressVisible(ctx context.Context, viewerID int, userID int) error
+	GetFeed(ctx context.Context, viewerID int, cursor string, limit int) (*domain.FeedPage, error)
+	RecordActivity(ctx context.Context, event *domain.ActivityEvent) error
+}
+
+type socialService struct {
+	followRepo   repository.FollowRepo
+	activityRepo repository.ActivityRepo
+	userRepo     repository.UserRepo
+	progressRepo repository.ProgressRepo
+	sseManager   *infra.SSEManager
+}
+
+func NewSocialService(followRepo repository.FollowRepo, activityRepo repository.ActivityRepo, userRepo repository.UserRepo, progressRepo repository.ProgressRepo, sseManager *infra.SSEManager) SocialService {
+	return &socialService{
+		followRepo:   followRepo,
+		activityRepo: activityRepo,
+		userRepo:     userRepo,
+		progressRepo: progressRepo,
+		sseManager:   sseManager,
+	}
+}
+
+// Follow follows a user with a public profile straight away. Anyone else has
+// to approve the follower first, so a request is filed instead.
+func (s *socialService) Follow(ctx context.Context, followerID int, followeeID int) (*domain.FollowResult, error) {
+	if followerID == followeeID {
+		return nil, errors.ErrInvalidInput("you cannot follow yourself")
+	}
+	followee, err := s.getUser(ctx, followeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	following, err := s.followRepo.IsFollowing(ctx, followerID, followeeID)
+	if err != nil {
+		return nil, err
+	}
+	if following {
+		return &domain.FollowResult{Status: domain.FollowStatusFollowing}, nil
+	}
+
+	if followee.ProfilePrivacy != domain.PrivacyPublic {
+		if err := s.followRepo.RequestFollow(ctx, followerID, followeeID); err != nil {
+			return nil, err
+		}
+		return &domain.FollowResult{Status: domain.FollowStatusRequested}, nil
+	}
+	if err := s.followRepo.Follow(ctx, followerID, followeeID); err != nil {
+		return nil, err
+	}
+	return &domain.FollowResult{Status: domain.FollowStatusFollowing}, nil
+}
+
+// Unfollow stops following a user, withdrawing the request too if it hasn't
+// been approved yet.
+func (s *socialService) Unfollow(ctx context.Context, followerID int, followeeID int) error {
+	if _, err := s.followRepo.DeleteFollowRequest(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+	return s.followRepo.Unfollow(ctx, followerID, followeeID)
+}
+
+func (s *socialService) GetFollowRequests(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	return s.followRepo.GetFollowRequests(ctx, userID)
+}
+
+func (s *socialService) ApproveFollowRequest(ctx context.Context, userID int, requesterID int) error {
+	approved, err := s.followRepo.ApproveFollowRequest(ctx, requesterID, userID)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+func (s *socialService) DeclineFollowRequest(ctx context.Context, userID int, requesterID int) error {
+	deleted, err := s.followRepo.DeleteFollowRequest(ctx, requesterID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+func (s *socialService) GetFollowers(ctx context.Context, viewerID int, userID int) ([]domain.UserSummary, error) {
+	if _, _, err := s.getVisibleProfileOwner(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+	return s.followRepo.GetFollowers(ctx, userID)
+}
+
+func (s *socialService) GetFollowing(ctx context.Context, viewerID int, userID int) ([]domain.UserSummary, error) {
+	if _, _, err := s.getVisibleProfileOwner(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+	return s.followRepo.GetFollowing(ctx, userID)
+}
+
+func (s *socialService) GetProfile(ctx context.Context, viewerID int, userID int) (*domain.Profile, error) {
+	user, isFollowing, err := s.getVisibleProfileOwner(ctx, viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	followers, following, err := s.followRepo.Counts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &domain.Profile{
+		ID:             user.ID,
+		Username:       user.Username,
+		FollowerCount:  followers,
+		FollowingCount: following,
+		IsFollowing:    isFollowing,
+	}
+
+	if user.ProgressPrivacy.Allows(viewerID == userID, isFollowing) {
+		reading, err := s.progressRepo.GetAllEnrichedByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		profile.CurrentlyReading = reading
+	}
+	return profile, nil
+}
+
+// CheckProgressVisible returns ErrForbidden unless viewerID may see both
+// userID's profile and their progress.
+func (s *socialService) CheckProgressVisible(ctx context.Context, viewerID int, userID int) error {
+	if viewerID == userID {
+		return nil
+	}
+	user, isFollowing, err := s.getVisibleProfileOwner(ctx, viewerID, userID)
+	if err != nil {
+		return err
+	}
+	if !user.ProgressPrivacy.Allows(false, isFollowing) {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
+func (s *socialService) GetFeed(ctx context.Context, viewerID int, cursor string, limit int) (*domain.FeedPage, error) {
+	beforeID, err := decodeFeedCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	events, err := s.activityRepo.GetFeed(ctx, viewerID, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.FeedPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = encodeFeedCursor(page.Events[limit-1].ID)
+	}
+	if page.Events == nil {
+		page.Events = []domain.ActivityEvent{}
+	}
+	return page, nil
+}
+
+// RecordActivity stores a feed event and pushes it to the author's followers
+// over SSE when the author's privacy settings let followers see it.
+func (s *socialService) RecordActivity(ctx context.Context, event *domain.ActivityEvent) error {
+	if err := s.activityRepo.Create(ctx, event); err != nil {
+		return err
+	}
+	if s.sseManager == nil {
+		return nil
+	}
+
+	author, err := s.getUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	level := author.ProgressPrivacy
+	if event.Type == domain.ActivityRated {
+		level = author.ReviewPrivacy
+	}
+	if !author.ProfilePrivacy.Allows(false, true) || !level.Allows(false, true) {
+		return nil
+	}
+
+	enriched, err := s.activityRepo.GetByID(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+	if enriched == nil {
+		return nil
+	}
+
+	followers, err := s.followRepo.GetFollowers(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	for _, follower := range followers {
+		s.sseManager.SendToUser(follower.ID, "feed.activity", enriched)
+	}
+	return nil
+}
+
+func (s *socialService) getUser(ctx context.Context, id int) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrNotFound
+	}
+	return user, nil
+}
+
+// getVisibleProfileOwner loads userID and checks the viewer may see their
+// profile, also reporting whether the viewer follows them.
+func (s *socialService) getVisibleProfileOwner(ctx context.Context, viewerID int, userID int) (*domain.User, bool, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	isFollowing := false
+	if viewerID != userID {
+		isFollowing, err = s.followRepo.IsFollowing(ctx, viewerID, userID)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if !user.ProfilePrivacy.Allows(viewerID == userID, isFollowing) {
+		return nil, false, errors.ErrForbidden
+	}
+	return user, isFollowing, nil
+}
+
+// publishActivity records a feed event on behalf of another service. Like goal
+// tracking, the feed is best effort and never fails the caller's write.
+func publishActivity(ctx context.Context, social SocialService, logger *slog.Logger, event *domain.ActivityEvent) {
+	if social == nil {
+		return
+	}
+	// Followers hear about the write only once it commits, and a client
+	// hanging up after that shouldn't drop it from the feed.
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		if err := social.RecordActivity(context.WithoutCancel(ctx), event); err != nil {
+			logger.WarnContext(ctx, "failed to record feed activity", "type", event.Type, "user_id", event.UserID, "error", err)
+		}
+	})
+}
+
+func encodeFeedCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeFeedCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.ErrInvalidInput("invalid cursor")
+	}
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id <= 0 {
+		return 0, errors.ErrInvalidInput("invalid cursor")
+	}
+	return id, nil
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+)
+
+type mockFollowRepo struct {
+	Follows  map[[2]int]bool
+	Requests map[[2]int]bool
+	Err      error
+}
+
+func (m *mockFollowRepo) Follow(ctx context.Context, followerID int, followeeID int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Follows[[2]int{followerID, followeeID}] = true
+	return nil
+}
+
+func (m *mockFollowRepo) Unfollow(ctx context.Context, followerID int, followeeID int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	delete(m.Follows, [2]int{followerID, followeeID})
+	return nil
+}
+
+func (m *mockFollowRepo) IsFollowing(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	if m.Err != nil {
+		return false, m.Err
+	}
+	return m.Follows[[2]int{followerID, followeeID}], nil
+}
+
+func (m *mockFollowRepo) GetFollowers(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var users []domain.UserSummary
+	for pair := range m.Follows {
+		if pair[1] == userID {
+			users = append(users, domain.UserSummary{ID: pair[0]})
+		}
+	}
+	return users, nil
+}
+
+func (m *mockFollowRepo) GetFollowing(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var users []domain.UserSummary
+	for pair := range m.Follows {
+		if pair[0] == userID {
+			users = append(users, domain.UserSummary{ID: pair[1]})
+		}
+	}
+	return users, nil
+}
+
+func (m *mockFollowRepo) Counts(ctx context.Context, userID int) (int, int, error) {
+	followers, _ := m.GetFollowers(context.Background(), userID)
+	following, _ := m.GetFollowing(context.Background(), userID)
+	return len(followers), len(following), m.Err
+}
+
+func (m *mockFollowRepo) RequestFollow(ctx context.Context, followerID int, followeeID int) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	if m.Requests == nil {
+		m.Requests = map[[2]int]bool{}
+	}
+	m.Requests[[2]int{followerID, followeeID}] = true
+	return nil
+}
+
+func (m *mockFollowRepo) GetFollowRequests(ctx context.Context, userID int) ([]domain.UserSummary, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var users []domain.UserSummary
+	for pair := range m.Requests {
+		if pair[1] == userID {
+			users = append(users, domain.UserSummary{ID: pair[0]})
+		}
+	}
+	return users, nil
+}
+
+func (m *mockFollowRepo) ApproveFollowRequest(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	if m.Err != nil {
+		return false, m.Err
+	}
+	pair := [2]int{followerID, followeeID}
+	if !m.Requests[pair] {
+		return false, nil
+	}
+	delete(m.Requests, pair)
+	m.Follows[pair] = true
+	return true, nil
+}
+
+func (m *mockFollowRepo) DeleteFollowRequest(ctx context.Context, followerID int, followeeID int) (bool, error) {
+	if m.Err != nil {
+		return false, m.Err
+	}
+	pair := [2]int{followerID, followeeID}
+	existed := m.Requests[pair]
+	delete(m.Requests, pair)
+	return existed, nil
+}
+
+// mockActivityRepo keeps events in insertion order; GetFeed mirrors the SQL
+// (followed authors, newest first, private profiles and levels hidden).
+type mockActivityRepo struct {
+	Events  []domain.ActivityEvent
+	Follows *mockFollowRepo
+	Users   *mockUserRepo
+	Err     error
+}
+
+func (m *mockActivityRepo) Create(ctx context.Context, event *domain.ActivityEvent) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	event.ID = len(m.Events) + 1
+	event.CreatedAt = time.Now()
+	m.Events = append(m.Events, *event)
+	return nil
+}
+
+func (m *mockActivityRepo) GetByID(ctx context.Context, id int) (*domain.ActivityEvent, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if id < 1 || id > len(m.Events) {
+		return nil, nil
+	}
+	event := m.Events[id-1]
+	return &event, nil
+}
+
+func (m *mockActivityRepo) GetFeed(ctx context.Context, viewerID int, beforeID int, limit int) ([]domain.ActivityEvent, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var events []domain.ActivityEvent
+	for i := len(m.Events) - 1; i >= 0 && len(events) < limit; i-- {
+		event := m.Events[i]
+		if beforeID != 0 && event.ID >= beforeID {
+			continue
+		}
+		if !m.Follows.Follows[[2]int{viewerID, event.UserID}] {
+			continue
+		}
+		author := m.Users.Users[event.UserID]
+		if author.ProfilePrivacy == domain.PrivacyPrivate {
+			continue
+		}
+		level := author.ProgressPrivacy
+		if event.Type == domain.ActivityRated {
+			level = author.ReviewPrivacy
+		}
+		if level == domain.PrivacyPrivate {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func newSocialTestService() (SocialService, *mockFollowRepo, *mockActivityRepo, *mockUserRepo, *mockProgressRepo) {
+	users := &mockUserRepo{Users: map[int]domain.User{
+		1: {ID: 1, Username: "ann", ProfilePrivacy: domain.PrivacyPublic, ProgressPrivacy: domain.PrivacyPublic, ReviewPrivacy: domain.PrivacyPublic},
+		2: {ID: 2, Username: "ben", ProfilePrivacy: domain.PrivacyFollowers, ProgressPrivacy: domain.PrivacyFollowers, ReviewPrivacy: domain.PrivacyPrivate},
+		3: {ID: 3, Username: "cat", ProfilePrivacy: domain.PrivacyPublic, ProgressPrivacy: domain.PrivacyPrivate, ReviewPrivacy: domain.PrivacyPublic},
+	}}
+	follows := &mockFollowRepo{Follows: map[[2]int]bool{}}
+	activities := &mockActivityRepo{Follows: follows, Users: users}
+	progress := &mockProgressRepo{Data: map[int]domain.Progress{
+		1: {ID: 1, UserID: 2},
+	}}
+	return NewSocialService(follows, activities, users, progress, nil), follows, activities, users, progress
+}
+
+func TestSocialService_Follow(t *testing.T) {
+	svc, follows, _, _, _ := newSocialTestService()
+
+	result, err := svc.Follow(context.Background(), 2, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != domain.FollowStatusFollowing || !follows.Follows[[2]int{2, 1}] {
+		t.Fatalf("expected public profile to be followed at once, got %+v", result)
+	}
+	if _, err := svc.Follow(context.Background(), 1, 1); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for self-follow, got %v", err)
+	}
+	if _, err := svc.Follow(context.Background(), 1, 99); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := svc.Unfollow(context.Background(), 2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if follows.Follows[[2]int{2, 1}] {
+		t.Fatal("expected follow to be removed")
+	}
+}
+
+func TestSocialService_Follow_NonPublicProfileNeedsApproval(t *testing.T) {
+	svc, follows, _, _, _ := newSocialTestService()
+
+	result, err := svc.Follow(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != domain.FollowStatusRequested {
+		t.Fatalf("expected a follow request, got %+v", result)
+	}
+	if follows.Follows[[2]int{1, 2}] {
+		t.Fatal("expected no follow before approval")
+	}
+	if _, err := svc.GetProfile(context.Background(), 1, 2); err != apperrors.ErrForbidden {
+		t.Fatalf("expected a pending requester to be kept out, got %v", err)
+	}
+
+	requests, err := svc.GetFollowRequests(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 1 || requests[0].ID != 1 {
+		t.Fatalf("expected one request from user 1, got %+v", requests)
+	}
+
+	if err := svc.ApproveFollowRequest(context.Background(), 2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !follows.Follows[[2]int{1, 2}] {
+		t.Fatal("expected approval to create the follow")
+	}
+	if err := svc.ApproveFollowRequest(context.Background(), 2, 1); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for an answered request, got %v", err)
+	}
+	if result, _ := svc.Follow(context.Background(), 1, 2); result.Status != domain.FollowStatusFollowing {
+		t.Fatalf("expected an existing follower to stay following, got %+v", result)
+	}
+}
+
+func TestSocialService_DeclineAndWithdrawFollowRequest(t *testing.T) {
+	svc, follows, _, _, _ := newSocialTestService()
+
+	svc.Follow(context.Background(), 1, 2)
+	if err := svc.DeclineFollowRequest(context.Background(), 2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if follows.Follows[[2]int{1, 2}] || follows.Requests[[2]int{1, 2}] {
+		t.Fatal("expected a declined request to leave nothing behind")
+	}
+	if err := svc.DeclineFollowRequest(context.Background(), 2, 1); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	svc.Follow(context.Background(), 1, 2)
+	if err := svc.Unfollow(context.Background(), 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if follows.Requests[[2]int{1, 2}] {
+		t.Fatal("expected unfollow to withdraw the pending request")
+	}
+}
+
+func TestSocialService_GetProfile_Privacy(t *testing.T) {
+	svc, follows, _, _, _ := newSocialTestService()
+
+	if _, err := svc.GetProfile(context.Background(), 1, 2); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for followers-only profile, got %v", err)
+	}
+
+	follows.Follows[[2]int{1, 2}] = true
+	profile, err := svc.GetProfile(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !profile.IsFollowing || profile.FollowerCount != 1 {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	if len(profile.CurrentlyReading) != 1 {
+		t.Fatalf("expected follower to see progress, got %d entries", len(profile.CurrentlyReading))
+	}
+
+	own, err := svc.GetProfile(context.Background(), 3, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if own.IsFollowing {
+		t.Fatal("expected own profile not to report following")
+	}
+}
+
+func TestSocialService_CheckProgressVisible(t *testing.T) {
+	svc, follows, _, _, _ := newSocialTestService()
+	ctx := context.Background()
+
+	if err := svc.CheckProgressVisible(ctx, 3, 3); err != nil {
+		t.Fatalf("expected owners to see their own progress, got %v", err)
+	}
+	if err := svc.CheckProgressVisible(ctx, 1, 3); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for private progress, got %v", err)
+	}
+	if err := svc.CheckProgressVisible(ctx, 1, 2); err != apperrors.ErrForbidden {
+		t.Fatalf("expected ErrForbidden for a non-follower, got %v", err)
+	}
+	follows.Follows[[2]int{1, 2}] = true
+	if err := svc.CheckProgressVisible(ctx, 1, 2); err != nil {
+		t.Fatalf("expected a follower to see followers-only progress, got %v", err)
+	}
+	if err := svc.CheckProgressVisible(ctx, 1, 99); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSocialService_GetFeed_Pagination(t *testing.T) {
+	svc, follows, activities, _, _ := newSocialTestService()
+	follows.Follows[[2]int{2, 1}] = true
+
+	for i := 0; i < 5; i++ {
+		if err := svc.RecordActivity(context.Background(), &domain.ActivityEvent{UserID: 1, Type: domain.ActivityStarted}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(activities.Events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(activities.Events))
+	}
+
+	first, err := svc.GetFeed(context.Background(), 2, "", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Events) != 3 || first.Events[0].ID != 5 || first.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+
+	second, err := svc.GetFeed(context.Background(), 2, first.NextCursor, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(second.Events) != 2 || second.Events[0].ID != 2 || second.NextCursor != "" {
+		t.Fatalf("unexpected second page: %+v", second)
+	}
+
+	if _, err := svc.GetFeed(context.Background(), 2, "not-a-cursor!", 3); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for bad cursor, got %v", err)
+	}
+}
+
+func TestSocialService_GetFeed_HidesPrivateActivity(t *testing.T) {
+	svc, follows, _, _, _ := newSocialTestService()
+	follows.Follows[[2]int{1, 2}] = true
+	follows.Follows[[2]int{1, 3}] = true
+
+	rating := 4.5
+	svc.RecordActivity(context.Background(), &domain.ActivityEvent{UserID: 2, Type: domain.ActivityStarted})
+	svc.RecordActivity(context.Background(), &domain.ActivityEvent{UserID: 2, Type: domain.ActivityRated, Rating: &rating})
+	svc.RecordActivity(context.Background(), &domain.ActivityEvent{UserID: 3, Type: domain.ActivityFinished})
+	svc.RecordActivity(context.Background(), &domain.ActivityEvent{UserID: 3, Type: domain.ActivityRated, Rating: &rating})
+
+	page, err := svc.GetFeed(context.Background(), 1, "", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Events) != 2 {
+		t.Fatalf("expected 2 visible events, got %+v", page.Events)
+	}
+	if page.Events[0].UserID != 3 || page.Events[0].Type != domain.ActivityRated {
+		t.Fatalf("unexpected newest event: %+v", page.Events[0])
+	}
+	if page.Events[1].UserID != 2 || page.Events[1].Type != domain.ActivityStarted {
+		t.Fatalf("unexpected oldest event: %+v", page.Events[1])
+	}
+}
+
+func TestSocialService_GetFeed_HidesPrivateProfiles(t *testing.T) {
+	svc, follows, _, users, _ := newSocialTestService()
+	users.Users[4] = domain.User{ID: 4, Username: "dee", ProfilePrivacy: domain.PrivacyPrivate, ProgressPrivacy: domain.PrivacyPublic, ReviewPrivacy: domain.PrivacyPublic}
+	follows.Follows[[2]int{1, 4}] = true
+
+	svc.RecordActivity(context.Background(), &domain.ActivityEvent{UserID: 4, Type: domain.ActivityStarted})
+
+	page, err := svc.GetFeed(context.Background(), 1, "", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Events) != 0 {
+		t.Fatalf("expected a private profile's activity to stay out of the feed, got %+v", page.Events)
+	}
+}
+
+func TestProgressService_PublishesFeedActivity(t *testing.T) {
+	social, _, activities, _, _ := newSocialTestService()
+	bookID := 1
+	progressRepo := &mockProgressRepo{Data: map[int]domain.Progress{}}
+	svc := NewProgressService(progressRepo, nil, social, nil, nil)
+
+	page := 1
+	id, err := svc.Create(context.Background(), &domain.Progress{UserID: 1, BookID: &bookID, BookPage: &page})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.UpdateProgressPage(context.Background(), id, 500, domain.UpdateCondition{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(activities.Events) != 2 {
+		t.Fatalf("expected started and finished events, got %+v", activities.Events)
+	}
+	if activities.Events[0].Type != domain.ActivityStarted || activities.Events[1].Type != domain.ActivityFinished {
+		t.Fatalf("unexpected event types: %s, %s", activities.Events[0].Type, activities.Events[1].Type)
+	}
+	if activities.Events[1].ProgressID == nil || *activities.Events[1].ProgressID != id {
+		t.Fatalf("expected finished event to reference progress %d", id)
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/logging"
+	"book_boy/api/internal/repository"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const syncTokenPrefix = "v1:"
+
+type SyncService interface {
+	Sync(ctx context.Context, userID int, req *domain.SyncRequest) (*domain.SyncResponse, error)
+}
+
+type syncService struct {
+	repo   repository.SyncRepo
+	goals  GoalService
+	social SocialService
+	views  ProgressViews
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewSyncService(repo repository.SyncRepo, goals GoalService, social SocialService, views ProgressViews, logger *slog.Logger) SyncService {
+	return &syncService{repo: repo, goals: goals, social: social, views: views, logger: logging.OrDiscard(logger), now: time.Now}
+}
+
+// syncOutcome is what resolving one entry did, kept for the activity it feeds.
+type syncOutcome struct {
+	before     domain.Progress
+	after      domain.Progress
+	target     domain.SyncTarget
+	recordedAt time.Time
+}
+
+// Sync applies the client's offline mutations in one transaction and returns
+// everything that changed for the user since the client's token, including the
+// entries this sync just wrote.
+//
+// Conflicts are resolved per field, independent of the order mutations arrive:
+//   - book_page and audiobook_time: the furthest position wins, whether it is
+//     the server's or any mutation's. Rewinding needs an online PUT.
+//   - when the entry has both formats, whichever moved furthest through the
+//     work sets the other by page/time conversion.
+//
+// Mutations on entries the user doesn't own, or for a format the entry isn't
+// linked to, are rejected without failing the rest of the batch.
+func (s *syncService) Sync(ctx context.Context, userID int, req *domain.SyncRequest) (*domain.SyncResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	since, err := decodeSyncToken(req.SyncToken)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.SyncResult, len(req.Mutations))
+	byProgress := make(map[int][]int)
+	var progressIDs []int
+	for i, m := range req.Mutations {
+		results[i] = domain.SyncResult{Index: i, ProgressID: m.ProgressID}
+		if _, ok := byProgress[m.ProgressID]; !ok {
+			progressIDs = append(progressIDs, m.ProgressID)
+		}
+		byProgress[m.ProgressID] = append(byProgress[m.ProgressID], i)
+	}
+
+	var outcomes []syncOutcome
+	if len(progressIDs) > 0 {
+		err := s.repo.ApplyMutations(ctx, userID, progressIDs, func(targets []domain.SyncTarget) ([]domain.Progress, error) {
+			outcomes = outcomes[:0]
+			found := make(map[int]bool, len(targets))
+			var changed []domain.Progress
+			for _, target := range targets {
+				found[target.Progress.ID] = true
+				outcome, ok := resolveSyncTarget(target, req.Mutations, byProgress[target.Progress.ID], results)
+				if ok {
+					changed = append(changed, outcome.after)
+					outcomes = append(outcomes, outcome)
+				}
+			}
+			for _, id := range progressIDs {
+				if !found[id] {
+					for _, i := range byProgress[id] {
+						results[i].Status = domain.SyncRejected
+						results[i].Reason = "progress not found"
+					}
+				}
+			}
+			return changed, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(outcomes) > 0 {
+		progressChanged(ctx, s.views, userID)
+	}
+	for _, outcome := range outcomes {
+		s.recordActivity(ctx, outcome)
+	}
+
+	changes, err := s.repo.GetChangesSince(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SyncResponse{
+		SyncToken: encodeSyncToken(changes.Horizon),
+		Results:   results,
+		Changes:   changes.Progress,
+		Deleted:   changes.DeletedIDs,
+	}, nil
+}
+
+// resolveSyncTarget replays the entry's mutations oldest first, filling in
+// their results. ok is false when the entry ends where it started.
+func resolveSyncTarget(target domain.SyncTarget, mutations []domain.SyncMutation, indexes []int, results []domain.SyncResult) (syncOutcome, bool) {
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return mutations[indexes[a]].RecordedAt.Before(mutations[indexes[b]].RecordedAt)
+	})
+
+	current := target.Progress
+	hasLength := target.TotalLength != nil && target.TotalLength.Duration > 0
+	page := 0
+	if current.BookPage != nil {
+		page = *current.BookPage
+	}
+	var position time.Duration
+	if current.AudiobookTime != nil {
+		position = current.AudiobookTime.Duration
+	}
+
+	pageMoved, timeMoved := false, false
+	var recordedAt time.Time
+	for _, i := range indexes {
+		m := mutations[i]
+		if (m.BookPage != nil && current.BookID == nil) || (m.AudiobookTime != nil && current.AudiobookID == nil) {
+			results[i].Status = domain.SyncRejected
+			results[i].Reason = "progress is not linked to that format"
+			continue
+		}
+
+		applied := false
+		if m.BookPage != nil {
+			p := *m.BookPage
+			if target.TotalPages > 0 && p > target.TotalPages {
+				p = target.TotalPages
+			}
+			if p > page {
+				page, pageMoved, applied = p, true, true
+			}
+		}
+		if m.AudiobookTime != nil {
+			t := m.AudiobookTime.Duration
+			if hasLength && t > target.TotalLength.Duration {
+				t = target.TotalLength.Duration
+			}
+			if t > position {
+				position, timeMoved, applied = t, true, true
+			}
+		}
+
+		if applied {
+			results[i].Status = domain.SyncApplied
+			recordedAt = m.RecordedAt
+		} else {
+			results[i].Status = domain.SyncSuperseded
+		}
+	}
+	if !pageMoved && !timeMoved {
+		return syncOutcome{}, false
+	}
+
+	// With both formats linked, the one further through the work wins and the
+	// other follows it.
+	if current.BookID != nil && current.AudiobookID != nil && target.TotalPages > 0 && hasLength {
+		total := target.TotalLength.Duration
+		pageFraction := float64(page) / float64(target.TotalPages)
+		timeFraction := position.Seconds() / total.Seconds()
+		if pageFraction >= timeFraction {
+			position, _ = pageToTimestamp(target.TotalPages, page, total)
+		} else {
+			page, _ = timestampToPage(target.TotalPages, position, total)
+		}
+		pageMoved, timeMoved = true, true
+	}
+
+	after := current
+	if pageMoved {
+		after.BookPage = &page
+	}
+	if timeMoved {
+		after.AudiobookTime = &domain.CustomDuration{Duration: position}
+	}
+	return syncOutcome{before: current, after: after, target: target, recordedAt: recordedAt}, true
+}
+
+func (s *syncService) recordActivity(ctx context.Context, outcome syncOutcome) {
+	before, after, target := outcome.before, outcome.after, outcome.target
+
+	oldPage, newPage := 0, 0
+	if before.BookPage != nil {
+		oldPage = *before.BookPage
+	}
+	if after.BookPage != nil {
+		newPage = *after.BookPage
+	}
+	var oldTime, newTime time.Duration
+	if before.AudiobookTime != nil {
+		oldTime = before.AudiobookTime.Duration
+	}
+	if after.AudiobookTime != nil {
+		newTime = after.AudiobookTime.Duration
+	}
+
+	finished := target.TotalPages > 0 && oldPage < target.TotalPages && newPage >= target.TotalPages
+	if target.TotalLength != nil && target.TotalLength.Duration > 0 {
+		finished = finished || (oldTime < target.TotalLength.Duration && newTime >= target.TotalLength.Duration)
+	}
+
+	// Client clocks can run ahead; never date reading in the future.
+	recordedAt := outcome.recordedAt.UTC()
+	if now := s.now().UTC(); recordedAt.After(now) {
+		recordedAt = now
+	}
+
+	recordGoalActivity(ctx, s.goals, s.logger, &domain.ProgressActivity{
+		UserID:       after.UserID,
+		ProgressID:   after.ID,
+		PagesDelta:   newPage - oldPage,
+		SecondsDelta: int((newTime - oldTime) / time.Second),
+		Finished:     finished,
+		RecordedAt:   recordedAt,
+	})
+	if finished {
+		progressID := after.ID
+		publishActivity(ctx, s.social, s.logger, &domain.ActivityEvent{
+			UserID:      after.UserID,
+			Type:        domain.ActivityFinished,
+			ProgressID:  &progressID,
+			BookID:      after.BookID,
+			AudiobookID: after.AudiobookID,
+		})
+	}
+}
+
+func encodeSyncToken(horizon int64) string {
+	if horizon == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(horizon, 10)))
+}
+
+func decodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), syncTokenPrefix) {
+		return 0, errors.ErrInvalidInput("invalid sync_token")
+	}
+	horizon, err := strconv.ParseInt(strings.TrimPrefix(string(raw), syncTokenPrefix), 10, 64)
+	if err != nil || horizon <= 0 {
+		return 0, errors.ErrInvalidInput("invalid sync_token")
+	}
+	return horizon, nil
+}
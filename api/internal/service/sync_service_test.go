@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+	"book_boy/api/internal/repository"
+)
+
+type mockSyncRepo struct {
+	Targets map[int]domain.SyncTarget
+	Changes domain.SyncChanges
+	Since   int64
+	Written []domain.Progress
+	Err     error
+}
+
+func (m *mockSyncRepo) ApplyMutations(ctx context.Context, userID int, progressIDs []int, resolve repository.SyncResolveFunc) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	var targets []domain.SyncTarget
+	for _, id := range progressIDs {
+		if target, ok := m.Targets[id]; ok && target.Progress.UserID == userID {
+			targets = append(targets, target)
+		}
+	}
+	changed, err := resolve(targets)
+	if err != nil {
+		return err
+	}
+	for _, p := range changed {
+		p.Version++
+		target := m.Targets[p.ID]
+		target.Progress = p
+		m.Targets[p.ID] = target
+		m.Written = append(m.Written, p)
+	}
+	return nil
+}
+
+func (m *mockSyncRepo) GetChangesSince(ctx context.Context, userID int, horizon int64) (*domain.SyncChanges, error) {
+	m.Since = horizon
+	changes := m.Changes
+	if changes.Horizon == 0 {
+		changes.Horizon = horizon
+	}
+	return &changes, nil
+}
+
+func TestSyncService_FurthestPositionWins(t *testing.T) {
+	bookID := 1
+	page := 100
+	repo := &mockSyncRepo{Targets: map[int]domain.SyncTarget{
+		1: {Progress: domain.Progress{ID: 1, UserID: 7, BookID: &bookID, BookPage: &page, Version: 3}, TotalPages: 400},
+	}}
+	goals := &mockGoalRepo{}
+	svc := NewSyncService(repo, NewGoalService(goals, nil), nil, nil, nil)
+
+	t1 := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	pages := func(n int) *int { return &n }
+	resp, err := svc.Sync(context.Background(), 7, &domain.SyncRequest{Mutations: []domain.SyncMutation{
+		{ProgressID: 1, RecordedAt: t1.Add(time.Hour), BookPage: pages(150)},
+		{ProgressID: 1, RecordedAt: t1, BookPage: pages(120)},
+		{ProgressID: 1, RecordedAt: t1.Add(2 * time.Hour), BookPage: pages(90)},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []domain.SyncStatus{domain.SyncApplied, domain.SyncApplied, domain.SyncSuperseded}
+	for i, status := range want {
+		if resp.Results[i].Status != status {
+			t.Fatalf("mutation %d: expected %s, got %+v", i, status, resp.Results[i])
+		}
+	}
+	if len(repo.Written) != 1 || *repo.Written[0].BookPage != 150 {
+		t.Fatalf("expected a single write at page 150, got %+v", repo.Written)
+	}
+	if len(goals.Activities) != 1 || goals.Activities[0].PagesDelta != 50 || !goals.Activities[0].RecordedAt.Equal(t1.Add(time.Hour)) {
+		t.Fatalf("expected 50 pages dated at the winning mutation, got %+v", goals.Activities)
+	}
+}
+
+func TestSyncService_ReconcilesFormats(t *testing.T) {
+	bookID, audiobookID := 1, 2
+	page := 100
+	repo := &mockSyncRepo{Targets: map[int]domain.SyncTarget{
+		1: {
+			Progress: domain.Progress{
+				ID: 1, UserID: 7, BookID: &bookID, AudiobookID: &audiobookID,
+				BookPage: &page, AudiobookTime: &domain.CustomDuration{Duration: time.Hour},
+			},
+			TotalPages:  500,
+			TotalLength: &domain.CustomDuration{Duration: 10 * time.Hour},
+		},
+	}}
+	svc := NewSyncService(repo, nil, nil, nil, nil)
+
+	_, err := svc.Sync(context.Background(), 7, &domain.SyncRequest{Mutations: []domain.SyncMutation{
+		{ProgressID: 1, RecordedAt: time.Now(), AudiobookTime: &domain.CustomDuration{Duration: 6 * time.Hour}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := repo.Targets[1].Progress
+	if *got.BookPage != 300 || got.AudiobookTime.Duration != 6*time.Hour {
+		t.Fatalf("expected page 300 to follow 6h, got page %d at %v", *got.BookPage, got.AudiobookTime.Duration)
+	}
+}
+
+func TestSyncService_RejectsWithoutFailingBatch(t *testing.T) {
+	bookID := 1
+	page := 10
+	repo := &mockSyncRepo{Targets: map[int]domain.SyncTarget{
+		1: {Progress: domain.Progress{ID: 1, UserID: 7, BookID: &bookID, BookPage: &page}, TotalPages: 400},
+		2: {Progress: domain.Progress{ID: 2, UserID: 8, BookID: &bookID, BookPage: &page}, TotalPages: 400},
+	}}
+	svc := NewSyncService(repo, nil, nil, nil, nil)
+
+	twenty := 20
+	now := time.Now()
+	resp, err := svc.Sync(context.Background(), 7, &domain.SyncRequest{Mutations: []domain.SyncMutation{
+		{ProgressID: 1, RecordedAt: now, BookPage: &twenty},
+		{ProgressID: 2, RecordedAt: now, BookPage: &twenty},
+		{ProgressID: 99, RecordedAt: now, BookPage: &twenty},
+		{ProgressID: 1, RecordedAt: now, AudiobookTime: &domain.CustomDuration{Duration: time.Hour}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.SyncStatus{domain.SyncApplied, domain.SyncRejected, domain.SyncRejected, domain.SyncRejected}
+	for i, status := range want {
+		if resp.Results[i].Status != status {
+			t.Fatalf("mutation %d: expected %s, got %+v", i, status, resp.Results[i])
+		}
+	}
+	if *repo.Targets[2].Progress.BookPage != 10 {
+		t.Fatal("expected another user's progress to be left alone")
+	}
+}
+
+func TestSyncService_Token(t *testing.T) {
+	repo := &mockSyncRepo{Targets: map[int]domain.SyncTarget{}, Changes: domain.SyncChanges{Horizon: 42}}
+	svc := NewSyncService(repo, nil, nil, nil, nil)
+
+	resp, err := svc.Sync(context.Background(), 7, &domain.SyncRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Since != 0 || resp.SyncToken == "" {
+		t.Fatalf("expected a full sync to hand out a token, got since=%d token=%q", repo.Since, resp.SyncToken)
+	}
+
+	if _, err := svc.Sync(context.Background(), 7, &domain.SyncRequest{SyncToken: resp.SyncToken}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Since != 42 {
+		t.Fatalf("expected the token to resume from 42, got %d", repo.Since)
+	}
+
+	if _, err := svc.Sync(context.Background(), 7, &domain.SyncRequest{SyncToken: "not-a-token"}); !apperrors.IsValidationError(err) {
+		t.Fatalf("expected validation error for a bad token, got %v", err)
+	}
+}
@@ -2,31 +2,44 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
+	"book_boy/api/internal/logging"
 	"book_boy/api/internal/repository"
+	"context"
 	"fmt"
+	"log/slog"
 	"time"
 )
 
 type TrackingService interface {
-	StartTracking(userID int, req *domain.StartTrackingRequest) (*domain.Progress, error)
-	GetCurrentTracking(userID int) ([]domain.CurrentTrackingResponse, error)
+	StartTracking(ctx context.Context, userID int, req *domain.StartTrackingRequest) (*domain.Progress, error)
+	GetCurrentTracking(ctx context.Context, userID int) ([]domain.CurrentTrackingResponse, error)
+	GetCurrentTrackingByShelf(ctx context.Context, userID int, shelfID int) ([]domain.CurrentTrackingResponse, error)
 }
 
 type trackingService struct {
 	bookRepo      repository.BookRepo
 	audiobookRepo repository.AudiobookRepo
 	progressRepo  repository.ProgressRepo
+	social        SocialService
+	tx            repository.TxManager
+	views         ProgressViews
+	logger        *slog.Logger
 }
 
-func NewTrackingService(bookRepo repository.BookRepo, audiobookRepo repository.AudiobookRepo, progressRepo repository.ProgressRepo) TrackingService {
+func NewTrackingService(bookRepo repository.BookRepo, audiobookRepo repository.AudiobookRepo, progressRepo repository.ProgressRepo, social SocialService, tx repository.TxManager, views ProgressViews, logger *slog.Logger) TrackingService {
 	return &trackingService{
 		bookRepo:      bookRepo,
 		audiobookRepo: audiobookRepo,
 		progressRepo:  progressRepo,
+		social:        social,
+		tx:            tx,
+		views:         views,
+		logger:        logging.OrDiscard(logger),
 	}
 }
 
-func (s *trackingService) StartTracking(userID int, req *domain.StartTrackingRequest) (*domain.Progress, error) {
+func (s *trackingService) StartTracking(ctx context.Context, userID int, req *domain.StartTrackingRequest) (*domain.Progress, error) {
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
@@ -35,94 +48,136 @@ func (s *trackingService) StartTracking(userID int, req *domain.StartTrackingReq
 		UserID: userID,
 	}
 
-	if req.Format == "book" {
-		book := &domain.Book{
-			Title:      req.Title,
-			TotalPages: req.TotalPages,
-			ISBN:       req.ISBN,
-		}
-		if err := book.Validate(); err != nil {
-			return nil, err
-		}
-
-		bookID, err := s.bookRepo.Create(book)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create book: %w", err)
-		}
+	// The catalog row only exists for this entry, so both land or neither does.
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if req.Format == "book" {
+			book := &domain.Book{
+				Title:      req.Title,
+				TotalPages: req.TotalPages,
+				ISBN:       req.ISBN,
+			}
+			if err := book.Validate(); err != nil {
+				return err
+			}
+			book.LockedFields = book.EnteredFields()
 
-		progress.BookID = &bookID
+			bookID, err := s.bookRepo.Create(ctx, book)
+			if err != nil {
+				return fmt.Errorf("failed to create book: %w", err)
+			}
 
-		currentPage := 1
-		if req.CurrentPage > 0 {
-			currentPage = req.CurrentPage
-		}
-		progress.BookPage = &currentPage
-	}
+			progress.BookID = &bookID
 
-	if req.Format == "audiobook" {
-		duration := &domain.CustomDuration{}
-		parsedDuration, err := time.ParseDuration(req.TotalLength)
-		if err != nil {
-			hms, parseErr := parseHMS(req.TotalLength)
-			if parseErr != nil {
-				return nil, fmt.Errorf("invalid total_length format: use HH:MM:SS or duration string")
+			currentPage := 1
+			if req.CurrentPage > 0 {
+				currentPage = req.CurrentPage
 			}
-			parsedDuration = hms
+			progress.BookPage = &currentPage
 		}
-		duration.Duration = parsedDuration
 
-		audiobook := &domain.Audiobook{
-			Title:       req.Title,
-			TotalLength: duration,
-		}
-		if err := audiobook.Validate(); err != nil {
-			return nil, err
-		}
-
-		audiobookID, err := s.audiobookRepo.Create(audiobook)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create audiobook: %w", err)
-		}
+		if req.Format == "audiobook" {
+			duration := &domain.CustomDuration{}
+			parsedDuration, err := time.ParseDuration(req.TotalLength)
+			if err != nil {
+				hms, parseErr := parseHMS(req.TotalLength)
+				if parseErr != nil {
+					return errors.ErrInvalidField("total_length", "must be HH:MM:SS or a duration string")
+				}
+				parsedDuration = hms
+			}
+			duration.Duration = parsedDuration
 
-		progress.AudiobookID = &audiobookID
+			audiobook := &domain.Audiobook{
+				Title:       req.Title,
+				TotalLength: duration,
+			}
+			if err := audiobook.Validate(); err != nil {
+				return err
+			}
 
-		if req.CurrentTime != "" {
-			currentDuration := &domain.CustomDuration{}
-			parsedTime, err := time.ParseDuration(req.CurrentTime)
+			audiobookID, err := s.audiobookRepo.Create(ctx, audiobook)
 			if err != nil {
-				hms, parseErr := parseHMS(req.CurrentTime)
-				if parseErr != nil {
-					return nil, fmt.Errorf("invalid current_time format: use HH:MM:SS or duration string")
+				return fmt.Errorf("failed to create audiobook: %w", err)
+			}
+
+			progress.AudiobookID = &audiobookID
+
+			if req.CurrentTime != "" {
+				currentDuration := &domain.CustomDuration{}
+				parsedTime, err := time.ParseDuration(req.CurrentTime)
+				if err != nil {
+					hms, parseErr := parseHMS(req.CurrentTime)
+					if parseErr != nil {
+						return errors.ErrInvalidField("current_time", "must be HH:MM:SS or a duration string")
+					}
+					parsedTime = hms
 				}
-				parsedTime = hms
+				currentDuration.Duration = parsedTime
+				progress.AudiobookTime = currentDuration
+			} else {
+				zeroDuration := &domain.CustomDuration{Duration: 0}
+				progress.AudiobookTime = zeroDuration
 			}
-			currentDuration.Duration = parsedTime
-			progress.AudiobookTime = currentDuration
-		} else {
-			zeroDuration := &domain.CustomDuration{Duration: 0}
-			progress.AudiobookTime = zeroDuration
 		}
-	}
 
-	if err := progress.Validate(); err != nil {
-		return nil, err
-	}
+		if err := progress.Validate(); err != nil {
+			return err
+		}
 
-	progressID, err := s.progressRepo.Create(progress)
+		progressID, err := s.progressRepo.Create(ctx, progress)
+		if err != nil {
+			return fmt.Errorf("failed to create progress: %w", err)
+		}
+		progress.ID = progressID
+		progressChanged(ctx, s.views, userID)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create progress: %w", err)
+		return nil, err
 	}
 
-	progress.ID = progressID
+	progressID := progress.ID
+	publishActivity(ctx, s.social, s.logger, &domain.ActivityEvent{
+		UserID:      userID,
+		Type:        domain.ActivityStarted,
+		ProgressID:  &progressID,
+		BookID:      progress.BookID,
+		AudiobookID: progress.AudiobookID,
+	})
 	return progress, nil
 }
 
-func (s *trackingService) GetCurrentTracking(userID int) ([]domain.CurrentTrackingResponse, error) {
-	enriched, err := s.progressRepo.GetAllEnrichedByUser(userID)
+func (s *trackingService) GetCurrentTracking(ctx context.Context, userID int) ([]domain.CurrentTrackingResponse, error) {
+	enriched, err := s.enriched(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current tracking: %w", err)
+	}
+	return toCurrentTrackingResponses(enriched), nil
+}
+
+func (s *trackingService) GetCurrentTrackingByShelf(ctx context.Context, userID int, shelfID int) ([]domain.CurrentTrackingResponse, error) {
+	enriched, err := s.enrichedByShelf(ctx, userID, shelfID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current tracking: %w", err)
 	}
+	return toCurrentTrackingResponses(enriched), nil
+}
+
+func (s *trackingService) enriched(ctx context.Context, userID int) ([]domain.EnrichedProgress, error) {
+	if s.views != nil {
+		return s.views.Enriched(ctx, userID)
+	}
+	return s.progressRepo.GetAllEnrichedByUser(ctx, userID)
+}
+
+func (s *trackingService) enrichedByShelf(ctx context.Context, userID int, shelfID int) ([]domain.EnrichedProgress, error) {
+	if s.views != nil {
+		return s.views.EnrichedByShelf(ctx, userID, shelfID)
+	}
+	return s.progressRepo.GetAllEnrichedByUserShelf(ctx, userID, shelfID)
+}
 
+func toCurrentTrackingResponses(enriched []domain.EnrichedProgress) []domain.CurrentTrackingResponse {
 	responses := make([]domain.CurrentTrackingResponse, 0, len(enriched))
 	for _, e := range enriched {
 		responses = append(responses, domain.CurrentTrackingResponse{
@@ -137,7 +192,7 @@ func (s *trackingService) GetCurrentTracking(userID int) ([]domain.CurrentTracki
 		})
 	}
 
-	return responses, nil
+	return responses
 }
 
 func parseHMS(s string) (time.Duration, error) {
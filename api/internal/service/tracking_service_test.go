@@ -1,18 +1,35 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
 )
 
+// mockTxManager runs the work inline and counts how units of work ended.
+type mockTxManager struct {
+	Committed  int
+	RolledBack int
+}
+
+func (m *mockTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		m.RolledBack++
+		return err
+	}
+	m.Committed++
+	return nil
+}
+
 func TestTrackingService_StartTracking_Book(t *testing.T) {
 	bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 	audiobookRepo := &mockAudiobookRepo{}
 	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 	req := &domain.StartTrackingRequest{
 		Format:     "book",
@@ -21,7 +38,7 @@ func TestTrackingService_StartTracking_Book(t *testing.T) {
 		ISBN:       "9780743273565",
 	}
 
-	progress, err := svc.StartTracking(1, req)
+	progress, err := svc.StartTracking(context.Background(), 1, req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -39,11 +56,30 @@ func TestTrackingService_StartTracking_Book(t *testing.T) {
 	}
 }
 
+func TestTrackingService_StartTracking_RollsBackBookWhenProgressFails(t *testing.T) {
+	bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
+	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress), Err: errors.New("db error")}
+	tx := &mockTxManager{}
+	svc := NewTrackingService(bookRepo, &mockAudiobookRepo{}, progressRepo, nil, tx, nil, nil)
+
+	_, err := svc.StartTracking(context.Background(), 1, &domain.StartTrackingRequest{
+		Format:     "book",
+		Title:      "The Great Gatsby",
+		TotalPages: 300,
+	})
+	if err == nil {
+		t.Fatal("expected error from progress repo")
+	}
+	if len(bookRepo.Books) != 1 || tx.RolledBack != 1 || tx.Committed != 0 {
+		t.Fatalf("expected the book write to share a rolled back unit of work, got %d books, %+v", len(bookRepo.Books), tx)
+	}
+}
+
 func TestTrackingService_StartTracking_BookWithCurrentPage(t *testing.T) {
 	bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 	audiobookRepo := &mockAudiobookRepo{}
 	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 	req := &domain.StartTrackingRequest{
 		Format:      "book",
@@ -53,7 +89,7 @@ func TestTrackingService_StartTracking_BookWithCurrentPage(t *testing.T) {
 		CurrentPage: 50,
 	}
 
-	progress, err := svc.StartTracking(1, req)
+	progress, err := svc.StartTracking(context.Background(), 1, req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -66,7 +102,7 @@ func TestTrackingService_StartTracking_Audiobook(t *testing.T) {
 	bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 	audiobookRepo := &mockAudiobookRepo{}
 	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 	req := &domain.StartTrackingRequest{
 		Format:      "audiobook",
@@ -74,7 +110,7 @@ func TestTrackingService_StartTracking_Audiobook(t *testing.T) {
 		TotalLength: "10:30:00",
 	}
 
-	progress, err := svc.StartTracking(1, req)
+	progress, err := svc.StartTracking(context.Background(), 1, req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -93,7 +129,7 @@ func TestTrackingService_StartTracking_AudiobookWithCurrentTime(t *testing.T) {
 	bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 	audiobookRepo := &mockAudiobookRepo{}
 	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 	req := &domain.StartTrackingRequest{
 		Format:      "audiobook",
@@ -102,7 +138,7 @@ func TestTrackingService_StartTracking_AudiobookWithCurrentTime(t *testing.T) {
 		CurrentTime: "2:15:00",
 	}
 
-	progress, err := svc.StartTracking(1, req)
+	progress, err := svc.StartTracking(context.Background(), 1, req)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -116,14 +152,14 @@ func TestTrackingService_StartTracking_ValidationErrors(t *testing.T) {
 	bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 	audiobookRepo := &mockAudiobookRepo{}
 	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+	svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 	t.Run("book missing total_pages", func(t *testing.T) {
 		req := &domain.StartTrackingRequest{
 			Format: "book",
 			Title:  "Some Book",
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected validation error")
 		}
@@ -134,7 +170,7 @@ func TestTrackingService_StartTracking_ValidationErrors(t *testing.T) {
 			Format: "audiobook",
 			Title:  "Some Audiobook",
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected validation error")
 		}
@@ -146,7 +182,7 @@ func TestTrackingService_StartTracking_ValidationErrors(t *testing.T) {
 			Title:       "Some Audiobook",
 			TotalLength: "not-a-duration",
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected error for invalid duration format")
 		}
@@ -159,10 +195,14 @@ func TestTrackingService_StartTracking_ValidationErrors(t *testing.T) {
 			TotalLength: "10:00:00",
 			CurrentTime: "not-a-time",
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected error for invalid current_time format")
 		}
+		var validation apperrors.ValidationError
+		if !apperrors.As(err, &validation) || validation.Field != "current_time" {
+			t.Fatalf("expected a validation error on current_time, got %v", err)
+		}
 	})
 }
 
@@ -171,14 +211,14 @@ func TestTrackingService_StartTracking_RepoErrors(t *testing.T) {
 		bookRepo := &mockBookRepo{Books: make(map[int]domain.Book), Err: errors.New("db error")}
 		audiobookRepo := &mockAudiobookRepo{}
 		progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-		svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+		svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 		req := &domain.StartTrackingRequest{
 			Format:     "book",
 			Title:      "Test Book",
 			TotalPages: 200,
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected error from book repo")
 		}
@@ -188,14 +228,14 @@ func TestTrackingService_StartTracking_RepoErrors(t *testing.T) {
 		bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 		audiobookRepo := &mockAudiobookRepo{Err: errors.New("db error")}
 		progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-		svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+		svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 		req := &domain.StartTrackingRequest{
 			Format:      "audiobook",
 			Title:       "Test Audiobook",
 			TotalLength: "5:00:00",
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected error from audiobook repo")
 		}
@@ -205,14 +245,14 @@ func TestTrackingService_StartTracking_RepoErrors(t *testing.T) {
 		bookRepo := &mockBookRepo{Books: make(map[int]domain.Book)}
 		audiobookRepo := &mockAudiobookRepo{}
 		progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress), Err: errors.New("db error")}
-		svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo)
+		svc := NewTrackingService(bookRepo, audiobookRepo, progressRepo, nil, &mockTxManager{}, nil, nil)
 
 		req := &domain.StartTrackingRequest{
 			Format:     "book",
 			Title:      "Test Book",
 			TotalPages: 200,
 		}
-		_, err := svc.StartTracking(1, req)
+		_, err := svc.StartTracking(context.Background(), 1, req)
 		if err == nil {
 			t.Fatal("expected error from progress repo")
 		}
@@ -228,9 +268,9 @@ func TestTrackingService_GetCurrentTracking(t *testing.T) {
 			2: {ID: 2, UserID: 2, BookID: &bookID, BookPage: &page},
 		},
 	}
-	svc := NewTrackingService(nil, nil, progressRepo)
+	svc := NewTrackingService(nil, nil, progressRepo, nil, &mockTxManager{}, nil, nil)
 
-	responses, err := svc.GetCurrentTracking(1)
+	responses, err := svc.GetCurrentTracking(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -247,9 +287,9 @@ func TestTrackingService_GetCurrentTracking_Error(t *testing.T) {
 		Data: make(map[int]domain.Progress),
 		Err:  errors.New("db error"),
 	}
-	svc := NewTrackingService(nil, nil, progressRepo)
+	svc := NewTrackingService(nil, nil, progressRepo, nil, &mockTxManager{}, nil, nil)
 
-	_, err := svc.GetCurrentTracking(1)
+	_, err := svc.GetCurrentTracking(context.Background(), 1)
 	if err == nil {
 		t.Fatal("expected error from progress repo")
 	}
@@ -257,9 +297,9 @@ func TestTrackingService_GetCurrentTracking_Error(t *testing.T) {
 
 func TestTrackingService_GetCurrentTracking_Empty(t *testing.T) {
 	progressRepo := &mockProgressRepo{Data: make(map[int]domain.Progress)}
-	svc := NewTrackingService(nil, nil, progressRepo)
+	svc := NewTrackingService(nil, nil, progressRepo, nil, &mockTxManager{}, nil, nil)
 
-	responses, err := svc.GetCurrentTracking(1)
+	responses, err := svc.GetCurrentTracking(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -267,3 +307,24 @@ func TestTrackingService_GetCurrentTracking_Empty(t *testing.T) {
 		t.Fatalf("expected 0 responses, got %d", len(responses))
 	}
 }
+
+func TestTrackingService_GetCurrentTrackingByShelf(t *testing.T) {
+	bookID := 1
+	audiobookID := 2
+	progressRepo := &mockProgressRepo{
+		Data: map[int]domain.Progress{
+			1: {ID: 1, UserID: 1, BookID: &bookID},
+			2: {ID: 2, UserID: 1, AudiobookID: &audiobookID},
+		},
+		Shelves: map[int][]int{3: {2}},
+	}
+	svc := NewTrackingService(nil, nil, progressRepo, nil, &mockTxManager{}, nil, nil)
+
+	responses, err := svc.GetCurrentTrackingByShelf(context.Background(), 1, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(responses) != 1 || responses[0].ProgressID != 2 {
+		t.Fatalf("expected only progress 2 on shelf 3, got %+v", responses)
+	}
+}
@@ -1,42 +1,140 @@
 package service
 
 import (
+	"context"
+
 	"book_boy/api/internal/domain"
+	"book_boy/api/internal/errors"
 	"book_boy/api/internal/repository"
 )
 
 type UserService interface {
-	GetAll() ([]domain.User, error)
-	GetByID(id int) (*domain.User, error)
-	Create(user *domain.User) (int, error)
-	Update(user *domain.User) error
-	Delete(id int) error
+	GetAll(ctx context.Context, viewerID int) ([]domain.User, error)
+	GetByID(ctx context.Context, viewerID int, id int) (*domain.User, error)
+	Create(ctx context.Context, user *domain.User) (int, error)
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, id int) error
+	UpdatePrivacy(ctx context.Context, id int, req *domain.UpdatePrivacyRequest) (*domain.PrivacySettings, error)
 }
 
 type userService struct {
-	repo repository.UserRepo
+	repo       repository.UserRepo
+	followRepo repository.FollowRepo
+}
+
+func NewUserService(repo repository.UserRepo, followRepo repository.FollowRepo) UserService {
+	return &userService{repo: repo, followRepo: followRepo}
 }
 
-func NewUserService(repo repository.UserRepo) UserService {
-	return &userService{repo: repo}
+// GetAll lists the users whose profile viewerID may see.
+func (s *userService) GetAll(ctx context.Context, viewerID int) ([]domain.User, error) {
+	users, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var following map[int]bool
+	visible := make([]domain.User, 0, len(users))
+	for _, user := range users {
+		isFollower := false
+		if user.ID != viewerID && user.ProfilePrivacy == domain.PrivacyFollowers {
+			if following == nil {
+				if following, err = s.followingSet(ctx, viewerID); err != nil {
+					return nil, err
+				}
+			}
+			isFollower = following[user.ID]
+		}
+		if !user.ProfilePrivacy.Allows(user.ID == viewerID, isFollower) {
+			continue
+		}
+		visible = append(visible, redactUser(viewerID, user))
+	}
+	return visible, nil
 }
 
-func (s *userService) GetAll() ([]domain.User, error) {
-	return s.repo.GetAll()
+// GetByID returns ErrForbidden when the user's profile privacy hides them
+// from viewerID, like the social profile does.
+func (s *userService) GetByID(ctx context.Context, viewerID int, id int) (*domain.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	isFollower := false
+	if id != viewerID && user.ProfilePrivacy == domain.PrivacyFollowers {
+		if isFollower, err = s.followRepo.IsFollowing(ctx, viewerID, id); err != nil {
+			return nil, err
+		}
+	}
+	if !user.ProfilePrivacy.Allows(id == viewerID, isFollower) {
+		return nil, errors.ErrForbidden
+	}
+	redacted := redactUser(viewerID, *user)
+	return &redacted, nil
 }
 
-func (s *userService) GetByID(id int) (*domain.User, error) {
-	return s.repo.GetByID(id)
+func (s *userService) followingSet(ctx context.Context, userID int) (map[int]bool, error) {
+	followees, err := s.followRepo.GetFollowing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[int]bool, len(followees))
+	for _, followee := range followees {
+		set[followee.ID] = true
+	}
+	return set, nil
 }
 
-func (s *userService) Create(user *domain.User) (int, error) {
-	return s.repo.Create(user)
+// redactUser drops the email from anyone's record but the viewer's own.
+func redactUser(viewerID int, user domain.User) domain.User {
+	if user.ID != viewerID {
+		user.Email = ""
+	}
+	return user
 }
 
-func (s *userService) Update(user *domain.User) error {
-	return s.repo.Update(user)
+func (s *userService) Create(ctx context.Context, user *domain.User) (int, error) {
+	return s.repo.Create(ctx, user)
 }
 
-func (s *userService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *userService) Update(ctx context.Context, user *domain.User) error {
+	return s.repo.Update(ctx, user)
+}
+
+func (s *userService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *userService) UpdatePrivacy(ctx context.Context, id int, req *domain.UpdatePrivacyRequest) (*domain.PrivacySettings, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	settings := &domain.PrivacySettings{
+		ProfilePrivacy:  user.ProfilePrivacy,
+		ProgressPrivacy: user.ProgressPrivacy,
+		ReviewPrivacy:   user.ReviewPrivacy,
+	}
+	if req.ProfilePrivacy != nil {
+		settings.ProfilePrivacy = *req.ProfilePrivacy
+	}
+	if req.ProgressPrivacy != nil {
+		settings.ProgressPrivacy = *req.ProgressPrivacy
+	}
+	if req.ReviewPrivacy != nil {
+		settings.ReviewPrivacy = *req.ReviewPrivacy
+	}
+
+	if !settings.ProfilePrivacy.IsValid() || !settings.ProgressPrivacy.IsValid() || !settings.ReviewPrivacy.IsValid() {
+		return nil, errors.ErrInvalidInput("privacy levels must be public, followers or private")
+	}
+	if err := s.repo.UpdatePrivacy(ctx, id, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
 }
@@ -2,6 +2,8 @@ package service
 
 import (
 	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
+	"context"
 	"errors"
 	"testing"
 )
@@ -11,7 +13,7 @@ type mockUserRepo struct {
 	Err   error
 }
 
-func (m *mockUserRepo) GetAll() ([]domain.User, error) {
+func (m *mockUserRepo) GetAll(ctx context.Context) ([]domain.User, error) {
 	var result []domain.User
 	for _, user := range m.Users {
 		result = append(result, user)
@@ -19,14 +21,14 @@ func (m *mockUserRepo) GetAll() ([]domain.User, error) {
 	return result, m.Err
 }
 
-func (m *mockUserRepo) GetByID(id int) (*domain.User, error) {
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	if user, ok := m.Users[id]; ok {
 		return &user, nil
 	}
 	return nil, nil
 }
 
-func (m *mockUserRepo) GetByEmail(email string) (*domain.User, error) {
+func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	for _, user := range m.Users {
 		if user.Email == email {
 			return &user, nil
@@ -35,7 +37,7 @@ func (m *mockUserRepo) GetByEmail(email string) (*domain.User, error) {
 	return nil, nil
 }
 
-func (m *mockUserRepo) Create(user *domain.User) (int, error) {
+func (m *mockUserRepo) Create(ctx context.Context, user *domain.User) (int, error) {
 	if m.Err != nil {
 		return 0, m.Err
 	}
@@ -45,7 +47,7 @@ func (m *mockUserRepo) Create(user *domain.User) (int, error) {
 	return id, nil
 }
 
-func (m *mockUserRepo) Update(user *domain.User) error {
+func (m *mockUserRepo) Update(ctx context.Context, user *domain.User) error {
 	if _, exists := m.Users[user.ID]; !exists {
 		return errors.New("user not found")
 	}
@@ -53,16 +55,28 @@ func (m *mockUserRepo) Update(user *domain.User) error {
 	return nil
 }
 
-func (m *mockUserRepo) Delete(id int) error {
+func (m *mockUserRepo) Delete(ctx context.Context, id int) error {
 	delete(m.Users, id)
 	return nil
 }
 
+func (m *mockUserRepo) UpdatePrivacy(ctx context.Context, id int, settings *domain.PrivacySettings) error {
+	user, exists := m.Users[id]
+	if !exists {
+		return errors.New("user not found")
+	}
+	user.ProfilePrivacy = settings.ProfilePrivacy
+	user.ProgressPrivacy = settings.ProgressPrivacy
+	user.ReviewPrivacy = settings.ReviewPrivacy
+	m.Users[id] = user
+	return nil
+}
+
 func TestUserService_GetAll_Error(t *testing.T) {
 	repo := &mockUserRepo{Users: make(map[int]domain.User), Err: errors.New("db error")}
-	svc := NewUserService(repo)
+	svc := NewUserService(repo, nil)
 
-	_, err := svc.GetAll()
+	_, err := svc.GetAll(context.Background(), 1)
 	if err == nil {
 		t.Fatal("expected error from repo")
 	}
@@ -70,9 +84,9 @@ func TestUserService_GetAll_Error(t *testing.T) {
 
 func TestUserService_GetByID_NotFound(t *testing.T) {
 	repo := &mockUserRepo{Users: make(map[int]domain.User)}
-	svc := NewUserService(repo)
+	svc := NewUserService(repo, nil)
 
-	user, err := svc.GetByID(999)
+	user, err := svc.GetByID(context.Background(), 1, 999)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -83,35 +97,110 @@ func TestUserService_GetByID_NotFound(t *testing.T) {
 
 func TestUserService_CRUD(t *testing.T) {
 	repo := &mockUserRepo{Users: make(map[int]domain.User)}
-	service := NewUserService(repo)
+	service := NewUserService(repo, nil)
 
 	user := &domain.User{Username: "test_user"}
-	_, err := service.Create(user)
+	_, err := service.Create(context.Background(), user)
 	if err != nil || user.ID == 0 {
 		t.Fatalf("Create failed: %v", err)
 	}
 
-	fetched, _ := service.GetByID(user.ID)
+	fetched, _ := service.GetByID(context.Background(), user.ID, user.ID)
 	if fetched == nil || fetched.Username != "test_user" {
 		t.Fatalf("GetByID failed")
 	}
 
 	user.Username = "updated_user"
-	if err := service.Update(user); err != nil {
+	if err := service.Update(context.Background(), user); err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
 
-	updated, _ := service.GetByID(user.ID)
+	updated, _ := service.GetByID(context.Background(), user.ID, user.ID)
 	if updated.Username != "updated_user" {
 		t.Fatalf("Update did not persist")
 	}
 
-	if err := service.Delete(user.ID); err != nil {
+	if err := service.Delete(context.Background(), user.ID); err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
-	deleted, _ := service.GetByID(user.ID)
+	deleted, _ := service.GetByID(context.Background(), user.ID, user.ID)
 	if deleted != nil {
 		t.Fatalf("Delete did not persist")
 	}
 }
+
+func TestUserService_UpdatePrivacy(t *testing.T) {
+	repo := &mockUserRepo{Users: map[int]domain.User{
+		1: {ID: 1, Username: "reader", ReviewPrivacy: domain.PrivacyPublic, ProfilePrivacy: domain.PrivacyPublic, ProgressPrivacy: domain.PrivacyPublic},
+	}}
+	service := NewUserService(repo, nil)
+
+	private := domain.PrivacyPrivate
+	followers := domain.PrivacyFollowers
+	settings, err := service.UpdatePrivacy(context.Background(), 1, &domain.UpdatePrivacyRequest{ReviewPrivacy: &private, ProgressPrivacy: &followers})
+	if err != nil {
+		t.Fatalf("UpdatePrivacy failed: %v", err)
+	}
+	if settings.ProfilePrivacy != domain.PrivacyPublic {
+		t.Fatalf("expected untouched profile privacy to stay public, got %q", settings.ProfilePrivacy)
+	}
+	if repo.Users[1].ReviewPrivacy != domain.PrivacyPrivate || repo.Users[1].ProgressPrivacy != domain.PrivacyFollowers {
+		t.Fatalf("privacy not persisted: %+v", repo.Users[1])
+	}
+
+	invalid := domain.PrivacyLevel("everyone")
+	if _, err := service.UpdatePrivacy(context.Background(), 1, &domain.UpdatePrivacyRequest{ReviewPrivacy: &invalid}); err == nil {
+		t.Fatal("expected error for invalid privacy level")
+	}
+	if _, err := service.UpdatePrivacy(context.Background(), 99, &domain.UpdatePrivacyRequest{}); err != apperrors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUserService_HonoursProfilePrivacy(t *testing.T) {
+	repo := &mockUserRepo{Users: map[int]domain.User{
+		1: {ID: 1, Username: "viewer", Email: "viewer@example.com", ProfilePrivacy: domain.PrivacyPublic},
+		2: {ID: 2, Username: "public", Email: "public@example.com", ProfilePrivacy: domain.PrivacyPublic},
+		3: {ID: 3, Username: "followed", Email: "followed@example.com", ProfilePrivacy: domain.PrivacyFollowers},
+		4: {ID: 4, Username: "unfollowed", Email: "unfollowed@example.com", ProfilePrivacy: domain.PrivacyFollowers},
+		5: {ID: 5, Username: "private", Email: "private@example.com", ProfilePrivacy: domain.PrivacyPrivate},
+	}}
+	follows := &mockFollowRepo{Follows: map[[2]int]bool{{1, 3}: true, {1, 5}: true}}
+	svc := NewUserService(repo, follows)
+
+	users, err := svc.GetAll(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	emails := map[int]string{}
+	for _, user := range users {
+		emails[user.ID] = user.Email
+	}
+	if len(emails) != 3 {
+		t.Fatalf("expected the viewer, the public user and the followed user, got %+v", users)
+	}
+	if emails[1] != "viewer@example.com" {
+		t.Errorf("expected the viewer's own email, got %q", emails[1])
+	}
+	if emails[2] != "" || emails[3] != "" {
+		t.Errorf("expected other users' emails to be hidden, got %+v", emails)
+	}
+
+	if _, err := svc.GetByID(context.Background(), 1, 4); err != apperrors.ErrForbidden {
+		t.Errorf("expected ErrForbidden for a followers-only profile, got %v", err)
+	}
+	if _, err := svc.GetByID(context.Background(), 1, 5); err != apperrors.ErrForbidden {
+		t.Errorf("expected ErrForbidden for a private profile, got %v", err)
+	}
+	user, err := svc.GetByID(context.Background(), 1, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Email != "" {
+		t.Errorf("expected the email to be hidden, got %q", user.Email)
+	}
+	if repo.Users[3].Email != "followed@example.com" {
+		t.Error("expected redaction not to touch the stored user")
+	}
+}
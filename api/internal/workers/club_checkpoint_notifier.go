@@ -0,0 +1,91 @@
+package workers
+
+import (
+	"book_boy/api/internal/logging"
+	"book_boy/api/internal/service"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ClubCheckpointNotifier periodically opens club checkpoints whose start time
+// has passed and lets the club service notify members over SSE.
+type ClubCheckpointNotifier struct {
+	service  service.ClubService
+	interval time.Duration
+	logger   *slog.Logger
+	stop     chan struct{}
+	done     chan struct{}
+	// runs is cancelled when Stop gives up waiting on a run.
+	runs       context.Context
+	cancelRuns context.CancelFunc
+}
+
+func NewClubCheckpointNotifier(svc service.ClubService, interval time.Duration, logger *slog.Logger) *ClubCheckpointNotifier {
+	n := &ClubCheckpointNotifier{
+		service:  svc,
+		interval: interval,
+		logger:   logging.OrDiscard(logger),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	n.runs, n.cancelRuns = context.WithCancel(context.Background())
+	return n
+}
+
+func (n *ClubCheckpointNotifier) Start() {
+	n.logger.Info("club checkpoint notifier started", "interval", n.interval)
+
+	go func() {
+		defer close(n.done)
+		ticker := time.NewTicker(n.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				n.notify()
+			case <-n.stop:
+				return
+			}
+		}
+	}()
+}
+
+// notify gives each run until the next tick to finish.
+func (n *ClubCheckpointNotifier) notify() {
+	ctx, cancel := context.WithTimeout(n.runs, n.interval)
+	defer cancel()
+
+	count, err := n.service.NotifyOpenedCheckpoints(ctx)
+	if err != nil {
+		n.logger.Error("failed to notify opened checkpoints", "error", err)
+		return
+	}
+	if count > 0 {
+		n.logger.Info("notified members of opened checkpoints", "checkpoints", count)
+	}
+}
+
+// Stop waits for a run in progress to finish, cancelling it if ctx is done
+// first.
+func (n *ClubCheckpointNotifier) Stop(ctx context.Context) error {
+	close(n.stop)
+	return awaitRun(ctx, n.done, n.cancelRuns)
+}
+
+// awaitRun waits for a worker's loop to exit. If ctx is done first the run in
+// progress is cancelled, and the loop, which only waits on that run, exits
+// soon after.
+func awaitRun(ctx context.Context, done <-chan struct{}, cancel context.CancelFunc) error {
+	defer cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return fmt.Errorf("run cancelled: %w", ctx.Err())
+	}
+}
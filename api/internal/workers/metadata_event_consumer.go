@@ -2,36 +2,131 @@ package workers
 
 import (
 	"book_boy/api/internal/domain"
+	apperrors "book_boy/api/internal/errors"
 	"book_boy/api/internal/infra"
+	"book_boy/api/internal/logging"
 	"book_boy/api/internal/service"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// metadataEventTimeout bounds the database and cache work for one event.
+const metadataEventTimeout = 30 * time.Second
+
+// metadataResubscribeDelay is the first wait before resubscribing when the
+// broker cancels the consumer; it doubles up to metadataMaxResubscribeDelay.
+const (
+	metadataResubscribeDelay    = time.Second
+	metadataMaxResubscribeDelay = 30 * time.Second
+)
+
 type MetadataEventConsumer struct {
-	conn       *amqp.Connection
+	conn       *infra.AMQPConnection
 	service    service.BookService
 	sseManager *infra.SSEManager
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	channel *amqp.Channel
+	stopped bool
+	stop    chan struct{}
+	// running counts the delivery loops, one per subscription.
+	running sync.WaitGroup
 }
 
-func NewMetadataEventConsumer(conn *amqp.Connection, svc service.BookService, sseMgr *infra.SSEManager) *MetadataEventConsumer {
+func NewMetadataEventConsumer(conn *infra.AMQPConnection, svc service.BookService, sseMgr *infra.SSEManager, logger *slog.Logger) *MetadataEventConsumer {
 	return &MetadataEventConsumer{
 		conn:       conn,
 		service:    svc,
 		sseManager: sseMgr,
+		logger:     logging.OrDiscard(logger),
+		stop:       make(chan struct{}),
 	}
 }
 
+// Start subscribes, and subscribes again each time the connection is
+// re-established.
 func (c *MetadataEventConsumer) Start() error {
-	ch, err := c.conn.Channel()
+	return c.conn.Register(c.subscribe)
+}
+
+// subscribe opens a channel on conn, declares the queue and its binding, and
+// starts a delivery loop.
+func (c *MetadataEventConsumer) subscribe(conn *amqp.Connection) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return nil
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	queue, msgs, err := declareMetadataQueue(ch)
 	if err != nil {
+		ch.Close()
 		return err
 	}
 
-	err = ch.ExchangeDeclare(
+	c.logger.Info("metadata event consumer started", "queue", queue)
+	if c.channel != nil && !c.channel.IsClosed() {
+		c.channel.Close()
+	}
+	c.channel = ch
+
+	c.running.Add(1)
+	go func() {
+		defer c.running.Done()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					c.resubscribe(conn)
+					return
+				}
+				c.handle(queue, msg)
+			}
+		}
+	}()
+	return nil
+}
+
+// resubscribe runs when deliveries stop. If the connection dropped, the
+// reconnect subscribes again; if only the subscription ended, say because
+// the broker cancelled it, it's retried here on the same connection.
+func (c *MetadataEventConsumer) resubscribe(conn *amqp.Connection) {
+	for delay := metadataResubscribeDelay; ; delay = min(2*delay, metadataMaxResubscribeDelay) {
+		if conn.IsClosed() {
+			return
+		}
+		c.logger.Warn("metadata event deliveries stopped, resubscribing", "retry_in", delay)
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(delay):
+		}
+		if conn.IsClosed() {
+			return
+		}
+		err := c.subscribe(conn)
+		if err == nil {
+			return
+		}
+		c.logger.Error("failed to resubscribe to metadata events", "error", err)
+	}
+}
+
+func declareMetadataQueue(ch *amqp.Channel) (string, <-chan amqp.Delivery, error) {
+	err := ch.ExchangeDeclare(
 		"book_events",
 		"topic",
 		true,
@@ -41,7 +136,7 @@ func (c *MetadataEventConsumer) Start() error {
 		nil,
 	)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	queue, err := ch.QueueDeclare(
@@ -53,7 +148,7 @@ func (c *MetadataEventConsumer) Start() error {
 		nil,
 	)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	err = ch.QueueBind(
@@ -64,7 +159,7 @@ func (c *MetadataEventConsumer) Start() error {
 		nil,
 	)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	msgs, err := ch.Consume(
@@ -77,51 +172,84 @@ func (c *MetadataEventConsumer) Start() error {
 		nil,
 	)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
+	return queue.Name, msgs, nil
+}
 
-	log.Println("Metadata event consumer started, waiting for book.metadata_fetched events...")
+// Stop lets the event being handled finish, then closes the channel, which
+// hands any delivered but unacknowledged events back to the queue. It gives
+// up waiting when ctx is done.
+func (c *MetadataEventConsumer) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	close(c.stop)
 
+	done := make(chan struct{})
 	go func() {
-		for msg := range msgs {
-			if err := c.handleMetadataFetched(msg.Body); err != nil {
-				log.Printf("Error handling metadata event: %v\n", err)
-				msg.Nack(false, true)
-			} else {
-				msg.Ack(false)
-			}
-		}
+		c.running.Wait()
+		close(done)
 	}()
 
-	return nil
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("metadata event still being handled: %w", ctx.Err())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channel != nil && !c.channel.IsClosed() {
+		c.channel.Close()
+	}
+	return err
+}
+
+func (c *MetadataEventConsumer) handle(queue string, msg amqp.Delivery) {
+	// The book service's request ID and trace come back with the event, so
+	// the round-trip logs and traces as one request.
+	ctx := infra.MessageContext(context.Background(), msg.Headers)
+	ctx, span := infra.StartProcessSpan(ctx, queue, msg)
+	err := c.handleMetadataFetched(ctx, msg.Body)
+	infra.EndSpan(span, err)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to handle metadata event", "error", err)
+		infra.AMQPConsumeFailures.WithLabelValues(queue).Inc()
+		msg.Nack(false, true)
+		return
+	}
+	infra.AMQPConsumed.WithLabelValues(queue).Inc()
+	msg.Ack(false)
 }
 
-func (c *MetadataEventConsumer) handleMetadataFetched(body []byte) error {
+func (c *MetadataEventConsumer) handleMetadataFetched(ctx context.Context, body []byte) error {
 	var event domain.BookMetadataFetchedEvent
 	if err := json.Unmarshal(body, &event); err != nil {
 		return fmt.Errorf("failed to unmarshal event: %w", err)
 	}
 
-	log.Printf("Received metadata for book %d: %s (%d pages)\n", event.BookID, event.Title, event.TotalPages)
+	c.logger.InfoContext(ctx, "received book metadata", "book_id", event.BookID, "title", event.Title, "total_pages", event.TotalPages)
 
 	if !event.Success {
-		log.Printf("Metadata fetch failed for book %d: %s\n", event.BookID, event.Error)
+		c.logger.WarnContext(ctx, "metadata fetch failed", "book_id", event.BookID, "error", event.Error)
 		return nil
 	}
 
-	book, err := c.service.GetByID(event.BookID)
-	if err != nil {
-		return fmt.Errorf("failed to get book: %w", err)
-	}
-
-	book.Title = event.Title
-	book.TotalPages = event.TotalPages
+	ctx, cancel := context.WithTimeout(ctx, metadataEventTimeout)
+	defer cancel()
 
-	if err := c.service.Update(book); err != nil {
-		return fmt.Errorf("failed to update book: %w", err)
+	book, err := c.service.ApplyMetadata(ctx, &event)
+	if err == apperrors.ErrNotFound {
+		c.logger.InfoContext(ctx, "book no longer exists, dropping metadata", "book_id", event.BookID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply metadata: %w", err)
 	}
 
-	log.Printf("Successfully updated book %d with metadata\n", event.BookID)
+	c.logger.InfoContext(ctx, "applied book metadata", "book_id", event.BookID)
 
 	c.sseManager.Broadcast("book.metadata_fetched", book)
 
@@ -0,0 +1,75 @@
+package workers
+
+import (
+	"book_boy/api/internal/logging"
+	"book_boy/api/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RecommendationWorker periodically recomputes every active user's
+// recommendations so GET /recommendations is served from cache.
+type RecommendationWorker struct {
+	service  service.RecommendationService
+	interval time.Duration
+	logger   *slog.Logger
+	stop     chan struct{}
+	done     chan struct{}
+	// runs is cancelled when Stop gives up waiting on a refresh.
+	runs       context.Context
+	cancelRuns context.CancelFunc
+}
+
+func NewRecommendationWorker(svc service.RecommendationService, interval time.Duration, logger *slog.Logger) *RecommendationWorker {
+	w := &RecommendationWorker{
+		service:  svc,
+		interval: interval,
+		logger:   logging.OrDiscard(logger),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.runs, w.cancelRuns = context.WithCancel(context.Background())
+	return w
+}
+
+func (w *RecommendationWorker) Start() {
+	w.logger.Info("recommendation worker started", "interval", w.interval)
+
+	go func() {
+		defer close(w.done)
+		w.refresh()
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.refresh()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop waits for a refresh in progress to finish, cancelling it if ctx is
+// done first.
+func (w *RecommendationWorker) Stop(ctx context.Context) error {
+	close(w.stop)
+	return awaitRun(ctx, w.done, w.cancelRuns)
+}
+
+// refresh gives each run until the next tick to finish.
+func (w *RecommendationWorker) refresh() {
+	ctx, cancel := context.WithTimeout(w.runs, w.interval)
+	defer cancel()
+
+	count, err := w.service.RefreshAll(ctx)
+	if err != nil {
+		w.logger.Error("failed to refresh recommendations", "error", err)
+		return
+	}
+	w.logger.Info("refreshed recommendations", "users", count)
+}
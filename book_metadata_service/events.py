@@ -36,12 +36,13 @@ class EventPublisher:
             durable=True
         )
 
-    async def publish(self, routing_key: str, event: BaseModel):
+    async def publish(self, routing_key: str, event: BaseModel, headers: Optional[dict] = None):
         body = json.dumps(event.model_dump()).encode()
         message = Message(
             body,
             delivery_mode=DeliveryMode.PERSISTENT,
-            content_type='application/json'
+            content_type='application/json',
+            headers=headers or {}
         )
 
         #TODO fix this
@@ -42,7 +42,7 @@ function Auth({ onLogin, apiUrl }: AuthProps) {
       const data = await response.json().catch(() => null)
 
       if (!response.ok) {
-        throw new Error(data?.error || 'Authentication failed')
+        throw new Error(data?.detail || 'Authentication failed')
       }
 
       onLogin(data)
@@ -69,7 +69,7 @@ function Auth({ onLogin, apiUrl }: AuthProps) {
       const data = await response.json().catch(() => null)
 
       if (!response.ok) {
-        throw new Error(data?.error || 'Demo account not available')
+        throw new Error(data?.detail || 'Demo account not available')
       }
 
       onLogin(data)
@@ -28,7 +28,7 @@ export function createApi(baseUrl: string, token: string, onUnauthorized: () =>
 
     if (!response.ok) {
       const data = await response.json().catch(() => ({}))
-      throw new ApiError(data.error || 'Request failed', response.status)
+      throw new ApiError(data.detail || 'Request failed', response.status)
     }
 
     const text = await response.text()